@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/config"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// runCleanup handles `axiom cleanup [--dry-run]`, deleting stale and reclaimable
+// workspaces according to the retention policy. It returns the process exit code.
+func runCleanup(projectDir, axiomDir, caseFile string, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dryRun := fs.Bool("dry-run", false, "list what would be deleted without deleting it")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(axiomDir)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "cleanup: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+	m, err := workspace.New(ctx, projectDir, cfg.Workspaces)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "cleanup: %v\n", err)
+		return 1
+	}
+
+	if !*dryRun {
+		c, err := m.Clean(ctx)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "cleanup: %v\n", err)
+			return 1
+		}
+		for _, b := range c.Kept {
+			_, _ = fmt.Fprintf(stdout, "kept     %s (unmerged commits, no workspace)\n", b)
+		}
+	}
+
+	cases, err := casestore.NewCaseStore().Load(caseFile)
+	if err != nil && !os.IsNotExist(err) {
+		_, _ = fmt.Fprintf(stderr, "cleanup: %v\n", err)
+		return 1
+	}
+	outcomes := make(map[string]casestore.Status, len(cases))
+	for _, c := range cases {
+		outcomes[c.ID] = c.Status
+	}
+	report, err := m.Scan(ctx, axiomDir, cfg.Workspaces.Retention, outcomes)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "cleanup: %v\n", err)
+		return 1
+	}
+
+	for _, d := range report.Workspaces {
+		action := "keep"
+		if d.Delete {
+			action = "delete"
+		}
+		_, _ = fmt.Fprintf(stdout, "%-8s %-20s %10d  %s\n", action, d.TaskID, d.Bytes, d.Reason)
+	}
+	if *dryRun {
+		_, _ = fmt.Fprintf(stdout, "%d bytes reclaimable\n", report.Reclaimable)
+		return 0
+	}
+
+	freed, err := m.Apply(ctx, report.Workspaces)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "cleanup: %v\n", err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(stdout, "%d bytes freed\n", freed)
+	return 0
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/deligoez/axiom/internal/autopilot"
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/config"
+	"github.com/deligoez/axiom/internal/integration"
+	"github.com/deligoez/axiom/internal/scaffold"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// Exit codes of axiom (docs/13-reference.md, "Exit Codes").
+const (
+	// exitSuccess: every task is done and merged.
+	exitSuccess = 0
+	// exitFailure: the run could not start, merged none of the tasks it completed,
+	// or was ended by the escalation strategy or the deferred task limit.
+	exitFailure = 1
+	// exitUsage: invalid command-line flags.
+	exitUsage = 2
+	// exitPartial: some tasks were completed and merged, others are not.
+	exitPartial = 3
+)
+
+// skipAnswer is what an agent is told when the "skip" strategy answers its question.
+const skipAnswer = "No human is available to answer in this non-interactive run. " +
+	"Use your best judgement and note the assumption in your commit."
+
+// ciVars are set by CI systems whose presence enables non-interactive mode.
+var ciVars = []string{"GITHUB_ACTIONS", "GITLAB_CI", "JENKINS_HOME", "CIRCLECI"}
+
+// nonInteractiveEnv reports whether the environment asks for a headless run:
+// AXIOM_NON_INTERACTIVE when it is set, otherwise a detected CI system.
+func nonInteractiveEnv(getenv func(string) string) bool {
+	if v := getenv("AXIOM_NON_INTERACTIVE"); v != "" {
+		on, err := strconv.ParseBool(v)
+		return err == nil && on
+	}
+	if ci, err := strconv.ParseBool(getenv("CI")); err == nil && ci {
+		return true
+	}
+	for _, name := range ciVars {
+		if getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// runEvent is a progress line of the run itself; the autopilot's events are
+// written as they come.
+type runEvent struct {
+	Time   time.Time      `json:"time"`
+	Event  string         `json:"event"`
+	Mode   autopilot.Mode `json:"mode,omitempty"`
+	TaskID string         `json:"taskId,omitempty"`
+	// Action is what the run did in place of a human, e.g. "defer".
+	Action string `json:"action,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// runSummary is the last progress line of a run.
+type runSummary struct {
+	Time     time.Time       `json:"time"`
+	Event    string          `json:"event"`
+	Outcome  string          `json:"outcome"`
+	ExitCode int             `json:"exitCode"`
+	Reason   string          `json:"reason,omitempty"`
+	Stats    autopilot.Stats `json:"stats"`
+	// Remaining lists the tasks that are not done or not merged.
+	Remaining []string `json:"remaining,omitempty"`
+}
+
+// progress writes JSON lines, one per event, from any goroutine.
+type progress struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (p *progress) write(v any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_ = p.enc.Encode(v)
+}
+
+// event writes a run event stamped with the current time.
+func (p *progress) event(e runEvent) {
+	e.Time = time.Now().UTC()
+	p.write(e)
+}
+
+// headless drives a non-interactive run: it applies the configured defaults where
+// the web UI would ask a human, and stops the run when only a human could go on.
+type headless struct {
+	cfg        config.NonInteractive
+	out        *progress
+	ap         *autopilot.Autopilot
+	workspaces *workspace.Manager
+	queue      *integration.Queue
+	stop       context.CancelFunc
+
+	// mu guards the events queued for the run loop, the tasks this run finished
+	// and the failure, which a task's PENDING question may set.
+	mu        sync.Mutex
+	queued    []autopilot.Event
+	completed map[string]bool
+	failure   string
+	notify    chan struct{}
+
+	// Owned by the run loop.
+	deferred map[string]bool
+	cleaned  bool
+	stopped  string
+}
+
+// runHeadless handles `axiom --non-interactive`: it works through the ready tasks
+// in autopilot mode without the web server, writes progress to stdout as JSON
+// lines and returns the process exit code. Logs go to stderr.
+func runHeadless(projectDir, axiomDir, caseFile string, mode autopilot.Mode, stdout io.Writer) int {
+	out := &progress{enc: json.NewEncoder(stdout)}
+	fail := func(format string, args ...any) int {
+		out.write(runSummary{Time: time.Now().UTC(), Event: "run_finished", Outcome: "failure", ExitCode: exitFailure, Reason: fmt.Sprintf(format, args...)})
+		return exitFailure
+	}
+
+	// A corrupted config falls back to its backup, then to the defaults; only a
+	// project that was never set up needs Ava in the web UI.
+	state := scaffold.CheckConfigState(projectDir)
+	cfg, recovered := config.Recover(axiomDir)
+	if state == scaffold.ConfigNew || (state != scaffold.ConfigComplete && recovered == "") {
+		return fail("the project is not set up; run axiom interactively so Ava can plan it")
+	}
+	if recovered != "" {
+		out.event(runEvent{Event: "config_recovered", Reason: recovered})
+	}
+	if mode != "" {
+		cfg.Mode = mode
+	}
+	if cfg.Mode == autopilot.ModeSemiAuto && !cfg.NonInteractive.AutoApprove {
+		return fail("semi-auto mode needs a human to approve each task; pass --mode autopilot or set nonInteractive.autoApprove")
+	}
+
+	h := &headless{
+		cfg:       cfg.NonInteractive,
+		out:       out,
+		notify:    make(chan struct{}, 1),
+		completed: make(map[string]bool),
+		deferred:  make(map[string]bool),
+	}
+	sess, err := openSession(projectDir, axiomDir, caseFile, cfg, os.Stderr, autopilot.Options{
+		Mode:          autopilot.ModeAutopilot,
+		OnEvent:       h.observe,
+		AnswerPending: h.answer,
+	})
+	if err != nil {
+		return fail("%v", err)
+	}
+	if sess.autopilot == nil {
+		return fail("%s is not a git repository; tasks run in git worktrees", projectDir)
+	}
+	if sess.inbox.Paused() {
+		return fail("an unanswered escalation paused the session; resume it in the web UI")
+	}
+	sess.broker.SetUnattended(true)
+	h.ap, h.workspaces, h.queue = sess.autopilot, sess.workspaces, sess.queue
+	// Let on-escalation hooks deliver their notifications before the process exits.
+	defer sess.inbox.Wait()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	go sess.inbox.Watch(ctx, time.Minute)
+	// Agents get to stop after the first signal; a second one kills the process.
+	context.AfterFunc(ctx, cancel)
+	stats, runErr := h.run(ctx)
+	// Merge what is still ready, e.g. entries an earlier session left queued, so
+	// the outcome reflects what landed.
+	if ctx.Err() == nil {
+		if err := h.queue.Process(ctx); err != nil {
+			log.Printf("[WARN] integration: %v", err)
+		}
+	}
+
+	if runErr != nil && !errors.Is(runErr, context.Canceled) {
+		h.fail(runErr.Error())
+	} else if ctx.Err() != nil && h.stopped == "" {
+		h.stopped = "interrupted"
+	}
+	return h.finish(caseFile, stats)
+}
+
+// run executes the autopilot until it completes or is stopped, handling the
+// events that need a decision as they arrive.
+func (h *headless) run(ctx context.Context) (autopilot.Stats, error) {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	h.stop = stop
+	h.out.event(runEvent{Event: "run_started", Mode: autopilot.ModeAutopilot})
+
+	type result struct {
+		stats autopilot.Stats
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		stats, err := h.ap.Run(ctx)
+		done <- result{stats, err}
+	}()
+	for {
+		select {
+		case <-h.notify:
+			h.handleQueued(ctx)
+		case res := <-done:
+			h.handleQueued(ctx)
+			return res.stats, res.err
+		}
+	}
+}
+
+// observe writes the autopilot's event and queues the ones the run loop acts on.
+// It is called with the autopilot's lock held, so the decisions wait for the loop.
+func (h *headless) observe(e autopilot.Event) {
+	h.out.write(e)
+	finished := e.Type == autopilot.EventTaskFinished
+	if finished && e.Status == casestore.StatusDone {
+		h.mu.Lock()
+		h.completed[e.TaskID] = true
+		h.mu.Unlock()
+	}
+	if e.Type != autopilot.EventPaused && !(finished && e.Status == casestore.StatusBlocked) {
+		return
+	}
+	h.mu.Lock()
+	h.queued = append(h.queued, e)
+	h.mu.Unlock()
+	select {
+	case h.notify <- struct{}{}:
+	default:
+	}
+}
+
+// handleQueued acts on the queued events in order.
+func (h *headless) handleQueued(ctx context.Context) {
+	h.mu.Lock()
+	queued := h.queued
+	h.queued = nil
+	h.mu.Unlock()
+	for _, e := range queued {
+		if e.Type == autopilot.EventPaused {
+			h.paused(ctx, e.Reason)
+		} else {
+			h.deferTask(e.TaskID, e.Reason)
+		}
+	}
+}
+
+// answer applies the escalation strategy to a PENDING question: "skip" answers
+// it, "fail" ends the run, and "defer" leaves it to the escalation inbox.
+func (h *headless) answer(taskID, question string) string {
+	switch h.cfg.EscalationStrategy {
+	case config.StrategySkip:
+		h.out.event(runEvent{Event: "escalation", TaskID: taskID, Action: config.StrategySkip, Reason: question})
+		return skipAnswer
+	case config.StrategyFail:
+		h.out.event(runEvent{Event: "escalation", TaskID: taskID, Action: config.StrategyFail, Reason: question})
+		h.fail(fmt.Sprintf("%s needs a human: %s", taskID, question))
+		return ""
+	}
+	h.out.event(runEvent{Event: "escalation", TaskID: taskID, Action: config.StrategyDefer, Reason: question})
+	return ""
+}
+
+// deferTask counts a blocked task as set aside and ends the run once more than
+// nonInteractive.maxDeferredTasks are.
+func (h *headless) deferTask(taskID, reason string) {
+	h.deferred[taskID] = true
+	if n := len(h.deferred); n > h.cfg.MaxDeferredTasks {
+		h.fail(fmt.Sprintf("%d tasks deferred, more than nonInteractive.maxDeferredTasks (%d); last: %s: %s", n, h.cfg.MaxDeferredTasks, taskID, reason))
+	}
+}
+
+// paused handles a paused run: low disk space gets one cleanup of stale
+// workspaces before resuming, anything else needs a human and stops the run.
+func (h *headless) paused(ctx context.Context, reason string) {
+	if strings.Contains(reason, workspace.ErrLowDisk.Error()) && !h.cleaned {
+		h.cleaned = true
+		cleanup, err := h.workspaces.Clean(ctx)
+		if err == nil {
+			n := len(cleanup.Pruned) + len(cleanup.Orphans) + len(cleanup.Branches)
+			h.out.event(runEvent{Event: "cleanup", Reason: fmt.Sprintf("removed %d stale workspace item(s)", n)})
+			if h.ap.Resume() == nil {
+				return
+			}
+		}
+	}
+	if h.stopped == "" {
+		h.stopped = "paused: " + reason
+	}
+	h.stop()
+}
+
+// fail ends the run as failed for reason, keeping the first reason given.
+func (h *headless) fail(reason string) {
+	h.mu.Lock()
+	if h.failure == "" {
+		h.failure = reason
+	}
+	h.mu.Unlock()
+	h.stop()
+}
+
+// finish writes the summary line and returns the exit code: success when every
+// task is done and merged, partial when a task this run completed was merged,
+// failure otherwise. A done task whose integration entry is not merged, e.g. on
+// a conflict, failed verification or deferral, is not counted as done.
+func (h *headless) finish(caseFile string, stats autopilot.Stats) int {
+	summary := runSummary{Event: "run_finished", Stats: stats}
+	cases, err := casestore.NewCaseStore().Load(caseFile)
+	if err != nil && !os.IsNotExist(err) {
+		h.fail(fmt.Sprintf("load cases: %v", err))
+	}
+	unmerged := make(map[string]bool)
+	if h.queue != nil {
+		for _, e := range h.queue.Entries() {
+			if e.State != integration.StateMerged {
+				unmerged[e.TaskID] = true
+			}
+		}
+	}
+	remaining := make(map[string]bool)
+	for _, c := range cases {
+		if c.Type == casestore.CaseTypeTask && (c.Status != casestore.StatusDone || unmerged[c.ID]) {
+			remaining[c.ID] = true
+			summary.Remaining = append(summary.Remaining, c.ID)
+		}
+	}
+
+	h.mu.Lock()
+	failure := h.failure
+	landed := 0
+	for id := range h.completed {
+		if !remaining[id] {
+			landed++
+		}
+	}
+	h.mu.Unlock()
+	switch {
+	case failure != "":
+		summary.Outcome, summary.ExitCode, summary.Reason = "failure", exitFailure, failure
+	case len(summary.Remaining) == 0:
+		summary.Outcome, summary.ExitCode = "success", exitSuccess
+	case landed > 0:
+		summary.Outcome, summary.ExitCode = "partial", exitPartial
+	default:
+		summary.Outcome, summary.ExitCode = "failure", exitFailure
+	}
+	if summary.Reason == "" && summary.ExitCode != exitSuccess {
+		summary.Reason = fmt.Sprintf("%d task(s) not done or not merged", len(summary.Remaining))
+		if h.stopped != "" {
+			summary.Reason = h.stopped + "; " + summary.Reason
+		}
+	}
+	summary.Time = time.Now().UTC()
+	h.out.write(summary)
+	return summary.ExitCode
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/autopilot"
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/config"
+	"github.com/deligoez/axiom/internal/integration"
+)
+
+// newHeadless returns a headless run with cfg that writes progress to out and
+// counts how often it was stopped.
+func newHeadless(cfg config.NonInteractive, out *bytes.Buffer, stops *int) *headless {
+	return &headless{
+		cfg:       cfg,
+		out:       &progress{enc: json.NewEncoder(out)},
+		stop:      func() { *stops++ },
+		notify:    make(chan struct{}, 1),
+		completed: make(map[string]bool),
+		deferred:  make(map[string]bool),
+	}
+}
+
+// writeCases writes cases to a case file in a fresh directory and returns its path.
+func writeCases(t *testing.T, cases ...casestore.Case) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, c := range cases {
+		if err := enc.Encode(c); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "cases.jsonl")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	return path
+}
+
+func TestNonInteractiveEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"nothing set", nil, false},
+		{"explicit on", map[string]string{"AXIOM_NON_INTERACTIVE": "true"}, true},
+		{"explicit off wins over CI", map[string]string{"AXIOM_NON_INTERACTIVE": "0", "CI": "true"}, false},
+		{"unparsable explicit value", map[string]string{"AXIOM_NON_INTERACTIVE": "yes"}, false},
+		{"generic CI", map[string]string{"CI": "1"}, true},
+		{"CI false", map[string]string{"CI": "false"}, false},
+		{"GitHub Actions", map[string]string{"GITHUB_ACTIONS": "true"}, true},
+		{"GitLab CI", map[string]string{"GITLAB_CI": "true"}, true},
+		{"Jenkins", map[string]string{"JENKINS_HOME": "/var/jenkins"}, true},
+		{"CircleCI", map[string]string{"CIRCLECI": "true"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(name string) string { return tt.env[name] }
+
+			if got := nonInteractiveEnv(getenv); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadless_FinishExitCodes(t *testing.T) {
+	task := func(id string, status casestore.Status) casestore.Case {
+		return casestore.Case{ID: id, Type: casestore.CaseTypeTask, Status: status}
+	}
+	entry := func(id string, state integration.State) string {
+		return fmt.Sprintf(`{"taskId": %q, "branch": "axiom/%s", "state": %q}`, id, id, state)
+	}
+	tests := []struct {
+		name  string
+		cases []casestore.Case
+		// completed are the tasks this run finished; entries is the integration queue.
+		completed []string
+		entries   []string
+		failure   string
+		stopped   string
+		want      int
+		outcome   string
+		reason    string
+		remaining []string
+	}{
+		{
+			name:      "every task done and merged",
+			cases:     []casestore.Case{task("t1", casestore.StatusDone), task("t2", casestore.StatusDone)},
+			completed: []string{"t1", "t2"},
+			entries:   []string{entry("t1", integration.StateMerged), entry("t2", integration.StateMerged)},
+			want:      exitSuccess,
+			outcome:   "success",
+		},
+		{
+			name:      "some tasks done",
+			cases:     []casestore.Case{task("t1", casestore.StatusDone), task("t2", casestore.StatusBlocked)},
+			completed: []string{"t1"},
+			stopped:   "interrupted",
+			want:      exitPartial,
+			outcome:   "partial",
+			reason:    "interrupted; 1 task(s) not done or not merged",
+			remaining: []string{"t2"},
+		},
+		{
+			name:      "no task completed",
+			cases:     []casestore.Case{task("t1", casestore.StatusBlocked)},
+			want:      exitFailure,
+			outcome:   "failure",
+			reason:    "1 task(s) not done or not merged",
+			remaining: []string{"t1"},
+		},
+		{
+			name:      "failure wins over completed tasks",
+			cases:     []casestore.Case{task("t1", casestore.StatusDone), task("t2", casestore.StatusPending)},
+			completed: []string{"t1"},
+			failure:   "t2 needs a human: which database?",
+			want:      exitFailure,
+			outcome:   "failure",
+			reason:    "t2 needs a human: which database?",
+			remaining: []string{"t2"},
+		},
+		{
+			name:      "done but not merged",
+			cases:     []casestore.Case{task("t1", casestore.StatusDone), task("t2", casestore.StatusDone)},
+			completed: []string{"t1", "t2"},
+			entries:   []string{entry("t1", integration.StateConflict), entry("t2", integration.StateFailed)},
+			want:      exitFailure,
+			outcome:   "failure",
+			reason:    "2 task(s) not done or not merged",
+			remaining: []string{"t1", "t2"},
+		},
+		{
+			name:      "some done tasks not merged",
+			cases:     []casestore.Case{task("t1", casestore.StatusDone), task("t2", casestore.StatusDone), task("t3", casestore.StatusDone)},
+			completed: []string{"t1", "t2", "t3"},
+			entries:   []string{entry("t1", integration.StateMerged), entry("t2", integration.StateDeferred), entry("t3", integration.StateQueued)},
+			want:      exitPartial,
+			outcome:   "partial",
+			reason:    "2 task(s) not done or not merged",
+			remaining: []string{"t2", "t3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var stops int
+			h := newHeadless(config.NonInteractive{}, &out, &stops)
+			h.failure, h.stopped = tt.failure, tt.stopped
+			caseFile := writeCases(t, tt.cases...)
+			queuePath := filepath.Join(t.TempDir(), "queue.json")
+			if err := os.WriteFile(queuePath, []byte("["+strings.Join(tt.entries, ",")+"]"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			q, err := integration.Open(queuePath, t.TempDir(), integration.Config{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.queue = q
+			for _, id := range tt.completed {
+				h.observe(autopilot.Event{Type: autopilot.EventTaskFinished, TaskID: id, Status: casestore.StatusDone})
+			}
+
+			code := h.finish(caseFile, autopilot.Stats{Completed: len(tt.completed)})
+
+			if code != tt.want {
+				t.Errorf("got exit code %d, want %d", code, tt.want)
+			}
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			var summary runSummary
+			if err := json.Unmarshal([]byte(lines[len(lines)-1]), &summary); err != nil {
+				t.Fatalf("decode summary %q: %v", out.String(), err)
+			}
+			if summary.Event != "run_finished" || summary.Outcome != tt.outcome || summary.ExitCode != tt.want || summary.Reason != tt.reason {
+				t.Errorf("got summary %+v, want outcome %q with reason %q", summary, tt.outcome, tt.reason)
+			}
+			if strings.Join(summary.Remaining, ",") != strings.Join(tt.remaining, ",") {
+				t.Errorf("got remaining %v, want %v", summary.Remaining, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestHeadless_AnswerStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		answer   string
+		failed   bool
+	}{
+		{config.StrategyDefer, "", false},
+		{config.StrategySkip, skipAnswer, false},
+		{config.StrategyFail, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			var out bytes.Buffer
+			var stops int
+			h := newHeadless(config.NonInteractive{EscalationStrategy: tt.strategy}, &out, &stops)
+
+			answer := h.answer("t1", "which database?")
+
+			if answer != tt.answer {
+				t.Errorf("got answer %q, want %q", answer, tt.answer)
+			}
+			if failed := h.failure != ""; failed != tt.failed || (stops > 0) != tt.failed {
+				t.Errorf("got failure %q after %d stop(s), want failed=%v", h.failure, stops, tt.failed)
+			}
+			var e runEvent
+			if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+				t.Fatalf("decode event %q: %v", out.String(), err)
+			}
+			if e.Event != "escalation" || e.TaskID != "t1" || e.Action != tt.strategy || e.Reason != "which database?" {
+				t.Errorf("got event %+v, want an escalation answered with %s", e, tt.strategy)
+			}
+		})
+	}
+}
+
+func TestHeadless_DeferTaskCutoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		max      int
+		deferred []string
+		failed   bool
+	}{
+		{"none allowed", 0, []string{"t1"}, true},
+		{"at the limit", 2, []string{"t1", "t2"}, false},
+		{"same task twice counts once", 2, []string{"t1", "t2", "t1"}, false},
+		{"over the limit", 2, []string{"t1", "t2", "t3"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var stops int
+			h := newHeadless(config.NonInteractive{MaxDeferredTasks: tt.max}, &out, &stops)
+
+			for _, id := range tt.deferred {
+				h.deferTask(id, "blocked")
+			}
+
+			if failed := h.failure != ""; failed != tt.failed || (stops > 0) != tt.failed {
+				t.Errorf("got failure %q after %d stop(s), want failed=%v", h.failure, stops, tt.failed)
+			}
+			if tt.failed && !strings.Contains(h.failure, "nonInteractive.maxDeferredTasks") {
+				t.Errorf("got failure %q, want it to name the limit", h.failure)
+			}
+		})
+	}
+}
@@ -2,18 +2,68 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/deligoez/axiom/internal/autopilot"
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/config"
+	"github.com/deligoez/axiom/internal/escalation"
+	"github.com/deligoez/axiom/internal/integration"
+	"github.com/deligoez/axiom/internal/permission"
+	"github.com/deligoez/axiom/internal/persona"
+	"github.com/deligoez/axiom/internal/registry"
+	"github.com/deligoez/axiom/internal/resolver"
 	"github.com/deligoez/axiom/internal/scaffold"
+	"github.com/deligoez/axiom/internal/verify"
 	"github.com/deligoez/axiom/internal/web"
+	"github.com/deligoez/axiom/internal/workspace"
 )
 
 func main() {
 	addr := ":8080"
 	caseFile := ".axiom/cases.jsonl"
 	promptPath := ".axiom/agents/ava/prompt.md"
+	permissionsPath := ".axiom/permissions.json"
+
+	if len(os.Args) > 1 && os.Args[1] == "prompts" {
+		os.Exit(runPrompts(".axiom", os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "cleanup" {
+		os.Exit(runCleanup(".", ".axiom", caseFile, os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "recover" {
+		os.Exit(runRecover(".", ".axiom", caseFile, os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	fs := flag.NewFlagSet("axiom", flag.ContinueOnError)
+	nonInteractive := fs.Bool("non-interactive", false, "work through ready tasks without the web UI, printing JSON progress (default from AXIOM_NON_INTERACTIVE or a CI environment)")
+	mode := fs.String("mode", "", "override the configured mode: semi-auto or autopilot")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(exitUsage)
+	}
+	if *mode != "" && !autopilot.Mode(*mode).Valid() {
+		_, _ = fmt.Fprintf(os.Stderr, "axiom: invalid --mode %q (want semi-auto or autopilot)\n", *mode)
+		os.Exit(exitUsage)
+	}
+	headless := nonInteractiveEnv(os.Getenv)
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "non-interactive" {
+			headless = *nonInteractive
+		}
+	})
+	if headless {
+		os.Exit(runHeadless(".", ".axiom", caseFile, autopilot.Mode(*mode), os.Stdout))
+	}
 
 	// Check config state before scaffolding
 	configState := scaffold.CheckConfigState(".")
@@ -26,14 +76,55 @@ func main() {
 		if err := scaffold.WriteConfig(".axiom"); err != nil {
 			log.Fatalf("config error: %v", err)
 		}
-		if err := scaffold.WriteAvaPrompt(".axiom"); err != nil {
+		if err := scaffold.WriteAgentPrompts(".axiom"); err != nil {
 			log.Fatalf("prompt error: %v", err)
 		}
+		if err := permission.WriteDefaultPolicy(permissionsPath); err != nil {
+			log.Fatalf("permissions error: %v", err)
+		}
 		fmt.Println("Created .axiom/ directory")
 	}
 
+	cfg, err := config.Load(".axiom")
+	if err != nil {
+		log.Fatalf("config error: %v", err)
+	}
+	if *mode != "" {
+		cfg.Mode = autopilot.Mode(*mode)
+	}
+	projectDir, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("working directory error: %v", err)
+	}
+	sess, err := openSession(projectDir, ".axiom", caseFile, cfg, os.Stdout, autopilot.Options{})
+	if err != nil {
+		log.Fatalf("session error: %v", err)
+	}
+	go sess.inbox.Watch(context.Background(), time.Minute)
+
 	server := web.NewServer(caseFile)
 	server.StaticDir("web/static")
+	server.SetRegistry(sess.agents)
+	server.SetPersonas(sess.personas, projectDir)
+	server.SetBroker(sess.broker)
+	server.SetAccounting(sess.ledger, ".axiom")
+	server.SetTimeouts(sess.timeouts)
+	server.SetModels(cfg.Models, cfg.Agents.DefaultModel)
+	server.SetEscalations(sess.inbox)
+	if sess.workspaces != nil {
+		server.SetWorkspaces(sess.workspaces, cfg.Workspaces)
+		server.SetAutopilot(sess.autopilot)
+		if configState == scaffold.ConfigComplete {
+			if cfg.Mode == autopilot.ModeAutopilot {
+				fmt.Println("Autopilot mode - working through ready tasks.")
+			} else {
+				fmt.Println("Semi-auto mode - approve each task, merge and blocker in the web UI.")
+			}
+			if err := server.StartAutopilot(); err != nil {
+				log.Printf("[autopilot] not started: %v", err)
+			}
+		}
+	}
 
 	// Enable init mode based on config state
 	switch configState {
@@ -54,3 +145,35 @@ func main() {
 		log.Fatalf("server error: %v", err)
 	}
 }
+
+// openQueue opens the integration queue, verifying merges with the configured
+// commands, resolving conflicts with Rex and escalating the rest to the inbox.
+func openQueue(projectDir, axiomDir, caseFile string, cfg config.Config, workspaces *workspace.Manager, inbox *escalation.Inbox, personas *persona.Loader, agents *registry.Registry) (*integration.Queue, error) {
+	queue, err := integration.Open(integration.Path(axiomDir), projectDir, cfg.Integration, func(ctx context.Context, dir string) error {
+		return verify.Check(ctx, cfg.Verification, dir, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	store := casestore.NewCaseStore()
+	queue.SetResolver(resolver.New(resolver.Config{
+		Personas:   personas,
+		ProjectDir: projectDir,
+		Model:      cfg.Agents.DefaultModel,
+		Timeout:    cfg.Agents.QueryTimeout,
+		Registry:   agents,
+		Cases: func(taskID string) *casestore.Case {
+			cases, _ := store.Load(caseFile)
+			for i := range cases {
+				if cases[i].ID == taskID {
+					return &cases[i]
+				}
+			}
+			return nil
+		},
+	}))
+	queue.SetEscalator(inbox.Escalator(workspaces.Path))
+	queue.SetRebaser(rebaser(workspaces, caseFile, target(cfg)))
+	inbox.OnClose(escalation.QueueHandler(queue))
+	return queue, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/deligoez/axiom/internal/scaffold"
+)
+
+// runPrompts handles `axiom prompts diff` and `axiom prompts upgrade [--force]`.
+// It returns the process exit code.
+func runPrompts(axiomDir string, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprintln(stderr, "usage: axiom prompts <diff|upgrade> [--force]")
+		return 2
+	}
+
+	switch args[0] {
+	case "diff":
+		drifts, err := scaffold.CheckPrompts(axiomDir)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "prompts diff: %v\n", err)
+			return 1
+		}
+		for _, d := range drifts {
+			_, _ = fmt.Fprintf(stdout, "%-8s %-5s %s\n", d.Status, d.Persona, d.File)
+		}
+		for _, d := range drifts {
+			if d.Diff != "" {
+				_, _ = fmt.Fprintf(stdout, "\n%s", d.Diff)
+			}
+		}
+		return 0
+
+	case "upgrade":
+		fs := flag.NewFlagSet("prompts upgrade", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		force := fs.Bool("force", false, "overwrite prompts you have edited")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		upgraded, err := scaffold.UpgradePrompts(axiomDir, *force)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "prompts upgrade: %v\n", err)
+			return 1
+		}
+		for _, d := range upgraded {
+			_, _ = fmt.Fprintf(stdout, "upgraded %s (was %s)\n", d.Path, d.Status)
+		}
+		if len(upgraded) == 0 {
+			_, _ = fmt.Fprintln(stdout, "all prompts up to date")
+		}
+		return 0
+
+	default:
+		_, _ = fmt.Fprintf(stderr, "unknown prompts command %q\n", args[0])
+		return 2
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/config"
+	"github.com/deligoez/axiom/internal/escalation"
+	"github.com/deligoez/axiom/internal/integration"
+	"github.com/deligoez/axiom/internal/persona"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// runRecover handles `axiom recover [--dry-run]`, rebasing workspaces whose base
+// vanished from the integration target after a force-push. It returns the process exit code.
+func runRecover(projectDir, axiomDir, caseFile string, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("recover", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dryRun := fs.Bool("dry-run", false, "list workspaces on a vanished base without rebasing them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(axiomDir)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "recover: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+	m, err := workspace.New(ctx, projectDir, cfg.Workspaces)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "recover: %v\n", err)
+		return 1
+	}
+
+	if *dryRun {
+		stale, err := m.Stale(ctx, target(cfg))
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "recover: %v\n", err)
+			return 1
+		}
+		for _, st := range stale {
+			_, _ = fmt.Fprintf(stdout, "stale    %-20s base %s\n", st.TaskID, short(st.BaseCommit))
+		}
+		_, _ = fmt.Fprintf(stdout, "%d workspace(s) on a vanished base\n", len(stale))
+		return 0
+	}
+
+	inbox, err := escalation.Open(filepath.Join(axiomDir, "escalations.json"), projectDir, axiomDir, cfg.Escalation)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "recover: %v\n", err)
+		return 1
+	}
+	defer inbox.Wait()
+	q, err := openQueue(projectDir, axiomDir, caseFile, cfg, m, inbox, persona.NewLoader(axiomDir), nil)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "recover: %v\n", err)
+		return 1
+	}
+	// A running session may have agents at work in active tasks' workspaces.
+	results, err := recoverWorkspaces(ctx, m, q, caseFile, target(cfg), activeTasks(caseFile))
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "recover: %v\n", err)
+		return 1
+	}
+	// No session runs the queue for this process; merge the rebased tasks now.
+	if err := q.Process(ctx); err != nil {
+		_, _ = fmt.Fprintf(stderr, "recover: integration: %v\n", err)
+		return 1
+	}
+	for _, r := range results {
+		if r.Rebased && r.Reason == "" {
+			state := "not queued"
+			if e, err := q.Get(r.TaskID); err == nil {
+				state = string(e.State)
+			}
+			_, _ = fmt.Fprintf(stdout, "rebased  %-20s %s -> %s, %s\n", r.TaskID, short(r.OldBase), short(r.NewBase), state)
+		} else {
+			_, _ = fmt.Fprintf(stdout, "review   %-20s %s\n", r.TaskID, r.Reason)
+		}
+	}
+	return 0
+}
+
+// recoverWorkspaces rebases workspaces whose base is no longer reachable from
+// target, skipping those busy reports an agent working in. Rebased tasks are
+// queued for integration again; tasks that could not be rebased are marked for review.
+func recoverWorkspaces(ctx context.Context, m *workspace.Manager, q *integration.Queue, caseFile, target string, busy func(taskID string) bool) ([]workspace.Rebase, error) {
+	results, err := m.Recover(ctx, target, busy)
+	if err != nil {
+		return results, err
+	}
+	for i, r := range results {
+		if r.Rebased {
+			_, err := q.Requeue(r.TaskID)
+			if err == nil {
+				continue
+			}
+			results[i].Reason = fmt.Sprintf("rebased, but could not be queued again: %v", err)
+		}
+		if err := markForReview(caseFile, r.TaskID); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// rebaser returns the integration queue's Rebaser: a task whose workspace sits on
+// commits a rewrite removed from target is rebased before it is merged, and
+// marked for review when that fails.
+func rebaser(m *workspace.Manager, caseFile, target string) integration.Rebaser {
+	return func(ctx context.Context, taskID string) error {
+		r, err := m.RecoverTask(ctx, target, taskID)
+		if err != nil || r == nil {
+			return err
+		}
+		if r.Rebased {
+			log.Printf("[integration] rebased %s onto rewritten %s at %s", taskID, target, short(r.NewBase))
+			return nil
+		}
+		if err := markForReview(caseFile, taskID); err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+		return errors.New(r.Reason)
+	}
+}
+
+// activeTasks reports whether a task is active, i.e. an agent may be working in its workspace.
+func activeTasks(caseFile string) func(taskID string) bool {
+	cases, _ := casestore.NewCaseStore().Load(caseFile)
+	active := make(map[string]bool)
+	for _, c := range cases {
+		if c.Status == casestore.StatusActive {
+			active[c.ID] = true
+		}
+	}
+	return func(taskID string) bool { return active[taskID] }
+}
+
+// markForReview sets taskID's status to review. A missing case is not an error.
+func markForReview(caseFile, taskID string) error {
+	err := casestore.NewCaseStore().SetStatus(caseFile, taskID, casestore.StatusReview)
+	if err != nil && !os.IsNotExist(err) && !errors.Is(err, casestore.ErrNotFound) {
+		return fmt.Errorf("mark %s for review: %w", taskID, err)
+	}
+	return nil
+}
+
+// target returns the branch task branches are integrated into.
+func target(cfg config.Config) string {
+	if cfg.Integration.Target != "" {
+		return cfg.Integration.Target
+	}
+	return integration.DefaultTarget
+}
+
+// short abbreviates a commit hash for display.
+func short(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
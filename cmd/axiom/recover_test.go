@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/config"
+	"github.com/deligoez/axiom/internal/gitcmd"
+	"github.com/deligoez/axiom/internal/integration"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// git runs git in dir and fails the test on error.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := gitcmd.Run(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// commitFile writes name in dir and commits it.
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", name)
+}
+
+func TestRunRecover_MergesRebasedTasks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	ctx := context.Background()
+	dir := t.TempDir()
+	axiomDir := filepath.Join(dir, ".axiom")
+	git(t, dir, "init", "-q", "-b", "main")
+	commitFile(t, dir, ".gitignore", ".axiom/\n")
+	commitFile(t, dir, "upstream.txt", "soon rewritten\n")
+
+	m, err := workspace.New(ctx, dir, config.Default().Workspaces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err := m.Create(ctx, "task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, ws.Path, "feature.txt", "feature\n")
+	// The merge failed on the rewritten target before recover ran.
+	failed := `[{"taskId": "task-1", "branch": "` + ws.Branch + `", "state": "failed", "error": "rebase onto main: conflicts"}]`
+	if err := os.MkdirAll(filepath.Dir(integration.Path(axiomDir)), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(integration.Path(axiomDir), []byte(failed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "reset", "-q", "--hard", "HEAD~1")
+	commitFile(t, dir, "other.txt", "rewritten\n")
+	var stdout, stderr bytes.Buffer
+
+	code := runRecover(dir, axiomDir, filepath.Join(axiomDir, "cases.jsonl"), nil, &stdout, &stderr)
+
+	if code != 0 {
+		t.Fatalf("got exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "rebased  task-1") || !strings.Contains(stdout.String(), string(integration.StateMerged)) {
+		t.Errorf("got output %q, want task-1 rebased and merged", stdout.String())
+	}
+	if got := git(t, dir, "show", "main:feature.txt"); got != "feature" {
+		t.Errorf("got feature.txt %q on main, want the recovered task merged", got)
+	}
+}
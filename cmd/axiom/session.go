@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+
+	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/autopilot"
+	"github.com/deligoez/axiom/internal/config"
+	"github.com/deligoez/axiom/internal/escalation"
+	"github.com/deligoez/axiom/internal/integration"
+	"github.com/deligoez/axiom/internal/permission"
+	"github.com/deligoez/axiom/internal/persona"
+	"github.com/deligoez/axiom/internal/registry"
+	"github.com/deligoez/axiom/internal/usage"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// session holds the services an axiom process wires together, for the web
+// server and for headless runs alike.
+type session struct {
+	agents   *registry.Registry
+	personas *persona.Loader
+	broker   *permission.Broker
+	ledger   *usage.Ledger
+	timeouts agent.Timeouts
+	inbox    *escalation.Inbox
+
+	// workspaces, queue and autopilot are nil outside a git repository.
+	workspaces *workspace.Manager
+	queue      *integration.Queue
+	autopilot  *autopilot.Autopilot
+}
+
+// openSession wires the services for projectDir with cfg, reporting housekeeping
+// such as cleaned up workspaces to out. opts adds autopilot options the caller
+// owns, e.g. OnEvent; the rest are filled in from the session.
+func openSession(projectDir, axiomDir, caseFile string, cfg config.Config, out io.Writer, opts autopilot.Options) (*session, error) {
+	counters := registry.NewCounters(filepath.Join(axiomDir, "metrics", "counters.json"))
+	if err := counters.Init(); err != nil {
+		return nil, fmt.Errorf("counters: %w", err)
+	}
+	s := &session{
+		agents:   registry.New(counters),
+		personas: persona.NewLoader(axiomDir),
+		ledger:   usage.NewLedger(cfg.Prices(), cfg.Usage.Budgets),
+	}
+
+	policy, err := permission.LoadPolicy(filepath.Join(axiomDir, "permissions.json"))
+	if err != nil {
+		return nil, fmt.Errorf("permissions: %w", err)
+	}
+	if s.broker, err = permission.NewBroker(policy); err != nil {
+		return nil, fmt.Errorf("permissions: %w", err)
+	}
+	if s.timeouts, err = cfg.Timeouts(); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	s.inbox, err = escalation.Open(filepath.Join(axiomDir, "escalations.json"), projectDir, axiomDir, cfg.Escalation)
+	if err != nil {
+		return nil, fmt.Errorf("escalations: %w", err)
+	}
+	if n := len(s.inbox.Pending()); n > 0 {
+		_, _ = fmt.Fprintf(out, "%d escalation(s) waiting for you\n", n)
+	}
+
+	// Clean up worktrees left behind by a crashed session. Outside a git repository
+	// there are no workspaces to manage.
+	workspaces, err := workspace.New(context.Background(), projectDir, cfg.Workspaces)
+	if err != nil {
+		return s, nil
+	}
+	s.workspaces = workspaces
+	cleanup, err := workspaces.Clean(context.Background())
+	if err != nil {
+		log.Printf("[WARN] workspace cleanup: %v", err)
+	} else if n := len(cleanup.Pruned) + len(cleanup.Orphans) + len(cleanup.Branches); n > 0 {
+		_, _ = fmt.Fprintf(out, "Cleaned up %d stale workspace item(s)\n", n)
+	}
+	queue, err := openQueue(projectDir, axiomDir, caseFile, cfg, workspaces, s.inbox, s.personas, s.agents)
+	if err != nil {
+		return nil, fmt.Errorf("integration queue: %w", err)
+	}
+	// Rebase task branches left on commits a force-push removed from the target.
+	// No agent runs yet; while the session runs, the queue rebases each branch
+	// before merging it.
+	if results, err := recoverWorkspaces(context.Background(), workspaces, queue, caseFile, target(cfg), nil); err != nil {
+		log.Printf("[WARN] workspace recovery: %v", err)
+	} else if len(results) > 0 {
+		_, _ = fmt.Fprintf(out, "Recovered %d workspace(s) from a rewritten %s\n", len(results), target(cfg))
+	}
+	// Merge what earlier sessions, recovery and humans queued without waiting
+	// for a task to finish.
+	s.queue = queue
+	go queue.Run(context.Background())
+	opts.CaseFile, opts.ProjectDir, opts.AxiomDir = caseFile, projectDir, axiomDir
+	if opts.Mode == "" {
+		opts.Mode = cfg.Mode
+	}
+	opts.MaxParallel = cfg.Agents.MaxParallel
+	opts.Workspaces, opts.MinFreeMB = workspaces, cfg.Workspaces.MinFreeMB
+	opts.Queue, opts.Inbox = queue, s.inbox
+	opts.Personas, opts.Registry, opts.Ledger, opts.Broker = s.personas, s.agents, s.ledger, s.broker
+	opts.Models, opts.DefaultModel = cfg.Models, cfg.Agents.DefaultModel
+	opts.Timeouts, opts.Supervisor, opts.Verification = s.timeouts, cfg.Supervisor(), cfg.Verification
+	if s.autopilot, err = autopilot.New(cfg.Autopilot, opts); err != nil {
+		return nil, fmt.Errorf("autopilot: %w", err)
+	}
+	return s, nil
+}
@@ -4,6 +4,8 @@ package agent
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/dotcommander/agent-sdk-go/claude"
 
@@ -19,25 +21,38 @@ type AgentConfig struct {
 	SystemPrompt string
 
 	// WorkDir is the working directory for the agent (typically a git worktree).
+	// NewAgentClient checks that it exists and, when ProjectDir is set, that it is inside the project.
 	WorkDir string
 
+	// ProjectDir is the project root that WorkDir must stay within.
+	ProjectDir string
+
 	// AllowedTools lists tools to auto-approve (e.g., "Bash", "Read", "Edit").
+	// Ignored when PermissionHandler is set.
 	AllowedTools []string
 
+	// DisallowedTools lists tools the agent may not use at all.
+	DisallowedTools []string
+
 	// Timeout is the maximum duration for a single query (e.g., "30m").
+	// NewAgentClient validates it and Execute cancels the query when it passes.
 	Timeout string
 
 	// TaskID is the AXIOM task identifier for this agent.
 	TaskID string
 
-	// AgentID is the unique agent identifier.
+	// AgentID is the unique agent identifier (e.g., "echo-001"), validated by NewAgentClient.
 	AgentID string
 
 	// Verbose enables verbose CLI output.
 	Verbose bool
 
-	// SkipPermissions bypasses all permission prompts.
+	// SkipPermissions bypasses all permission prompts. Ignored when PermissionHandler is set.
 	SkipPermissions bool
+
+	// PermissionHandler decides tool-use requests the policy does not auto-approve.
+	// When set, the agent runs over a streaming connection so the CLI can ask it.
+	PermissionHandler claude.CanUseToolCallback
 }
 
 // AgentMessage represents a message from the agent with extracted signals.
@@ -50,16 +65,50 @@ type AgentMessage struct {
 
 	// Signals contains any AXIOM signals extracted from the text.
 	Signals []signal.Signal
+
+	// ToolCalls contains the tool calls this message started or finished.
+	ToolCalls []ToolCall
 }
 
 // AgentClient wraps the Go SDK client with AXIOM-specific configuration.
 type AgentClient struct {
-	client claude.Client
-	config AgentConfig
+	client  claude.Client
+	config  AgentConfig
+	machine *Machine
+	tools   *toolTracker
+
+	queryTimeout time.Duration
+
+	// Streaming connection, used when PermissionHandler is set
+	sessionMu sync.Mutex
+	session   *session
 }
 
 // NewAgentClient creates a new AgentClient with the given configuration.
+// WorkDir is resolved to an absolute path in the stored copy of config.
 func NewAgentClient(config *AgentConfig) (*AgentClient, error) {
+	cfg := *config
+	config = &cfg
+
+	if config.AgentID != "" {
+		if _, _, err := ParseAgentID(config.AgentID); err != nil {
+			return nil, err
+		}
+	}
+
+	queryTimeout, err := ParseTimeout(config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.WorkDir != "" {
+		workDir, err := validateWorkDir(config.WorkDir, config.ProjectDir)
+		if err != nil {
+			return nil, err
+		}
+		config.WorkDir = workDir
+	}
+
 	opts := buildClientOptions(config)
 
 	client, err := claude.NewClient(opts...)
@@ -68,8 +117,12 @@ func NewAgentClient(config *AgentConfig) (*AgentClient, error) {
 	}
 
 	return &AgentClient{
-		client: client,
-		config: *config,
+		client:  client,
+		config:  *config,
+		machine: NewMachine(config.AgentID),
+		tools:   newToolTracker(config.WorkDir),
+
+		queryTimeout: queryTimeout,
 	}, nil
 }
 
@@ -85,12 +138,29 @@ func buildClientOptions(config *AgentConfig) []claude.ClientOption {
 		opts = append(opts, claude.WithTimeout(config.Timeout))
 	}
 
+	if config.SystemPrompt != "" {
+		opts = append(opts, claude.WithSystemPrompt(config.SystemPrompt))
+	}
+
+	if config.WorkDir != "" {
+		opts = append(opts, claude.WithWorkingDirectory(config.WorkDir))
+	}
+
+	// With a permission handler every tool use goes through it, so nothing is pre-approved.
+	if len(config.AllowedTools) > 0 && config.PermissionHandler == nil {
+		opts = append(opts, claude.WithAllowedTools(config.AllowedTools...))
+	}
+
+	if len(config.DisallowedTools) > 0 {
+		opts = append(opts, claude.WithDisallowedTools(config.DisallowedTools...))
+	}
+
 	// Build custom args
 	var customArgs []string
 	if config.Verbose {
 		customArgs = append(customArgs, "--verbose")
 	}
-	if config.SkipPermissions {
+	if config.SkipPermissions && config.PermissionHandler == nil {
 		customArgs = append(customArgs, "--dangerously-skip-permissions")
 	}
 	if len(customArgs) > 0 {
@@ -114,14 +184,29 @@ func buildClientOptions(config *AgentConfig) []claude.ClientOption {
 
 // Execute sends a prompt to the agent and returns a channel of messages.
 // The channel is closed when the agent finishes or an error occurs.
+// The first Execute moves the agent from idle to starting, and the first message to running;
+// an error or cancellation marks it failed. Cancellation reports the context's cause,
+// which wraps ErrTimeout when the query, task or session deadline passed.
 func (a *AgentClient) Execute(ctx context.Context, prompt string) (messages <-chan AgentMessage, errors <-chan error) {
 	msgChan := make(chan AgentMessage)
 	errChan := make(chan error, 1)
 
+	if a.machine.State() == StateIdle {
+		_ = a.machine.Transition(StateStarting, "query sent")
+	}
+
 	go func() {
 		defer close(msgChan)
 		defer close(errChan)
 
+		ctx, cancel := withTimeout(ctx, a.queryTimeout, "query")
+		defer cancel()
+
+		if a.config.PermissionHandler != nil {
+			a.executeSession(ctx, prompt, msgChan, errChan)
+			return
+		}
+
 		sdkMsgChan, sdkErrChan := a.client.QueryStream(ctx, prompt)
 
 		for {
@@ -131,33 +216,28 @@ func (a *AgentClient) Execute(ctx context.Context, prompt string) (messages <-ch
 					return
 				}
 
-				// Extract text content
-				text := claude.GetContentText(msg)
-
-				// Extract AXIOM signals from text
-				signals := signal.Parse(text)
-
-				agentMsg := AgentMessage{
-					Raw:     msg,
-					Text:    text,
-					Signals: signals,
+				if a.machine.State() == StateStarting {
+					_ = a.machine.Transition(StateRunning, "first message")
 				}
 
 				select {
-				case msgChan <- agentMsg:
+				case msgChan <- a.newMessage(msg):
 				case <-ctx.Done():
-					errChan <- ctx.Err()
+					a.fail(context.Cause(ctx))
+					errChan <- context.Cause(ctx)
 					return
 				}
 
 			case err, ok := <-sdkErrChan:
 				if ok && err != nil {
+					a.fail(err)
 					errChan <- err
 				}
 				return
 
 			case <-ctx.Done():
-				errChan <- ctx.Err()
+				a.fail(context.Cause(ctx))
+				errChan <- context.Cause(ctx)
 				return
 			}
 		}
@@ -166,12 +246,51 @@ func (a *AgentClient) Execute(ctx context.Context, prompt string) (messages <-ch
 	return msgChan, errChan
 }
 
+// newMessage extracts text, AXIOM signals and tool calls from an SDK message.
+func (a *AgentClient) newMessage(msg claude.Message) AgentMessage {
+	text := claude.GetContentText(msg)
+	return AgentMessage{
+		Raw:       msg,
+		Text:      text,
+		Signals:   signal.Parse(text),
+		ToolCalls: a.tools.observe(msg),
+	}
+}
+
+// ToolCalls returns every tool call the agent has made, in start order.
+func (a *AgentClient) ToolCalls() []ToolCall {
+	return a.tools.history()
+}
+
+// TouchedFiles returns the sorted paths the agent's tool calls modified,
+// relative to its work directory when inside it.
+func (a *AgentClient) TouchedFiles() []string {
+	return a.tools.files()
+}
+
+// fail moves the agent to the failed state unless it already finished,
+// or a supervisor marked it stuck and decides what happens next.
+func (a *AgentClient) fail(err error) {
+	if state := a.machine.State(); !state.Terminal() && state != StateStuck {
+		_ = a.machine.Transition(StateFailed, err.Error())
+	}
+}
+
+// Machine returns the agent's lifecycle state machine.
+// Orchestration code drives the later states (verifying, stuck, done).
+func (a *AgentClient) Machine() *Machine {
+	return a.machine
+}
+
 // Close releases resources associated with the agent client.
 func (a *AgentClient) Close() error {
+	sessionErr := a.closeSession()
 	if a.client != nil {
-		return a.client.Disconnect()
+		if err := a.client.Disconnect(); err != nil {
+			return err
+		}
 	}
-	return nil
+	return sessionErr
 }
 
 // Config returns the agent configuration.
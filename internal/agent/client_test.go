@@ -1,7 +1,14 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
 )
 
 func TestNewAgentClient_CreatesClient(t *testing.T) {
@@ -29,11 +36,11 @@ func TestNewAgentClient_WithFullConfig(t *testing.T) {
 	config := &AgentConfig{
 		Model:           "claude-sonnet-4-20250514",
 		SystemPrompt:    "You are a helpful assistant",
-		WorkDir:         "/tmp/test",
+		WorkDir:         t.TempDir(),
 		AllowedTools:    []string{"Bash", "Read", "Edit"},
 		Timeout:         "30m",
 		TaskID:          "ax-001",
-		AgentID:         "agent-1",
+		AgentID:         "echo-001",
 		Verbose:         true,
 		SkipPermissions: true,
 	}
@@ -121,3 +128,150 @@ func TestBuildClientOptions_WithEnv(t *testing.T) {
 		t.Errorf("expected 1 option for env, got %d", len(opts))
 	}
 }
+
+func TestNewAgentClient_InvalidAgentID(t *testing.T) {
+	config := &AgentConfig{
+		AgentID: "agent-1",
+	}
+
+	_, err := NewAgentClient(config)
+	if !errors.Is(err, ErrInvalidAgentID) {
+		t.Errorf("expected ErrInvalidAgentID, got %v", err)
+	}
+}
+
+func TestNewAgentClient_StartsIdle(t *testing.T) {
+	client, err := NewAgentClient(&AgentConfig{AgentID: "echo-001"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	m := client.Machine()
+	if m.State() != StateIdle || m.AgentID() != "echo-001" {
+		t.Errorf("got state %s for %q, want idle for echo-001", m.State(), m.AgentID())
+	}
+}
+
+func TestBuildClientOptions_PersonaArgs(t *testing.T) {
+	config := &AgentConfig{
+		SystemPrompt:    "You are Echo",
+		WorkDir:         "/repo/.workspaces/task-001",
+		AllowedTools:    []string{"Read", "Bash"},
+		DisallowedTools: []string{"NotebookEdit"},
+	}
+
+	var applied claude.ClientOptions
+	for _, opt := range buildClientOptions(config) {
+		opt(&applied)
+	}
+
+	args := applied.CustomArgs
+	for _, pair := range [][2]string{
+		{"--system-prompt", "You are Echo"},
+		{"--cwd", "/repo/.workspaces/task-001"},
+		{"--allowed-tools", "Read,Bash"},
+		{"--disallowed-tools", "NotebookEdit"},
+	} {
+		i := slices.Index(args, pair[0])
+		if i < 0 || i+1 >= len(args) || args[i+1] != pair[1] {
+			t.Errorf("expected %s %q in args %q", pair[0], pair[1], args)
+		}
+	}
+}
+
+func TestNewAgentClient_WorkDirValidation(t *testing.T) {
+	project := t.TempDir()
+	inside := filepath.Join(project, ".workspaces", "task-001")
+	if err := os.MkdirAll(inside, 0o755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	file := filepath.Join(project, "main.go")
+	if err := os.WriteFile(file, []byte("package main"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		workDir string
+		wantErr bool
+	}{
+		{"inside project", inside, false},
+		{"project root", project, false},
+		{"missing", filepath.Join(project, "missing"), true},
+		{"not a directory", file, true},
+		{"outside project", t.TempDir(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewAgentClient(&AgentConfig{WorkDir: tt.workDir, ProjectDir: project})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidWorkDir) {
+					t.Errorf("expected ErrInvalidWorkDir, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_ = client.Close()
+		})
+	}
+}
+
+func TestNewAgentClient_ResolvesRelativeWorkDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	config := &AgentConfig{WorkDir: "."}
+	client, err := NewAgentClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if !filepath.IsAbs(client.Config().WorkDir) {
+		t.Errorf("expected absolute WorkDir, got %q", client.Config().WorkDir)
+	}
+	if config.WorkDir != "." {
+		t.Error("caller's config should not be modified")
+	}
+}
+
+func TestBuildClientOptions_PermissionHandlerReplacesSkipAndAllowList(t *testing.T) {
+	config := &AgentConfig{
+		AllowedTools:    []string{"Bash"},
+		DisallowedTools: []string{"WebFetch"},
+		SkipPermissions: true,
+		PermissionHandler: func(context.Context, string, map[string]any, claude.CanUseToolOptions) (claude.PermissionResult, error) {
+			return claude.NewPermissionResultAllow(), nil
+		},
+	}
+
+	var applied claude.ClientOptions
+	for _, opt := range buildClientOptions(config) {
+		opt(&applied)
+	}
+
+	for _, arg := range []string{"--dangerously-skip-permissions", "--allowed-tools"} {
+		if slices.Contains(applied.CustomArgs, arg) {
+			t.Errorf("expected %s to be dropped with a permission handler, got %q", arg, applied.CustomArgs)
+		}
+	}
+	if !slices.Contains(applied.CustomArgs, "--disallowed-tools") {
+		t.Error("expected disallowed tools to still apply")
+	}
+}
+
+func TestAgentClient_PIDWithoutSession(t *testing.T) {
+	client, err := NewAgentClient(&AgentConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if client.PID() != 0 {
+		t.Errorf("expected PID 0 before a session starts, got %d", client.PID())
+	}
+}
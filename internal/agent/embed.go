@@ -1,9 +1,37 @@
 // Package agent provides agent-related functionality including prompt templates.
 package agent
 
-import _ "embed"
+import (
+	"embed"
+	"path"
+)
 
 // AvaPromptTemplate contains the embedded Ava prompt template.
 //
 //go:embed prompts/ava.md.tmpl
 var AvaPromptTemplate string
+
+// promptsFS holds the default prompt and rules templates for every persona:
+// prompts/{persona}.md.tmpl and prompts/{persona}.rules.md.tmpl.
+//
+//go:embed prompts/*.tmpl
+var promptsFS embed.FS
+
+// DefaultPrompt returns the embedded default prompt template for a persona.
+func DefaultPrompt(persona string) (string, bool) {
+	return readPrompt(persona + ".md.tmpl")
+}
+
+// DefaultRules returns the embedded default rules template for a persona.
+func DefaultRules(persona string) (string, bool) {
+	return readPrompt(persona + ".rules.md.tmpl")
+}
+
+// readPrompt reads a file from the embedded prompts directory.
+func readPrompt(name string) (string, bool) {
+	data, err := promptsFS.ReadFile(path.Join("prompts", name))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
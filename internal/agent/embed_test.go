@@ -20,3 +20,31 @@ func TestAvaPromptTemplate_ContainsAvaIdentity(t *testing.T) {
 		t.Error("AvaPromptTemplate does not contain role definition")
 	}
 }
+
+func TestDefaultPrompt_AllPersonas(t *testing.T) {
+	personas := []string{"ava", "axel", "echo", "rex", "cleo", "dex", "max", "ash"}
+
+	for _, p := range personas {
+		prompt, ok := DefaultPrompt(p)
+		if !ok || prompt == "" {
+			t.Errorf("missing default prompt for %s", p)
+		}
+		rules, ok := DefaultRules(p)
+		if !ok || !strings.Contains(rules, "## Must Do") {
+			t.Errorf("missing default rules for %s", p)
+		}
+	}
+}
+
+func TestDefaultPrompt_AvaMatchesTemplate(t *testing.T) {
+	prompt, _ := DefaultPrompt("ava")
+	if prompt != AvaPromptTemplate {
+		t.Error("DefaultPrompt(ava) does not match AvaPromptTemplate")
+	}
+}
+
+func TestDefaultPrompt_Unknown(t *testing.T) {
+	if _, ok := DefaultPrompt("bob"); ok {
+		t.Error("expected no default prompt for unknown persona")
+	}
+}
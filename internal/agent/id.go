@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ErrInvalidAgentID is returned when an agent ID does not match {persona}-{NNN}.
+var ErrInvalidAgentID = errors.New("invalid agent ID")
+
+// agentIDRegex matches IDs like "echo-001" or "echo-1047".
+var agentIDRegex = regexp.MustCompile(`^([a-z]+)-(\d{3,})$`)
+
+// FormatAgentID builds an agent ID from a persona and spawn number (e.g. "echo-047").
+func FormatAgentID(persona string, n int) string {
+	return fmt.Sprintf("%s-%03d", persona, n)
+}
+
+// ParseAgentID splits an agent ID into its persona and spawn number.
+func ParseAgentID(id string) (persona string, n int, err error) {
+	m := agentIDRegex.FindStringSubmatch(id)
+	if m == nil {
+		return "", 0, fmt.Errorf("%w: %q", ErrInvalidAgentID, id)
+	}
+	n, err = strconv.Atoi(m[2])
+	if err != nil || n < 1 {
+		return "", 0, fmt.Errorf("%w: %q", ErrInvalidAgentID, id)
+	}
+	return m[1], n, nil
+}
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatAgentID(t *testing.T) {
+	tests := []struct {
+		persona string
+		n       int
+		want    string
+	}{
+		{"echo", 1, "echo-001"},
+		{"ava", 47, "ava-047"},
+		{"echo", 1047, "echo-1047"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatAgentID(tt.persona, tt.n); got != tt.want {
+			t.Errorf("FormatAgentID(%q, %d) = %q, want %q", tt.persona, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestParseAgentID_Valid(t *testing.T) {
+	persona, n, err := ParseAgentID("echo-047")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if persona != "echo" || n != 47 {
+		t.Errorf("got (%q, %d), want (echo, 47)", persona, n)
+	}
+}
+
+func TestParseAgentID_Invalid(t *testing.T) {
+	for _, id := range []string{"", "echo", "echo-1", "Echo-001", "echo-000", "echo_001", "echo-001x"} {
+		if _, _, err := ParseAgentID(id); !errors.Is(err, ErrInvalidAgentID) {
+			t.Errorf("ParseAgentID(%q): expected ErrInvalidAgentID, got %v", id, err)
+		}
+	}
+}
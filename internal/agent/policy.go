@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidWorkDir is returned when WorkDir does not exist or lies outside the project.
+var ErrInvalidWorkDir = errors.New("invalid work directory")
+
+// Tool groups used to build persona policies.
+var (
+	readTools  = []string{"Read", "Glob", "Grep", "LS"}
+	editTools  = []string{"Edit", "MultiEdit", "Write"}
+	writeTools = append(append([]string{}, editTools...), "NotebookEdit")
+)
+
+// ToolPolicy is the set of tools a persona may and may not use.
+// Allowed tools are auto-approved; disallowed tools are removed from the agent entirely.
+type ToolPolicy struct {
+	Allowed    []string
+	Disallowed []string
+}
+
+// toolPolicies maps persona names to their default tool policy.
+// Only Echo (implementation) and Rex (merge resolution) may run commands;
+// both are confined to their worktree through WorkDir.
+var toolPolicies = map[string]ToolPolicy{
+	"ava":  readOnlyPolicy(),
+	"axel": readOnlyPolicy(),
+	"echo": {Allowed: concat(readTools, editTools, []string{"Bash"}), Disallowed: []string{"NotebookEdit"}},
+	"rex":  {Allowed: concat(readTools, editTools, []string{"Bash"}), Disallowed: []string{"NotebookEdit"}},
+	"cleo": {Allowed: concat(readTools, editTools), Disallowed: []string{"Bash", "NotebookEdit"}},
+	"dex":  readOnlyPolicy(),
+	"max":  readOnlyPolicy(),
+	"ash":  readOnlyPolicy(),
+}
+
+// readOnlyPolicy allows reading the codebase and denies every tool that changes it.
+func readOnlyPolicy() ToolPolicy {
+	return ToolPolicy{
+		Allowed:    concat(readTools),
+		Disallowed: concat(writeTools, []string{"Bash"}),
+	}
+}
+
+// PolicyFor returns the default tool policy for a persona.
+func PolicyFor(persona string) (ToolPolicy, bool) {
+	p, ok := toolPolicies[persona]
+	return p, ok
+}
+
+// ApplyToolPolicy sets config.AllowedTools and config.DisallowedTools from the persona's policy.
+// Tools already on config are kept, so callers can widen or narrow a policy per task.
+func ApplyToolPolicy(config *AgentConfig, persona string) error {
+	p, ok := PolicyFor(persona)
+	if !ok {
+		return fmt.Errorf("no tool policy for persona %q", persona)
+	}
+	config.AllowedTools = mergeTools(p.Allowed, config.AllowedTools)
+	config.DisallowedTools = mergeTools(p.Disallowed, config.DisallowedTools)
+	return nil
+}
+
+// validateWorkDir resolves workDir to an absolute path and checks that it is an
+// existing directory inside projectDir. An empty projectDir skips the containment check.
+func validateWorkDir(workDir, projectDir string) (string, error) {
+	abs, err := filepath.Abs(workDir)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidWorkDir, err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidWorkDir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%w: %s is not a directory", ErrInvalidWorkDir, abs)
+	}
+	if projectDir == "" {
+		return abs, nil
+	}
+
+	root, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidWorkDir, err)
+	}
+	// Compare resolved paths so symlinks cannot escape the project.
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidWorkDir, err)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidWorkDir, err)
+	}
+	if !within(resolvedRoot, resolved) {
+		return "", fmt.Errorf("%w: %s is outside project %s", ErrInvalidWorkDir, abs, root)
+	}
+	return abs, nil
+}
+
+// within reports whether path is root or a descendant of it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// mergeTools appends extra to base, dropping duplicates.
+func mergeTools(base, extra []string) []string {
+	seen := make(map[string]bool, len(base)+len(extra))
+	var out []string
+	for _, t := range concat(base, extra) {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// concat joins tool lists into a new slice.
+func concat(lists ...[]string) []string {
+	var out []string
+	for _, l := range lists {
+		out = append(out, l...)
+	}
+	return out
+}
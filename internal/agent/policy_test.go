@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPolicyFor_AllPersonas(t *testing.T) {
+	for _, name := range []string{"ava", "axel", "echo", "rex", "cleo", "dex", "max", "ash"} {
+		if _, ok := PolicyFor(name); !ok {
+			t.Errorf("no tool policy for %s", name)
+		}
+	}
+	if _, ok := PolicyFor("bob"); ok {
+		t.Error("expected no policy for unknown persona")
+	}
+}
+
+func TestPolicyFor_AvaIsReadOnly(t *testing.T) {
+	p, _ := PolicyFor("ava")
+
+	for _, tool := range []string{"Bash", "Edit", "Write", "MultiEdit", "NotebookEdit"} {
+		if slices.Contains(p.Allowed, tool) {
+			t.Errorf("ava should not be allowed %s", tool)
+		}
+		if !slices.Contains(p.Disallowed, tool) {
+			t.Errorf("ava should have %s disallowed", tool)
+		}
+	}
+	if !slices.Contains(p.Allowed, "Read") {
+		t.Error("ava should be allowed Read")
+	}
+}
+
+func TestPolicyFor_EchoCanEditAndRun(t *testing.T) {
+	p, _ := PolicyFor("echo")
+
+	for _, tool := range []string{"Read", "Edit", "Write", "Bash"} {
+		if !slices.Contains(p.Allowed, tool) {
+			t.Errorf("echo should be allowed %s", tool)
+		}
+	}
+}
+
+func TestApplyToolPolicy_MergesExisting(t *testing.T) {
+	config := &AgentConfig{AllowedTools: []string{"WebFetch", "Read"}}
+
+	if err := ApplyToolPolicy(config, "ava"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Contains(config.AllowedTools, "WebFetch") {
+		t.Error("expected caller's extra tool to be kept")
+	}
+	count := 0
+	for _, tool := range config.AllowedTools {
+		if tool == "Read" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected Read once, got %d times", count)
+	}
+	if !slices.Contains(config.DisallowedTools, "Bash") {
+		t.Error("expected Bash disallowed")
+	}
+
+	if err := ApplyToolPolicy(&AgentConfig{}, "bob"); err == nil {
+		t.Error("expected error for unknown persona")
+	}
+}
@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
+	"github.com/dotcommander/agent-sdk-go/claude/subprocess"
+)
+
+// session is a long-lived streaming CLI connection. It is used instead of one-shot
+// queries when tool permissions are brokered, because the CLI only sends
+// permission requests over the control protocol of a streaming connection.
+type session struct {
+	transport *subprocess.Transport
+	cancel    context.CancelFunc
+}
+
+// connectSession starts the CLI in streaming mode with the permission handler attached.
+func (a *AgentClient) connectSession() (*session, error) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	if a.session != nil {
+		return a.session, nil
+	}
+
+	var opts claude.ClientOptions
+	for _, opt := range buildClientOptions(&a.config) {
+		opt(&opts)
+	}
+
+	transport, err := subprocess.NewTransport(&subprocess.TransportConfig{
+		Model:   a.config.Model,
+		Timeout: a.queryTimeout,
+		// Route permission prompts to the control protocol instead of the terminal.
+		CustomArgs:            append(opts.CustomArgs, "--permission-prompt-tool", "stdio"),
+		Env:                   opts.Env,
+		Cwd:                   a.config.WorkDir,
+		CanUseTool:            a.config.PermissionHandler,
+		EnableControlProtocol: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create session transport: %w", err)
+	}
+
+	// The CLI process lives until Close, not just for one Execute.
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := transport.Connect(ctx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("connect session transport: %w", err)
+	}
+
+	a.session = &session{transport: transport, cancel: cancel}
+	return a.session, nil
+}
+
+// executeSession sends prompt over the streaming connection and forwards messages
+// until the turn's result message arrives.
+func (a *AgentClient) executeSession(ctx context.Context, prompt string, msgChan chan<- AgentMessage, errChan chan<- error) {
+	s, err := a.connectSession()
+	if err != nil {
+		a.fail(err)
+		errChan <- err
+		return
+	}
+
+	sdkMsgChan, sdkErrChan := s.transport.ReceiveMessages(ctx)
+	if err := s.transport.SendMessage(ctx, prompt); err != nil {
+		err = fmt.Errorf("send prompt: %w", err)
+		a.fail(err)
+		errChan <- err
+		return
+	}
+
+	for {
+		select {
+		case msg, ok := <-sdkMsgChan:
+			if !ok {
+				return
+			}
+			if a.machine.State() == StateStarting {
+				_ = a.machine.Transition(StateRunning, "first message")
+			}
+
+			select {
+			case msgChan <- a.newMessage(msg):
+			case <-ctx.Done():
+				_ = a.closeSession()
+				a.fail(context.Cause(ctx))
+				errChan <- context.Cause(ctx)
+				return
+			}
+
+			// A result message ends the turn; the connection stays open for the next prompt.
+			if _, done := msg.(*claude.ResultMessage); done {
+				return
+			}
+
+		case err, ok := <-sdkErrChan:
+			if ok && err != nil {
+				a.fail(err)
+				errChan <- err
+			}
+			return
+
+		case <-ctx.Done():
+			// The abandoned turn would leave its remaining output in the stream,
+			// so the next Execute starts a fresh connection.
+			_ = a.closeSession()
+			a.fail(context.Cause(ctx))
+			errChan <- context.Cause(ctx)
+			return
+		}
+	}
+}
+
+// PID returns the process ID of the agent's streaming CLI child, or 0 if none is running.
+func (a *AgentClient) PID() int {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	if a.session == nil {
+		return 0
+	}
+	return a.session.transport.GetPID()
+}
+
+// closeSession stops the streaming CLI child, if any.
+func (a *AgentClient) closeSession() error {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+
+	if a.session == nil {
+		return nil
+	}
+	err := a.session.transport.Close()
+	a.session.cancel()
+	a.session = nil
+	return err
+}
+
+// Interrupt asks the CLI to end the current turn. The turn's Execute then finishes
+// normally with its result message. One-shot queries cannot be interrupted;
+// cancel their context instead.
+func (a *AgentClient) Interrupt(ctx context.Context) error {
+	a.sessionMu.Lock()
+	s := a.session
+	a.sessionMu.Unlock()
+
+	if s == nil {
+		return errors.New("interrupt: no streaming session")
+	}
+	if err := s.transport.InterruptProtocol(ctx); err != nil {
+		return fmt.Errorf("interrupt: %w", err)
+	}
+	return nil
+}
+
+// Reset drops the agent's conversation so the next Execute starts from a fresh session.
+// Tool call history is kept.
+func (a *AgentClient) Reset() error {
+	return a.closeSession()
+}
@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// State is an agent lifecycle state.
+type State string
+
+const (
+	StateIdle      State = "idle"
+	StateStarting  State = "starting"
+	StateRunning   State = "running"
+	StateVerifying State = "verifying"
+	StateStuck     State = "stuck"
+	StateDone      State = "done"
+	StateFailed    State = "failed"
+)
+
+// ErrInvalidTransition is returned when a state change is not allowed from the current state.
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// transitions lists the states reachable from each state.
+var transitions = map[State][]State{
+	StateIdle:      {StateStarting, StateFailed},
+	StateStarting:  {StateRunning, StateStuck, StateFailed},
+	StateRunning:   {StateVerifying, StateStuck, StateDone, StateFailed},
+	StateVerifying: {StateRunning, StateDone, StateFailed},
+	StateStuck:     {StateRunning, StateStarting, StateFailed},
+}
+
+// subscriberBuffer is the number of events a slow subscriber may fall behind before events are dropped.
+const subscriberBuffer = 16
+
+// Terminal reports whether the state is final.
+func (s State) Terminal() bool {
+	return s == StateDone || s == StateFailed
+}
+
+// CanTransition reports whether an agent may move from one state to another.
+func CanTransition(from, to State) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StateChange is emitted each time an agent changes state.
+type StateChange struct {
+	AgentID string    `json:"agentId"`
+	From    State     `json:"from"`
+	To      State     `json:"to"`
+	Reason  string    `json:"reason,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Machine is an agent's lifecycle state machine. It starts idle, validates every
+// transition, keeps a timestamped history, and notifies subscribers of each change.
+type Machine struct {
+	agentID string
+
+	mu      sync.Mutex
+	state   State
+	since   time.Time
+	history []StateChange
+	subs    map[int]chan StateChange
+	nextSub int
+	now     func() time.Time
+}
+
+// NewMachine creates a Machine for agentID in the idle state.
+func NewMachine(agentID string) *Machine {
+	return &Machine{
+		agentID: agentID,
+		state:   StateIdle,
+		since:   time.Now(),
+		subs:    make(map[int]chan StateChange),
+		now:     time.Now,
+	}
+}
+
+// AgentID returns the agent this machine belongs to.
+func (m *Machine) AgentID() string {
+	return m.agentID
+}
+
+// State returns the current state.
+func (m *Machine) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Since returns when the current state was entered.
+func (m *Machine) Since() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.since
+}
+
+// History returns every transition so far, oldest first.
+func (m *Machine) History() []StateChange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]StateChange(nil), m.history...)
+}
+
+// Transition moves the agent to state to, recording reason.
+// Reaching a terminal state closes all subscriptions.
+func (m *Machine) Transition(to State, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !CanTransition(m.state, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, m.state, to)
+	}
+
+	change := StateChange{
+		AgentID: m.agentID,
+		From:    m.state,
+		To:      to,
+		Reason:  reason,
+		At:      m.now(),
+	}
+	m.state = to
+	m.since = change.At
+	m.history = append(m.history, change)
+
+	for _, ch := range m.subs {
+		select {
+		case ch <- change:
+		default:
+			log.Printf("[WARN] dropping %s state change for slow subscriber", m.agentID)
+		}
+	}
+	if to.Terminal() {
+		for id, ch := range m.subs {
+			close(ch)
+			delete(m.subs, id)
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel of future state changes and a func to cancel the subscription.
+// The channel is closed on cancel or once the agent reaches a terminal state.
+func (m *Machine) Subscribe() (<-chan StateChange, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch := make(chan StateChange, subscriberBuffer)
+	if m.state.Terminal() {
+		close(ch)
+		return ch, func() {}
+	}
+
+	id := m.nextSub
+	m.nextSub++
+	m.subs[id] = ch
+
+	cancel := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if sub, ok := m.subs[id]; ok {
+			close(sub)
+			delete(m.subs, id)
+		}
+	}
+	return ch, cancel
+}
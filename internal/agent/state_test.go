@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMachine_StartsIdle(t *testing.T) {
+	m := NewMachine("echo-001")
+
+	if m.State() != StateIdle {
+		t.Errorf("got state %s, want %s", m.State(), StateIdle)
+	}
+	if len(m.History()) != 0 {
+		t.Error("expected empty history")
+	}
+}
+
+func TestMachine_ValidTransitions(t *testing.T) {
+	m := NewMachine("echo-001")
+
+	for _, to := range []State{StateStarting, StateRunning, StateVerifying, StateRunning, StateVerifying, StateDone} {
+		if err := m.Transition(to, ""); err != nil {
+			t.Fatalf("transition to %s: %v", to, err)
+		}
+	}
+
+	history := m.History()
+	if len(history) != 6 {
+		t.Fatalf("got %d history entries, want 6", len(history))
+	}
+	if history[0].From != StateIdle || history[0].To != StateStarting || history[0].AgentID != "echo-001" {
+		t.Errorf("unexpected first change: %+v", history[0])
+	}
+	if m.Since() != history[5].At {
+		t.Error("expected Since to match last transition time")
+	}
+}
+
+func TestMachine_InvalidTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		path []State
+		to   State
+	}{
+		{"idle to running", nil, StateRunning},
+		{"starting to done", []State{StateStarting}, StateDone},
+		{"done is final", []State{StateStarting, StateRunning, StateDone}, StateRunning},
+		{"failed is final", []State{StateFailed}, StateStarting},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMachine("echo-001")
+			for _, s := range tt.path {
+				if err := m.Transition(s, ""); err != nil {
+					t.Fatalf("setup transition to %s: %v", s, err)
+				}
+			}
+
+			err := m.Transition(tt.to, "")
+			if !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("expected ErrInvalidTransition, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMachine_SubscribeReceivesChanges(t *testing.T) {
+	m := NewMachine("echo-001")
+	events, cancel := m.Subscribe()
+	defer cancel()
+
+	_ = m.Transition(StateStarting, "spawned")
+	_ = m.Transition(StateFailed, "boom")
+
+	var got []StateChange
+	for e := range events {
+		got = append(got, e)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2 (channel should close on terminal state)", len(got))
+	}
+	if got[0].Reason != "spawned" || got[1].To != StateFailed {
+		t.Errorf("unexpected events: %+v", got)
+	}
+}
+
+func TestMachine_CancelSubscription(t *testing.T) {
+	m := NewMachine("echo-001")
+	events, cancel := m.Subscribe()
+	cancel()
+
+	_ = m.Transition(StateStarting, "")
+
+	if _, ok := <-events; ok {
+		t.Error("expected closed channel after cancel")
+	}
+	cancel() // safe to call twice
+}
+
+func TestMachine_SubscribeAfterTerminal(t *testing.T) {
+	m := NewMachine("echo-001")
+	_ = m.Transition(StateFailed, "")
+
+	events, _ := m.Subscribe()
+	if _, ok := <-events; ok {
+		t.Error("expected closed channel for terminal machine")
+	}
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrTimeout is the cause of contexts cancelled by a query, task or session deadline.
+	ErrTimeout = errors.New("timed out")
+	// ErrInvalidTimeout is returned for durations that do not parse or are negative.
+	ErrInvalidTimeout = errors.New("invalid timeout")
+)
+
+// ParseTimeout parses a duration such as "30m" or "1h30m". An empty string means no limit.
+func ParseTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidTimeout, s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%w: %q is negative", ErrInvalidTimeout, s)
+	}
+	return d, nil
+}
+
+// Timeouts bounds how long agents may run. Zero means no limit.
+type Timeouts struct {
+	// Query limits one Execute call.
+	Query time.Duration
+	// Task limits all runs for one task, including nudges, restarts and retries.
+	Task time.Duration
+	// Session limits everything started by this AXIOM process.
+	Session time.Duration
+}
+
+// TaskContext returns a context that is cancelled with an ErrTimeout cause when the task limit passes.
+func (t Timeouts) TaskContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(parent, t.Task, "task")
+}
+
+// SessionContext returns a context that is cancelled with an ErrTimeout cause when the session limit passes.
+func (t Timeouts) SessionContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(parent, t.Session, "session")
+}
+
+// withTimeout applies d to parent unless d is zero.
+func withTimeout(parent context.Context, d time.Duration, scope string) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeoutCause(parent, d, fmt.Errorf("%w: %s exceeded %s", ErrTimeout, scope, d))
+}
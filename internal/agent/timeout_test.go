@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"30m", 30 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"thirty", 0, true},
+		{"30", 0, true},
+		{"-5m", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeout(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidTimeout) {
+				t.Errorf("ParseTimeout(%q): got %v, want ErrInvalidTimeout", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTimeout(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestTimeouts_TaskContextCause(t *testing.T) {
+	ctx, cancel := Timeouts{Task: 10 * time.Millisecond}.TaskContext(context.Background())
+	defer cancel()
+
+	<-ctx.Done()
+
+	if cause := context.Cause(ctx); !errors.Is(cause, ErrTimeout) {
+		t.Errorf("got cause %v, want ErrTimeout", cause)
+	}
+}
+
+func TestTimeouts_ZeroMeansNoDeadline(t *testing.T) {
+	ctx, cancel := Timeouts{}.SessionContext(context.Background())
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline")
+	}
+}
+
+func TestNewAgentClient_InvalidTimeout(t *testing.T) {
+	_, err := NewAgentClient(&AgentConfig{Timeout: "soon"})
+
+	if !errors.Is(err, ErrInvalidTimeout) {
+		t.Errorf("got %v, want ErrInvalidTimeout", err)
+	}
+}
@@ -0,0 +1,313 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
+)
+
+// maxToolResultLen bounds how much of a tool's output is kept on a ToolCall.
+const maxToolResultLen = 4096
+
+// pathInputs maps the tools that modify files to the input key holding the path.
+var pathInputs = map[string]string{
+	"Edit":         "file_path",
+	"MultiEdit":    "file_path",
+	"Write":        "file_path",
+	"NotebookEdit": "notebook_path",
+}
+
+// ToolCall is one tool invocation extracted from an agent's messages.
+type ToolCall struct {
+	ID    string         `json:"id,omitempty"`
+	Name  string         `json:"name"`
+	Input map[string]any `json:"input,omitempty"`
+
+	// Summary describes the call for people, e.g. "edited internal/foo.go" or "ran go test ./...".
+	Summary string `json:"summary"`
+
+	// Files lists the paths the call modifies, relative to the agent's work directory when inside it.
+	Files []string `json:"files,omitempty"`
+
+	// Result is the tool's output, truncated; Error is set instead when the tool reported failure.
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	Done      bool          `json:"done"`
+}
+
+// toolTracker pairs tool uses with their results across an agent's messages.
+// The CLI does not always report tool_use IDs or results, so unmatched results
+// close the oldest pending call, and calls still pending when the next assistant
+// message or the turn's result arrives are closed without output.
+type toolTracker struct {
+	workDir string
+	now     func() time.Time
+
+	mu      sync.Mutex
+	calls   []*ToolCall
+	pending []*ToolCall
+	touched map[string]bool
+}
+
+// newToolTracker creates a tracker that reports paths relative to workDir.
+func newToolTracker(workDir string) *toolTracker {
+	return &toolTracker{
+		workDir: workDir,
+		now:     time.Now,
+		touched: make(map[string]bool),
+	}
+}
+
+// observe extracts the tool calls started or finished by msg.
+func (t *toolTracker) observe(msg claude.Message) []ToolCall {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var changed []ToolCall
+	switch m := msg.(type) {
+	case *claude.AssistantMessage:
+		// A new assistant message means every earlier tool use was already answered.
+		changed = t.closePending()
+		for _, block := range m.Content {
+			use, ok := block.(*claude.ToolUseBlock)
+			if !ok {
+				continue
+			}
+			call := t.start(use)
+			changed = append(changed, *call)
+		}
+	case *claude.UserMessage:
+		for _, result := range toolResults(m.Content) {
+			if call := t.finish(result); call != nil {
+				changed = append(changed, *call)
+			}
+		}
+	case *claude.ResultMessage:
+		changed = t.closePending()
+	}
+	return changed
+}
+
+// start records a new pending call.
+func (t *toolTracker) start(use *claude.ToolUseBlock) *ToolCall {
+	call := &ToolCall{
+		ID:        use.ToolUseID,
+		Name:      use.Name,
+		Input:     use.Input,
+		StartedAt: t.now(),
+	}
+	if key, ok := pathInputs[use.Name]; ok {
+		if path := inputString(use.Input, key); path != "" {
+			path = t.relative(path)
+			call.Files = []string{path}
+			t.touched[path] = true
+		}
+	}
+	call.Summary = summarize(call.Name, call.Input, t.relative)
+
+	t.calls = append(t.calls, call)
+	t.pending = append(t.pending, call)
+	return call
+}
+
+// finish completes the pending call a result belongs to, or the oldest one when
+// the result carries no known ID.
+func (t *toolTracker) finish(result toolResult) *ToolCall {
+	i := -1
+	for j, call := range t.pending {
+		if result.id != "" && call.ID == result.id {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		if len(t.pending) == 0 {
+			return nil
+		}
+		i = 0
+	}
+
+	call := t.pending[i]
+	t.pending = append(t.pending[:i], t.pending[i+1:]...)
+	t.complete(call)
+	if result.isError {
+		call.Error = result.content
+	} else {
+		call.Result = result.content
+	}
+	return call
+}
+
+// closePending completes every pending call without output.
+func (t *toolTracker) closePending() []ToolCall {
+	closed := make([]ToolCall, 0, len(t.pending))
+	for _, call := range t.pending {
+		t.complete(call)
+		closed = append(closed, *call)
+	}
+	t.pending = nil
+	return closed
+}
+
+// complete marks call done and records its duration.
+func (t *toolTracker) complete(call *ToolCall) {
+	call.Done = true
+	call.Duration = t.now().Sub(call.StartedAt)
+}
+
+// history returns copies of every call seen so far, in start order.
+func (t *toolTracker) history() []ToolCall {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	calls := make([]ToolCall, len(t.calls))
+	for i, call := range t.calls {
+		calls[i] = *call
+	}
+	return calls
+}
+
+// files returns the sorted paths modified by any call so far.
+func (t *toolTracker) files() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	files := make([]string, 0, len(t.touched))
+	for path := range t.touched {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// relative returns path relative to the work directory when it lies inside it.
+func (t *toolTracker) relative(path string) string {
+	if t.workDir == "" || !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(t.workDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// toolResult is a tool_result block from a user message.
+type toolResult struct {
+	id      string
+	content string
+	isError bool
+}
+
+// toolResults extracts tool results from a user message's content, which is either
+// parsed content blocks or the raw decoded JSON.
+func toolResults(content any) []toolResult {
+	var results []toolResult
+	switch c := content.(type) {
+	case []claude.ContentBlock:
+		for _, block := range c {
+			if b, ok := block.(*claude.ToolResultBlock); ok {
+				results = append(results, toolResult{
+					id:      b.ToolUseID,
+					content: resultText(b.Content),
+					isError: b.IsError != nil && *b.IsError,
+				})
+			}
+		}
+	case []any:
+		for _, item := range c {
+			block, ok := item.(map[string]any)
+			if !ok || block["type"] != "tool_result" {
+				continue
+			}
+			id, _ := block["tool_use_id"].(string)
+			isError, _ := block["is_error"].(bool)
+			results = append(results, toolResult{id: id, content: resultText(block["content"]), isError: isError})
+		}
+	}
+	return results
+}
+
+// resultText flattens a tool result's content (a string or a list of text blocks).
+func resultText(content any) string {
+	var text string
+	switch c := content.(type) {
+	case string:
+		text = c
+	case []any:
+		var parts []string
+		for _, item := range c {
+			if block, ok := item.(map[string]any); ok {
+				if s, ok := block["text"].(string); ok {
+					parts = append(parts, s)
+				}
+			}
+		}
+		text = strings.Join(parts, "\n")
+	}
+	if len(text) > maxToolResultLen {
+		text = text[:maxToolResultLen] + "…"
+	}
+	return text
+}
+
+// summarize describes a tool call in a few words.
+func summarize(name string, input map[string]any, rel func(string) string) string {
+	path := func(key string) string { return rel(inputString(input, key)) }
+
+	switch name {
+	case "Edit", "MultiEdit":
+		return "edited " + path("file_path")
+	case "Write":
+		return "wrote " + path("file_path")
+	case "NotebookEdit":
+		return "edited " + path("notebook_path")
+	case "Read":
+		return "read " + path("file_path")
+	case "Bash":
+		return "ran " + shorten(inputString(input, "command"))
+	case "Glob":
+		return "searched for files matching " + inputString(input, "pattern")
+	case "Grep":
+		return fmt.Sprintf("searched for %q", inputString(input, "pattern"))
+	case "LS":
+		return "listed " + path("path")
+	case "WebFetch":
+		return "fetched " + inputString(input, "url")
+	case "WebSearch":
+		return "searched the web for " + shorten(inputString(input, "query"))
+	case "TodoWrite":
+		return "updated the todo list"
+	case "Task":
+		return "started a subagent: " + shorten(inputString(input, "description"))
+	default:
+		return "used " + name
+	}
+}
+
+// shorten keeps the first line of s, cut to a length that fits a status line.
+func shorten(s string) string {
+	const limit = 80
+	line, _, multiline := strings.Cut(strings.TrimSpace(s), "\n")
+	if len(line) > limit {
+		return line[:limit] + "…"
+	}
+	if multiline {
+		return line + " …"
+	}
+	return line
+}
+
+// inputString returns a string field from a tool's input.
+func inputString(input map[string]any, key string) string {
+	s, _ := input[key].(string)
+	return s
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
+)
+
+// fakeClock returns a clock that advances by step on every call.
+func fakeClock(step time.Duration) func() time.Time {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func toolUse(id, name string, input map[string]any) *claude.ToolUseBlock {
+	return &claude.ToolUseBlock{ToolUseID: id, Name: name, Input: input}
+}
+
+func TestToolTracker_PairsResultsByID(t *testing.T) {
+	tracker := newToolTracker("/work")
+	tracker.now = fakeClock(time.Second)
+
+	started := tracker.observe(&claude.AssistantMessage{Content: []claude.ContentBlock{
+		&claude.TextBlock{Text: "Running tests"},
+		toolUse("t1", "Bash", map[string]any{"command": "go test ./..."}),
+		toolUse("t2", "Edit", map[string]any{"file_path": "/work/internal/foo.go"}),
+	}})
+	if len(started) != 2 || started[0].Done || started[1].Done {
+		t.Fatalf("expected two started calls, got %+v", started)
+	}
+	if started[0].Summary != "ran go test ./..." || started[1].Summary != "edited internal/foo.go" {
+		t.Errorf("unexpected summaries: %q, %q", started[0].Summary, started[1].Summary)
+	}
+
+	failed := true
+	finished := tracker.observe(&claude.UserMessage{Content: []claude.ContentBlock{
+		&claude.ToolResultBlock{ToolUseID: "t2", Content: "no such file", IsError: &failed},
+		&claude.ToolResultBlock{ToolUseID: "t1", Content: "ok  	pkg"},
+	}})
+	if len(finished) != 2 {
+		t.Fatalf("expected two finished calls, got %+v", finished)
+	}
+	if finished[0].Name != "Edit" || finished[0].Error != "no such file" || finished[0].Result != "" {
+		t.Errorf("unexpected edit result: %+v", finished[0])
+	}
+	if finished[1].Name != "Bash" || finished[1].Result != "ok  	pkg" || !finished[1].Done || finished[1].Duration <= 0 {
+		t.Errorf("unexpected bash result: %+v", finished[1])
+	}
+}
+
+func TestToolTracker_RawResultsWithoutIDs(t *testing.T) {
+	tracker := newToolTracker("")
+
+	tracker.observe(&claude.AssistantMessage{Content: []claude.ContentBlock{
+		toolUse("", "Read", map[string]any{"file_path": "a.go"}),
+		toolUse("", "Read", map[string]any{"file_path": "b.go"}),
+	}})
+	finished := tracker.observe(&claude.UserMessage{Content: []any{
+		map[string]any{"type": "tool_result", "content": []any{map[string]any{"type": "text", "text": "package a"}}},
+	}})
+
+	if len(finished) != 1 || finished[0].Summary != "read a.go" || finished[0].Result != "package a" {
+		t.Fatalf("expected oldest call finished, got %+v", finished)
+	}
+
+	closed := tracker.observe(&claude.ResultMessage{})
+	if len(closed) != 1 || closed[0].Summary != "read b.go" || !closed[0].Done {
+		t.Errorf("expected result message to close pending call, got %+v", closed)
+	}
+}
+
+func TestToolTracker_NextAssistantMessageClosesPending(t *testing.T) {
+	tracker := newToolTracker("")
+
+	tracker.observe(&claude.AssistantMessage{Content: []claude.ContentBlock{
+		toolUse("t1", "Grep", map[string]any{"pattern": "TODO"}),
+	}})
+	changed := tracker.observe(&claude.AssistantMessage{Content: []claude.ContentBlock{
+		&claude.TextBlock{Text: "Found it"},
+	}})
+
+	if len(changed) != 1 || !changed[0].Done || changed[0].Summary != `searched for "TODO"` {
+		t.Errorf("expected pending grep closed, got %+v", changed)
+	}
+}
+
+func TestToolTracker_TouchedFiles(t *testing.T) {
+	tracker := newToolTracker("/work")
+
+	tracker.observe(&claude.AssistantMessage{Content: []claude.ContentBlock{
+		toolUse("t1", "Write", map[string]any{"file_path": "/work/b.go"}),
+		toolUse("t2", "MultiEdit", map[string]any{"file_path": "/work/a.go"}),
+		toolUse("t3", "Read", map[string]any{"file_path": "/work/c.go"}),
+		toolUse("t4", "Edit", map[string]any{"file_path": "/elsewhere/d.go"}),
+		toolUse("t5", "Write", map[string]any{"file_path": "/work/b.go"}),
+	}})
+
+	got := tracker.files()
+	want := []string{"/elsewhere/d.go", "a.go", "b.go"}
+	if len(got) != len(want) {
+		t.Fatalf("got files %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got files %v, want %v", got, want)
+			break
+		}
+	}
+	if len(tracker.history()) != 5 {
+		t.Errorf("got %d calls in history, want 5", len(tracker.history()))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	rel := func(path string) string { return path }
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  string
+	}{
+		{"Bash", map[string]any{"command": "make build\nmake test"}, "ran make build …"},
+		{"Glob", map[string]any{"pattern": "**/*.go"}, "searched for files matching **/*.go"},
+		{"NotebookEdit", map[string]any{"notebook_path": "nb.ipynb"}, "edited nb.ipynb"},
+		{"TodoWrite", nil, "updated the todo list"},
+		{"mcp__db__query", nil, "used mcp__db__query"},
+	}
+	for _, tt := range tests {
+		if got := summarize(tt.name, tt.input, rel); got != tt.want {
+			t.Errorf("summarize(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/deligoez/axiom/internal/escalation"
+)
+
+// Mode selects how much of the loop waits for a human (docs/02-modes.md).
+type Mode string
+
+const (
+	// ModeSemiAuto, the default, waits for approval before starting each task,
+	// before merging it and on every BLOCKED and PENDING signal.
+	ModeSemiAuto Mode = "semi-auto"
+	// ModeAutopilot runs without approvals; PENDING signals still go to the escalation inbox.
+	ModeAutopilot Mode = "autopilot"
+)
+
+// Valid reports whether m is a known mode.
+func (m Mode) Valid() bool {
+	return m == ModeSemiAuto || m == ModeAutopilot
+}
+
+// Checkpoint is where a semi-auto task waits for approval.
+type Checkpoint string
+
+const (
+	// CheckpointStart comes before a task's agent is spawned.
+	CheckpointStart Checkpoint = "start"
+	// CheckpointMerge comes after verification passed, before the branch is queued for merging.
+	CheckpointMerge Checkpoint = "merge"
+	// CheckpointBlocked and CheckpointPending follow the agent's signals.
+	CheckpointBlocked Checkpoint = "blocked"
+	CheckpointPending Checkpoint = "pending"
+)
+
+var (
+	// ErrInvalidMode is returned by SetMode for modes other than semi-auto and autopilot.
+	ErrInvalidMode = errors.New("invalid mode")
+	// ErrApprovalNotFound is returned when deciding an approval that is not waiting.
+	ErrApprovalNotFound = errors.New("approval not found")
+
+	// errRejected marks a task whose start a human rejected; it is not picked
+	// again in the same run.
+	errRejected = errors.New("start rejected")
+)
+
+// Approval is a semi-auto checkpoint waiting for a human. Approve lets the task
+// proceed, Reject stops it, and Respond sends the message to the agent; see
+// each checkpoint in runTask.
+type Approval struct {
+	ID         string     `json:"id"`
+	TaskID     string     `json:"taskId"`
+	Checkpoint Checkpoint `json:"checkpoint"`
+	// Reason is the agent's signal payload, or what is about to happen.
+	Reason string `json:"reason"`
+	// Detail is what the human needs to decide, e.g. the task or the verification summary.
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// answer is a human's decision on an approval. auto answers come from switching
+// to autopilot, and the task carries on as autopilot would.
+type answer struct {
+	decision escalation.Decision
+	message  string
+	auto     bool
+}
+
+// approval is a waiting Approval with the channel its task listens on.
+type approval struct {
+	Approval
+	answers chan answer
+}
+
+// Mode returns the current mode.
+func (a *Autopilot) Mode() Mode {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.mode
+}
+
+// SetMode switches the mode, also during a run. Switching to autopilot releases
+// the waiting approvals: tasks start and merge, and BLOCKED and PENDING signals
+// are handled as in autopilot. Switching to semi-auto lets running agents finish
+// their current step; the next checkpoint waits.
+func (a *Autopilot) SetMode(m Mode) error {
+	if !m.Valid() {
+		return fmt.Errorf("%w: %q (want %s or %s)", ErrInvalidMode, m, ModeSemiAuto, ModeAutopilot)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.mode == m {
+		return nil
+	}
+	a.mode = m
+	log.Printf("[autopilot] mode: %s", m)
+	if m == ModeAutopilot {
+		for id, ap := range a.approvals {
+			delete(a.approvals, id)
+			ap.answers <- answer{auto: true}
+		}
+	}
+	a.signal()
+	return nil
+}
+
+// Approvals returns the checkpoints waiting for a human, oldest first.
+func (a *Autopilot) Approvals() []Approval {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.approvalsLocked()
+}
+
+// approvalsLocked lists the waiting approvals with mu held.
+func (a *Autopilot) approvalsLocked() []Approval {
+	list := make([]Approval, 0, len(a.approvals))
+	for _, ap := range a.approvals {
+		list = append(list, ap.Approval)
+	}
+	slices.SortFunc(list, func(x, y Approval) int {
+		if c := x.CreatedAt.Compare(y.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(x.ID, y.ID)
+	})
+	return list
+}
+
+// Decide answers the approval with id. Respond requires a message; it returns
+// escalation.ErrInvalidDecision otherwise, and for unknown decisions.
+func (a *Autopilot) Decide(id string, d escalation.Decision, message string) error {
+	message = strings.TrimSpace(message)
+	switch d {
+	case escalation.Approve, escalation.Reject:
+	case escalation.Respond:
+		if message == "" {
+			return fmt.Errorf("%w: respond needs a message", escalation.ErrInvalidDecision)
+		}
+	default:
+		return fmt.Errorf("%w: %q", escalation.ErrInvalidDecision, d)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ap, ok := a.approvals[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+	delete(a.approvals, id)
+	ap.answers <- answer{decision: d, message: message}
+	log.Printf("[autopilot] %s %s %s: %s", id, ap.TaskID, ap.Checkpoint, d)
+	return nil
+}
+
+// await waits for a human to decide the task's checkpoint in semi-auto mode; in
+// autopilot mode it returns an auto answer at once. The slot shows the approval
+// while it waits. It returns ctx's cause when ctx ends first.
+func (a *Autopilot) await(ctx context.Context, sl *Slot, cp Checkpoint, reason, detail string) (answer, error) {
+	a.mu.Lock()
+	if a.mode != ModeSemiAuto {
+		a.mu.Unlock()
+		return answer{auto: true}, nil
+	}
+	a.nextApproval++
+	ap := &approval{
+		Approval: Approval{
+			ID:         fmt.Sprintf("apr-%03d", a.nextApproval),
+			TaskID:     sl.TaskID,
+			Checkpoint: cp,
+			Reason:     reason,
+			Detail:     detail,
+			CreatedAt:  time.Now().UTC(),
+		},
+		answers: make(chan answer, 1),
+	}
+	a.approvals[ap.ID] = ap
+	sl.Approval = ap.ID
+	a.mu.Unlock()
+	log.Printf("[autopilot] %s %s waits for approval (%s): %s", ap.ID, sl.TaskID, cp, reason)
+
+	var ans answer
+	var err error
+	select {
+	case ans = <-ap.answers:
+	case <-ctx.Done():
+		err = context.Cause(ctx)
+	}
+	a.mu.Lock()
+	delete(a.approvals, ap.ID)
+	sl.Approval = ""
+	a.mu.Unlock()
+	return ans, err
+}
+
+// humanNote formats a human's message for the agent's next prompt.
+func humanNote(title, message string) string {
+	return fmt.Sprintf("\n\n## %s\n\n%s\n", title, message)
+}
@@ -0,0 +1,160 @@
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/escalation"
+	"github.com/deligoez/axiom/internal/signal"
+)
+
+// awaitApproval waits until the task waits at cp and returns its approval.
+func awaitApproval(t *testing.T, a *Autopilot, taskID string, cp Checkpoint) Approval {
+	t.Helper()
+	var found Approval
+	waitFor(t, string(cp)+" approval of "+taskID, func() bool {
+		for _, ap := range a.Approvals() {
+			if ap.TaskID == taskID && ap.Checkpoint == cp {
+				found = ap
+				return true
+			}
+		}
+		return false
+	})
+	return found
+}
+
+// runAsync runs a in the background and returns the channel its result arrives on.
+func runAsync(a *Autopilot) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.Run(context.Background())
+		done <- err
+	}()
+	return done
+}
+
+func TestSemiAuto_WaitsForStartAndMerge(t *testing.T) {
+	agents := &fakeAgents{turns: map[string][]turn{"task-1": {{signals: complete()}}}}
+	a, caseFile := setup(t, Config{}, []casestore.Case{task("task-1")}, agents, Options{Mode: ModeSemiAuto})
+
+	done := runAsync(a)
+
+	start := awaitApproval(t, a, "task-1", CheckpointStart)
+	if st := a.Status(); st.Mode != ModeSemiAuto || len(st.Slots) != 1 || st.Slots[0].Approval != start.ID {
+		t.Errorf("got status %+v, want the slot waiting for %s", st, start.ID)
+	}
+	if agents.prompts("task-1") != nil {
+		t.Fatal("agent started before the start was approved")
+	}
+	if err := a.Decide(start.ID, escalation.Respond, "keep the public API unchanged"); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+
+	merge := awaitApproval(t, a, "task-1", CheckpointMerge)
+	if c := loadCases(t, caseFile)["task-1"]; c.Status != casestore.StatusReview || !c.Execution.VerificationPassed {
+		t.Errorf("got status %s, execution %+v, want a verified task in review", c.Status, c.Execution)
+	}
+	if err := a.Decide(merge.ID, escalation.Approve, ""); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c := loadCases(t, caseFile)["task-1"]; c.Status != casestore.StatusDone {
+		t.Errorf("got status %s, want done", c.Status)
+	}
+	if prompts := agents.prompts("task-1"); len(prompts) != 1 || !strings.Contains(prompts[0], "keep the public API unchanged") {
+		t.Errorf("expected the start notes in the prompt, got %q", prompts)
+	}
+}
+
+func TestSemiAuto_SignalsAndRejections(t *testing.T) {
+	agents := &fakeAgents{turns: map[string][]turn{
+		"task-1": {
+			{signals: []signal.Signal{{Type: signal.Blocked, Payload: "missing API key"}}},
+			{signals: []signal.Signal{{Type: signal.Pending, Payload: "drop the old table?"}}},
+			{signals: complete()},
+		},
+	}}
+	cases := []casestore.Case{task("task-1"), task("task-2")}
+	cases[0].Labels = []string{LabelCritical}
+	a, caseFile := setup(t, Config{}, cases, agents, Options{Mode: ModeSemiAuto, MaxParallel: 1})
+
+	done := runAsync(a)
+
+	if err := a.Decide(awaitApproval(t, a, "task-1", CheckpointStart).ID, escalation.Approve, ""); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	blocked := awaitApproval(t, a, "task-1", CheckpointBlocked)
+	if blocked.Reason != "missing API key" {
+		t.Errorf("got reason %q", blocked.Reason)
+	}
+	if err := a.Decide(blocked.ID, escalation.Respond, "the key is in .env.example"); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	if err := a.Decide(awaitApproval(t, a, "task-1", CheckpointPending).ID, escalation.Approve, ""); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	if err := a.Decide(awaitApproval(t, a, "task-1", CheckpointMerge).ID, escalation.Reject, "split it up first"); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+	if err := a.Decide(awaitApproval(t, a, "task-2", CheckpointStart).ID, escalation.Reject, ""); err != nil {
+		t.Fatalf("decide: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := loadCases(t, caseFile)
+	if c := got["task-1"]; c.Status != casestore.StatusReview || c.Execution.LastError != "rejected: merge: split it up first" {
+		t.Errorf("task-1: got status %s, execution %+v", c.Status, c.Execution)
+	}
+	if c := got["task-2"]; c.Status != casestore.StatusPending || c.Execution != nil {
+		t.Errorf("task-2: got status %s, execution %+v, want it untouched", c.Status, c.Execution)
+	}
+	prompts := agents.prompts("task-1")
+	if len(prompts) != 3 || !strings.Contains(prompts[1], "the key is in .env.example") || !strings.Contains(prompts[2], approvedDecision) {
+		t.Errorf("expected the human's answers in the prompts, got %q", prompts)
+	}
+}
+
+func TestSetMode_AutopilotReleasesApprovals(t *testing.T) {
+	agents := &fakeAgents{turns: map[string][]turn{"task-1": {{signals: complete()}}}}
+	a, caseFile := setup(t, Config{}, []casestore.Case{task("task-1")}, agents, Options{Mode: ModeSemiAuto})
+
+	done := runAsync(a)
+	awaitApproval(t, a, "task-1", CheckpointStart)
+
+	if err := a.SetMode(ModeAutopilot); err != nil {
+		t.Fatalf("set mode: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c := loadCases(t, caseFile)["task-1"]; c.Status != casestore.StatusDone {
+		t.Errorf("got status %s, want done", c.Status)
+	}
+	if st := a.Status(); st.Mode != ModeAutopilot || len(st.Approvals) != 0 {
+		t.Errorf("got status %+v", st)
+	}
+}
+
+func TestSetModeAndDecide_Errors(t *testing.T) {
+	a, _ := setup(t, Config{}, nil, &fakeAgents{}, Options{})
+
+	if err := a.SetMode("manual"); !errors.Is(err, ErrInvalidMode) {
+		t.Errorf("got %v, want ErrInvalidMode", err)
+	}
+	if err := a.Decide("apr-001", escalation.Approve, ""); !errors.Is(err, ErrApprovalNotFound) {
+		t.Errorf("got %v, want ErrApprovalNotFound", err)
+	}
+	if err := a.Decide("apr-001", escalation.Respond, " "); !errors.Is(err, escalation.ErrInvalidDecision) {
+		t.Errorf("got %v, want ErrInvalidDecision", err)
+	}
+}
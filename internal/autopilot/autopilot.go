@@ -0,0 +1,598 @@
+// Package autopilot is the execution loop (docs/07-execution.md): it selects ready
+// tasks by priority, fills a fixed number of agent slots with Echo agents working
+// in their own worktrees, verifies and integrates the tasks they finish, and
+// repeats until no ready task remains. In semi-auto mode the same loop waits for
+// a human's approval at each checkpoint (docs/02-modes.md).
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/escalation"
+	"github.com/deligoez/axiom/internal/integration"
+	"github.com/deligoez/axiom/internal/models"
+	"github.com/deligoez/axiom/internal/permission"
+	"github.com/deligoez/axiom/internal/persona"
+	"github.com/deligoez/axiom/internal/registry"
+	"github.com/deligoez/axiom/internal/supervisor"
+	"github.com/deligoez/axiom/internal/usage"
+	"github.com/deligoez/axiom/internal/verify"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// DefaultMaxParallel is the number of agent slots when Options.MaxParallel is unset.
+const DefaultMaxParallel = 3
+
+// pollInterval is how often the loop looks for ready tasks when nothing wakes it,
+// e.g. after a human edits the cases file.
+const pollInterval = 5 * time.Second
+
+var (
+	// ErrRunning is returned by Run while another run is in progress.
+	ErrRunning = errors.New("autopilot already running")
+	// ErrNotRunning is returned by Pause and Resume without a run in progress.
+	ErrNotRunning = errors.New("autopilot not running")
+
+	// errStopped marks a task whose agent was stopped by the user; it is released
+	// and not picked again in the same run.
+	errStopped = errors.New("agent stopped")
+)
+
+// State is where the execution loop is.
+type State string
+
+const (
+	StateIdle      State = "idle"
+	StateRunning   State = "running"
+	StatePaused    State = "paused"
+	StateCompleted State = "completed"
+)
+
+// Options wires the autopilot to the session's services. Workspaces and CaseFile
+// are required; the rest are optional.
+type Options struct {
+	CaseFile   string
+	ProjectDir string
+	// AxiomDir holds run logs and the session usage file; without it neither is written.
+	AxiomDir string
+
+	// Mode is the mode to start in; SetMode switches it. Default semi-auto.
+	Mode Mode
+	// MaxParallel is the number of agent slots. Default 3.
+	MaxParallel int
+
+	Workspaces *workspace.Manager
+	// MinFreeMB pauses autopilot instead of spawning an agent when disk space runs low.
+	MinFreeMB int
+
+	// Queue integrates verified task branches; without it tasks are only marked done.
+	Queue *integration.Queue
+	// Inbox receives PENDING signals; its pause also holds back new agents.
+	Inbox *escalation.Inbox
+	// AnswerPending answers PENDING questions in autopilot mode when no human is
+	// around, e.g. in a non-interactive run. An empty answer escalates the question.
+	AnswerPending func(taskID, question string) string
+
+	Personas *persona.Loader
+	Registry *registry.Registry
+	Ledger   *usage.Ledger
+	Broker   *permission.Broker
+
+	Models       models.Policy
+	DefaultModel string
+	Timeouts     agent.Timeouts
+	Supervisor   supervisor.Config
+	Verification verify.Config
+
+	// OnEvent receives progress events. It is called synchronously, at times with
+	// the autopilot's lock held, so it must return quickly and not call the Autopilot.
+	OnEvent func(Event)
+}
+
+// Slot is an agent slot working on a task.
+type Slot struct {
+	TaskID    string    `json:"taskId"`
+	AgentID   string    `json:"agentId,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Iteration int       `json:"iteration"`
+	StartedAt time.Time `json:"startedAt"`
+	// Waiting reports an agent holding its slot at an iteration end while autopilot is paused.
+	Waiting bool `json:"waiting,omitempty"`
+	// Approval is the ID of the approval the task waits for in semi-auto mode.
+	Approval string `json:"approval,omitempty"`
+}
+
+// Stats counts what a run has done so far.
+type Stats struct {
+	StartedAt time.Time `json:"startedAt,omitzero"`
+	Completed int       `json:"completed"`
+	// Failed counts failed and timed-out tasks.
+	Failed     int     `json:"failed"`
+	Blocked    int     `json:"blocked"`
+	Iterations int     `json:"iterations"`
+	CostUSD    float64 `json:"costUSD"`
+}
+
+// Status is a snapshot of the autopilot for the UI.
+type Status struct {
+	State State `json:"state"`
+	Mode  Mode  `json:"mode"`
+	// Reason says why autopilot paused.
+	Reason      string     `json:"reason,omitempty"`
+	MaxParallel int        `json:"maxParallel"`
+	Slots       []Slot     `json:"slots"`
+	Approvals   []Approval `json:"approvals"`
+	Stats       Stats      `json:"stats"`
+}
+
+// executor is an agent the loop runs through a supervisor. *agent.AgentClient implements it.
+type executor interface {
+	supervisor.Executor
+	// PID returns the process ID of the agent's CLI child, or 0 if none is running.
+	PID() int
+	Close() error
+}
+
+// Autopilot runs the execution loop. Its methods are safe for concurrent use.
+type Autopilot struct {
+	cfg   Config
+	opts  Options
+	store *casestore.CaseStore
+	poll  time.Duration
+
+	newExecutor func(config *agent.AgentConfig) (executor, error)
+
+	// caseMu serializes case file updates from concurrent tasks, and wsMu worktree
+	// creation, since git locks the repository config while adding a worktree.
+	caseMu sync.Mutex
+	wsMu   sync.Mutex
+
+	mu     sync.Mutex
+	state  State
+	mode   Mode
+	reason string
+	slots  map[string]*Slot
+	// skipped holds tasks the user stopped or rejected; the run does not pick them again.
+	skipped     map[string]bool
+	integrating int
+	stats       Stats
+	errors      int
+	// iterBase and costBase are where the limits count from since the run started or resumed.
+	iterBase  int
+	costBase  float64
+	startCost float64
+	resumed   chan struct{}
+	nextAgent int
+
+	approvals    map[string]*approval
+	nextApproval int
+
+	wake chan struct{}
+}
+
+// New returns an idle autopilot. It registers with opts.Inbox to resume tasks
+// whose PENDING escalation a human answered.
+func New(cfg Config, opts Options) (*Autopilot, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Workspaces == nil || opts.CaseFile == "" {
+		return nil, fmt.Errorf("autopilot needs a cases file and a workspace manager")
+	}
+	if opts.Mode == "" {
+		opts.Mode = ModeSemiAuto
+	}
+	if !opts.Mode.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMode, opts.Mode)
+	}
+	a := &Autopilot{
+		cfg:         cfg,
+		opts:        opts,
+		store:       casestore.NewCaseStore(),
+		poll:        pollInterval,
+		newExecutor: newAgent,
+		state:       StateIdle,
+		mode:        opts.Mode,
+		slots:       make(map[string]*Slot),
+		skipped:     make(map[string]bool),
+		approvals:   make(map[string]*approval),
+		wake:        make(chan struct{}, 1),
+	}
+	if opts.Inbox != nil {
+		opts.Inbox.OnClose(a.answered)
+	}
+	return a, nil
+}
+
+// newAgent starts an agent client for config.
+func newAgent(config *agent.AgentConfig) (executor, error) {
+	client, err := agent.NewAgentClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Run executes ready tasks until none remain and no agent or integration is still
+// working, then returns what it did. A paused run waits for Resume. Cancelling
+// ctx stops the agents and releases their tasks to pending. Tasks left active by
+// an earlier session are released to pending before the first pass.
+func (a *Autopilot) Run(ctx context.Context) (Stats, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := a.begin(); err != nil {
+		return Stats{}, err
+	}
+	if err := a.recoverActive(); err != nil {
+		return a.end(StateIdle), err
+	}
+
+	ticker := time.NewTicker(a.poll)
+	defer ticker.Stop()
+	for {
+		waiting, err := a.fill(ctx)
+		if err != nil {
+			if a.idle() {
+				return a.end(StateIdle), err
+			}
+			log.Printf("[WARN] autopilot: %v", err)
+		}
+		if a.finished(ctx, waiting) {
+			break
+		}
+		// Once cancelled, only finishing tasks wake the loop.
+		done := ctx.Done()
+		if ctx.Err() != nil {
+			done = nil
+		}
+		select {
+		case <-a.wake:
+		case <-ticker.C:
+		case <-done:
+		}
+	}
+	if ctx.Err() != nil {
+		return a.end(StateIdle), ctx.Err()
+	}
+	return a.end(StateCompleted), nil
+}
+
+// begin starts a run, resetting its stats and limits.
+func (a *Autopilot) begin() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.state == StateRunning || a.state == StatePaused {
+		return ErrRunning
+	}
+	a.state, a.reason = StateRunning, ""
+	a.skipped = make(map[string]bool)
+	a.errors = 0
+	a.startCost = a.sessionCost()
+	a.stats = Stats{StartedAt: time.Now().UTC()}
+	a.iterBase, a.costBase = 0, 0
+	return nil
+}
+
+// end finishes the run in state and returns its stats.
+func (a *Autopilot) end(state State) Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.resumed != nil {
+		close(a.resumed)
+		a.resumed = nil
+	}
+	a.stats.CostUSD = a.sessionCost() - a.startCost
+	a.state, a.reason = state, ""
+	return a.stats
+}
+
+// recoverActive releases tasks left active by a crashed session back to pending
+// (docs/07-execution.md, "Crash"). Their workspaces are kept and reused.
+func (a *Autopilot) recoverActive() error {
+	cases, err := a.store.Load(a.opts.CaseFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load cases: %w", err)
+	}
+	for _, c := range cases {
+		if c.Type != casestore.CaseTypeTask || c.Status != casestore.StatusActive {
+			continue
+		}
+		log.Printf("[autopilot] %s was left active by an earlier session; releasing it", c.ID)
+		if err := a.setStatus(c.ID, casestore.StatusPending); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fill starts agents on the highest scoring ready tasks while slots are free. It
+// reports whether any ready task is running or waiting for a slot.
+func (a *Autopilot) fill(ctx context.Context) (bool, error) {
+	if ctx.Err() != nil {
+		return false, nil
+	}
+	cases, err := a.store.Load(a.opts.CaseFile)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("load cases: %w", err)
+	}
+	ready := a.integrated(casestore.Ready(cases))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	exclude := make(map[string]bool, len(a.slots)+len(a.skipped))
+	for id := range a.slots {
+		exclude[id] = true
+	}
+	for id := range a.skipped {
+		exclude[id] = true
+	}
+	waiting := false
+	for _, c := range ready {
+		waiting = waiting || !exclude[c.ID]
+	}
+
+	for len(a.slots) < a.maxParallel() && a.canSpawnLocked() {
+		c, ok := Select(ready, cases, exclude)
+		if !ok {
+			break
+		}
+		exclude[c.ID] = true
+		sl := &Slot{TaskID: c.ID, StartedAt: time.Now().UTC()}
+		a.slots[c.ID] = sl
+		go func() {
+			status, err := a.runTask(ctx, c, sl)
+			a.done(c.ID, status, err)
+		}()
+	}
+	return waiting, nil
+}
+
+// integrated drops ready tasks whose dependencies are done but not yet merged by
+// the integration queue, so their worktrees start from a base with that work.
+func (a *Autopilot) integrated(ready []casestore.Case) []casestore.Case {
+	if a.opts.Queue == nil {
+		return ready
+	}
+	var kept []casestore.Case
+	for _, c := range ready {
+		if !a.unmerged(c.DependsOn) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// unmerged reports whether any of ids is in the integration queue but not merged yet.
+func (a *Autopilot) unmerged(ids []string) bool {
+	for _, id := range ids {
+		if e, err := a.opts.Queue.Get(id); err == nil && e.State != integration.StateMerged {
+			return true
+		}
+	}
+	return false
+}
+
+// canSpawnLocked reports whether a new agent may start. Low disk space pauses the run.
+func (a *Autopilot) canSpawnLocked() bool {
+	if a.state != StateRunning {
+		return false
+	}
+	if a.opts.Inbox != nil && a.opts.Inbox.Paused() {
+		return false
+	}
+	if err := workspace.CheckFree(a.opts.Workspaces.Root(), a.opts.MinFreeMB); err != nil {
+		a.pauseLocked(err.Error())
+		return false
+	}
+	return true
+}
+
+// finished reports whether the run is over: nothing is running or integrating, and
+// either ctx is done or no ready task is waiting while autopilot runs.
+func (a *Autopilot) finished(ctx context.Context, waiting bool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.slots) > 0 || a.integrating > 0 {
+		return false
+	}
+	if ctx.Err() != nil {
+		return true
+	}
+	if a.state == StatePaused || (a.opts.Inbox != nil && a.opts.Inbox.Paused()) {
+		return false
+	}
+	return !waiting
+}
+
+// idle reports whether no agent or integration is working.
+func (a *Autopilot) idle() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.slots) == 0 && a.integrating == 0
+}
+
+// done frees the task's slot and counts its outcome. Too many failures in a row pause the run.
+func (a *Autopilot) done(taskID string, status casestore.Status, err error) {
+	defer a.signal()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.slots, taskID)
+
+	switch status {
+	case casestore.StatusDone:
+		a.stats.Completed++
+		a.errors = 0
+	case casestore.StatusFailed, casestore.StatusTimeout:
+		a.stats.Failed++
+		a.errors++
+		if a.errors >= a.cfg.maxConsecutiveErrors() {
+			a.pauseLocked(fmt.Sprintf("%d tasks failed in a row; last: %s: %v", a.errors, taskID, err))
+		}
+	case casestore.StatusBlocked:
+		a.stats.Blocked++
+	case casestore.StatusPending:
+		if errors.Is(err, errStopped) || errors.Is(err, errRejected) {
+			a.skipped[taskID] = true
+		}
+	}
+	if err != nil {
+		log.Printf("[autopilot] %s %s: %v", taskID, status, err)
+		a.emit(Event{Type: EventTaskFinished, TaskID: taskID, Status: status, Reason: err.Error()})
+	} else {
+		log.Printf("[autopilot] %s %s", taskID, status)
+		a.emit(Event{Type: EventTaskFinished, TaskID: taskID, Status: status})
+	}
+}
+
+// Pause stops new agents from starting; running agents stop at the end of their
+// current iteration and keep their slots until Resume.
+func (a *Autopilot) Pause(reason string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch a.state {
+	case StateRunning:
+		a.pauseLocked(reason)
+		return nil
+	case StatePaused:
+		return nil
+	}
+	return ErrNotRunning
+}
+
+// pauseLocked pauses a running loop.
+func (a *Autopilot) pauseLocked(reason string) {
+	if a.state != StateRunning {
+		return
+	}
+	a.state, a.reason = StatePaused, reason
+	a.resumed = make(chan struct{})
+	log.Printf("[autopilot] paused: %s", reason)
+	a.emit(Event{Type: EventPaused, Reason: reason})
+}
+
+// Resume continues a paused run. The iteration and cost limits and the
+// consecutive error count start over.
+func (a *Autopilot) Resume() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch a.state {
+	case StatePaused:
+	case StateRunning:
+		return nil
+	default:
+		return ErrNotRunning
+	}
+	a.state, a.reason = StateRunning, ""
+	close(a.resumed)
+	a.resumed = nil
+	a.errors = 0
+	a.iterBase = a.stats.Iterations
+	a.costBase = a.sessionCost() - a.startCost
+	a.emit(Event{Type: EventResumed})
+	a.signal()
+	return nil
+}
+
+// Status returns the loop's state and mode, its slots and approvals, and what the
+// current or last run did.
+func (a *Autopilot) Status() Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st := Status{
+		State:       a.state,
+		Mode:        a.mode,
+		Reason:      a.reason,
+		MaxParallel: a.maxParallel(),
+		Slots:       []Slot{},
+		Approvals:   a.approvalsLocked(),
+		Stats:       a.statsLocked(),
+	}
+	for _, sl := range a.slots {
+		st.Slots = append(st.Slots, *sl)
+	}
+	slices.SortFunc(st.Slots, func(x, y Slot) int { return x.StartedAt.Compare(y.StartedAt) })
+	return st
+}
+
+// statsLocked returns the run's stats with its current cost.
+func (a *Autopilot) statsLocked() Stats {
+	st := a.stats
+	if a.state == StateRunning || a.state == StatePaused {
+		st.CostUSD = a.sessionCost() - a.startCost
+	}
+	return st
+}
+
+// sessionCost returns the ledger's session cost, or 0 without a ledger.
+func (a *Autopilot) sessionCost() float64 {
+	if a.opts.Ledger == nil {
+		return 0
+	}
+	return a.opts.Ledger.Session().CostUSD
+}
+
+// maxParallel returns the configured slot count or its default.
+func (a *Autopilot) maxParallel() int {
+	if a.opts.MaxParallel > 0 {
+		return a.opts.MaxParallel
+	}
+	return DefaultMaxParallel
+}
+
+// checkLimits pauses the run once the iteration or cost limit is reached.
+func (a *Autopilot) checkLimits() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n := a.stats.Iterations - a.iterBase; a.cfg.MaxIterations > 0 && n >= a.cfg.MaxIterations {
+		a.pauseLocked(fmt.Sprintf("iteration limit reached: %d iterations", n))
+	}
+	if cost := a.sessionCost() - a.startCost - a.costBase; a.cfg.MaxCostUSD > 0 && cost >= a.cfg.MaxCostUSD {
+		a.pauseLocked(fmt.Sprintf("cost limit reached: $%.2f of $%.2f", cost, a.cfg.MaxCostUSD))
+	}
+}
+
+// checkpoint holds a task at an iteration end while autopilot is paused. It
+// returns ctx's cause when the task is cancelled meanwhile.
+func (a *Autopilot) checkpoint(ctx context.Context, sl *Slot) error {
+	a.checkLimits()
+	for {
+		a.mu.Lock()
+		resumed := a.resumed
+		sl.Waiting = a.state == StatePaused
+		a.mu.Unlock()
+		if resumed == nil || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+		}
+	}
+	a.mu.Lock()
+	sl.Waiting = false
+	a.mu.Unlock()
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return nil
+}
+
+// signal wakes the loop without blocking.
+func (a *Autopilot) signal() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,455 @@
+package autopilot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/escalation"
+	"github.com/deligoez/axiom/internal/registry"
+	"github.com/deligoez/axiom/internal/signal"
+	"github.com/deligoez/axiom/internal/verify"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// turn is what the fake agent does for one Execute call.
+type turn struct {
+	// do runs in the agent's work directory before it answers, e.g. to write files.
+	do      func(dir string)
+	signals []signal.Signal
+}
+
+// fakePID is the CLI process ID every fake agent reports.
+const fakePID = 4242
+
+// fakeAgent replays turns, one per Execute call; without turns left it answers
+// without a signal.
+type fakeAgent struct {
+	config  *agent.AgentConfig
+	machine *agent.Machine
+	turns   []turn
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (f *fakeAgent) Execute(ctx context.Context, prompt string) (<-chan agent.AgentMessage, <-chan error) {
+	f.mu.Lock()
+	n := len(f.prompts)
+	f.prompts = append(f.prompts, prompt)
+	f.mu.Unlock()
+
+	msgs := make(chan agent.AgentMessage)
+	errs := make(chan error, 1)
+	if f.machine.State() == agent.StateIdle {
+		_ = f.machine.Transition(agent.StateStarting, "query sent")
+	}
+	go func() {
+		defer close(msgs)
+		defer close(errs)
+		var tn turn
+		if n < len(f.turns) {
+			tn = f.turns[n]
+		}
+		if tn.do != nil {
+			tn.do(f.config.WorkDir)
+		}
+		if f.machine.State() == agent.StateStarting {
+			_ = f.machine.Transition(agent.StateRunning, "first message")
+		}
+		select {
+		case msgs <- agent.AgentMessage{Text: "working", Signals: tn.signals}:
+		case <-ctx.Done():
+			errs <- context.Cause(ctx)
+		}
+	}()
+	return msgs, errs
+}
+
+func (f *fakeAgent) Interrupt(context.Context) error { return errors.New("no session") }
+func (f *fakeAgent) Reset() error                    { return nil }
+func (f *fakeAgent) Machine() *agent.Machine         { return f.machine }
+func (f *fakeAgent) ToolCalls() []agent.ToolCall     { return nil }
+func (f *fakeAgent) PID() int                        { return fakePID }
+func (f *fakeAgent) Close() error                    { return nil }
+
+// fakeAgents hands out fake agents scripted per task.
+type fakeAgents struct {
+	mu     sync.Mutex
+	turns  map[string][]turn
+	agents map[string]*fakeAgent
+	err    error
+}
+
+func (f *fakeAgents) new(config *agent.AgentConfig) (executor, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	a := &fakeAgent{config: config, machine: agent.NewMachine(config.AgentID), turns: f.turns[config.TaskID]}
+	if f.agents == nil {
+		f.agents = make(map[string]*fakeAgent)
+	}
+	f.agents[config.TaskID] = a
+	return a, nil
+}
+
+func (f *fakeAgents) prompts(taskID string) []string {
+	f.mu.Lock()
+	a := f.agents[taskID]
+	f.mu.Unlock()
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.prompts...)
+}
+
+func complete() []signal.Signal { return []signal.Signal{{Type: signal.Complete}} }
+
+// setup creates a git repository with cases and an autopilot running fake agents on it.
+func setup(t *testing.T, cfg Config, cases []casestore.Case, agents *fakeAgents, opts Options) (*Autopilot, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"commit", "-q", "--allow-empty", "-m", "initial"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	var lines []string
+	for _, c := range cases {
+		data, err := json.Marshal(c)
+		if err != nil {
+			t.Fatalf("marshal case: %v", err)
+		}
+		lines = append(lines, string(data))
+	}
+	caseFile := filepath.Join(dir, "cases.jsonl")
+	if err := os.WriteFile(caseFile, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("write cases: %v", err)
+	}
+
+	m, err := workspace.New(context.Background(), dir, workspace.Config{Base: "main"})
+	if err != nil {
+		t.Fatalf("workspaces: %v", err)
+	}
+	opts.CaseFile, opts.ProjectDir, opts.Workspaces = caseFile, dir, m
+	if opts.Mode == "" {
+		opts.Mode = ModeAutopilot // tests of semi-auto ask for it
+	}
+	a, err := New(cfg, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a.newExecutor = agents.new
+	a.poll = 10 * time.Millisecond
+	return a, caseFile
+}
+
+func loadCases(t *testing.T, caseFile string) map[string]casestore.Case {
+	t.Helper()
+	cases, err := casestore.NewCaseStore().Load(caseFile)
+	if err != nil {
+		t.Fatalf("load cases: %v", err)
+	}
+	byID := make(map[string]casestore.Case)
+	for _, c := range cases {
+		byID[c.ID] = c
+	}
+	return byID
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func task(id string, deps ...string) casestore.Case {
+	return casestore.Case{ID: id, Type: casestore.CaseTypeTask, Status: casestore.StatusPending, Content: "do " + id, DependsOn: deps}
+}
+
+func TestRun_CompletesReadyTasks(t *testing.T) {
+	cases := []casestore.Case{task("task-1"), task("task-2", "task-1"), task("task-3")}
+	cases[2].Status = casestore.StatusActive // left behind by a crashed session
+	agents := &fakeAgents{turns: map[string][]turn{
+		"task-1": {{}, {signals: complete()}},
+		"task-2": {{signals: complete()}},
+		"task-3": {{signals: []signal.Signal{{Type: signal.Progress, Payload: "50"}, {Type: signal.Complete}}}},
+	}}
+	a, caseFile := setup(t, Config{}, cases, agents, Options{MaxParallel: 2})
+
+	stats, err := a.Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Completed != 3 || stats.Iterations != 4 {
+		t.Errorf("got %+v, want 3 tasks completed in 4 iterations", stats)
+	}
+	got := loadCases(t, caseFile)
+	for _, id := range []string{"task-1", "task-2", "task-3"} {
+		c := got[id]
+		if c.Status != casestore.StatusDone || c.Execution == nil || !c.Execution.VerificationPassed {
+			t.Errorf("%s: got status %s, execution %+v", id, c.Status, c.Execution)
+		}
+	}
+	if exec := got["task-1"].Execution; exec.Iterations != 2 || exec.Branch != workspace.Branch("task-1") || exec.CompletedAt.IsZero() {
+		t.Errorf("unexpected task-1 execution: %+v", exec)
+	}
+	if signals := got["task-3"].Execution.Signals; strings.Join(signals, ",") != "PROGRESS:50,COMPLETE" {
+		t.Errorf("got signals %v", signals)
+	}
+	if prompts := agents.prompts("task-1"); len(prompts) != 2 || !strings.Contains(prompts[1], continuePrompt) {
+		t.Errorf("expected a continue prompt after an iteration without a verdict, got %q", prompts)
+	}
+	if st := a.Status(); st.State != StateCompleted || len(st.Slots) != 0 {
+		t.Errorf("got status %+v", st)
+	}
+}
+
+func TestRun_RecordsAgentPID(t *testing.T) {
+	counters := registry.NewCounters(filepath.Join(t.TempDir(), "counters.json"))
+	if err := counters.Init(); err != nil {
+		t.Fatal(err)
+	}
+	agents := registry.New(counters)
+	var listed []registry.Info
+	fakes := &fakeAgents{turns: map[string][]turn{
+		"task-1": {{}, {do: func(string) { listed = agents.List() }, signals: complete()}},
+	}}
+	a, _ := setup(t, Config{}, []casestore.Case{task("task-1")}, fakes, Options{Registry: agents})
+
+	if _, err := a.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(listed) != 1 || listed[0].PID != fakePID {
+		t.Errorf("got agents %+v while task-1 ran, want its PID %d", listed, fakePID)
+	}
+	if left := agents.List(); len(left) != 0 {
+		t.Errorf("got agents %+v after the run, want none", left)
+	}
+}
+
+func TestRun_VerificationFailureContinues(t *testing.T) {
+	agents := &fakeAgents{turns: map[string][]turn{
+		"task-1": {
+			{signals: complete()},
+			{do: func(dir string) { _ = os.WriteFile(filepath.Join(dir, "done.txt"), nil, 0o644) }, signals: complete()},
+		},
+	}}
+	verification := verify.Config{Commands: []verify.Command{{Name: "done", Command: "test -f done.txt"}}}
+	var events []string
+	onEvent := func(e Event) { events = append(events, strings.TrimSpace(string(e.Type)+" "+e.Reason)) }
+	a, caseFile := setup(t, Config{}, []casestore.Case{task("task-1")}, agents, Options{Verification: verification, OnEvent: onEvent})
+
+	stats, err := a.Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Completed != 1 || stats.Iterations != 2 {
+		t.Errorf("got %+v, want 1 task completed in 2 iterations", stats)
+	}
+	if c := loadCases(t, caseFile)["task-1"]; c.Status != casestore.StatusDone {
+		t.Errorf("got status %s, want done", c.Status)
+	}
+	if prompts := agents.prompts("task-1"); len(prompts) != 2 || !strings.Contains(prompts[1], "## Verification Failed") {
+		t.Errorf("expected verification feedback in the second prompt, got %q", prompts)
+	}
+	want := []string{"task_started", "iteration", "verified failed: done", "iteration", "verified passed", "task_finished"}
+	if strings.Join(events, "|") != strings.Join(want, "|") {
+		t.Errorf("got events %q, want %q", events, want)
+	}
+}
+
+func TestRun_BlockedAndPending(t *testing.T) {
+	dir := t.TempDir()
+	inbox, err := escalation.Open(filepath.Join(dir, "escalations.json"), dir, dir, escalation.Config{})
+	if err != nil {
+		t.Fatalf("open inbox: %v", err)
+	}
+	agents := &fakeAgents{turns: map[string][]turn{
+		"task-1": {{signals: []signal.Signal{{Type: signal.Blocked, Payload: "missing API key"}}}},
+		"task-2": {{signals: []signal.Signal{{Type: signal.Pending, Payload: "use Postgres or SQLite?"}}}},
+	}}
+	a, caseFile := setup(t, Config{}, []casestore.Case{task("task-1"), task("task-2")}, agents, Options{Inbox: inbox})
+
+	stats, err := a.Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Blocked != 2 {
+		t.Errorf("got %+v, want 2 blocked", stats)
+	}
+	got := loadCases(t, caseFile)
+	if c := got["task-1"]; c.Status != casestore.StatusBlocked || c.Execution.LastError != "missing API key" {
+		t.Errorf("task-1: got status %s, execution %+v", c.Status, c.Execution)
+	}
+	pending := inbox.Pending()
+	if len(pending) != 1 || pending[0].TaskID != "task-2" || pending[0].Kind != escalation.KindPending || pending[0].Reason != "use Postgres or SQLite?" {
+		t.Fatalf("unexpected escalations: %+v", pending)
+	}
+
+	if _, err := inbox.Answer(pending[0].ID, escalation.Respond, "SQLite"); err != nil {
+		t.Fatalf("answer: %v", err)
+	}
+
+	c := loadCases(t, caseFile)["task-2"]
+	if c.Status != casestore.StatusPending || c.Execution.Decision != "SQLite" {
+		t.Errorf("task-2: got status %s, execution %+v", c.Status, c.Execution)
+	}
+}
+
+func TestRun_AnswerPendingContinuesWithoutEscalating(t *testing.T) {
+	dir := t.TempDir()
+	inbox, err := escalation.Open(filepath.Join(dir, "escalations.json"), dir, dir, escalation.Config{})
+	if err != nil {
+		t.Fatalf("open inbox: %v", err)
+	}
+	agents := &fakeAgents{turns: map[string][]turn{
+		"task-1": {{signals: []signal.Signal{{Type: signal.Pending, Payload: "use Postgres or SQLite?"}}}, {signals: complete()}},
+	}}
+	answer := func(taskID, question string) string { return "Use your best judgement." }
+	a, caseFile := setup(t, Config{}, []casestore.Case{task("task-1")}, agents, Options{Inbox: inbox, AnswerPending: answer})
+
+	stats, err := a.Run(context.Background())
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Completed != 1 || len(inbox.Pending()) != 0 {
+		t.Errorf("got %+v and escalations %+v, want the task completed without escalating", stats, inbox.Pending())
+	}
+	if c := loadCases(t, caseFile)["task-1"]; c.Status != casestore.StatusDone {
+		t.Errorf("got status %s, want done", c.Status)
+	}
+	if prompts := agents.prompts("task-1"); len(prompts) != 2 || !strings.Contains(prompts[1], "Use your best judgement.") {
+		t.Errorf("expected the answer in the second prompt, got %q", prompts)
+	}
+}
+
+func TestRun_IterationLimitPausesUntilResumed(t *testing.T) {
+	agents := &fakeAgents{turns: map[string][]turn{"task-1": {{}, {}, {signals: complete()}}}}
+	a, caseFile := setup(t, Config{MaxIterations: 2}, []casestore.Case{task("task-1")}, agents, Options{})
+
+	type result struct {
+		stats Stats
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		stats, err := a.Run(context.Background())
+		done <- result{stats, err}
+	}()
+
+	waitFor(t, "the pause", func() bool {
+		st := a.Status()
+		return st.State == StatePaused && len(st.Slots) == 1 && st.Slots[0].Waiting
+	})
+	if st := a.Status(); !strings.Contains(st.Reason, "iteration limit") || st.Stats.Iterations != 2 {
+		t.Errorf("got status %+v", st)
+	}
+	if c := loadCases(t, caseFile)["task-1"]; c.Status != casestore.StatusActive {
+		t.Errorf("got status %s, want the paused task to stay active", c.Status)
+	}
+
+	if err := a.Resume(); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+
+	res := <-done
+	if res.err != nil || res.stats.Completed != 1 || res.stats.Iterations != 3 {
+		t.Errorf("got %+v, %v", res.stats, res.err)
+	}
+}
+
+func TestRun_ConsecutiveErrorsPause(t *testing.T) {
+	agents := &fakeAgents{err: errors.New("claude CLI not found")}
+	cases := []casestore.Case{task("task-1"), task("task-2"), task("task-3"), task("task-4")}
+	a, caseFile := setup(t, Config{MaxConsecutiveErrors: 2}, cases, agents, Options{MaxParallel: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.Run(ctx)
+		done <- err
+	}()
+
+	waitFor(t, "the pause", func() bool { return a.Status().State == StatePaused })
+	if st := a.Status(); st.Stats.Failed != 2 || !strings.Contains(st.Reason, "2 tasks failed in a row") {
+		t.Errorf("got status %+v", st)
+	}
+	got := loadCases(t, caseFile)
+	if got["task-1"].Status != casestore.StatusFailed || got["task-3"].Status != casestore.StatusPending {
+		t.Errorf("got task-1 %s and task-3 %s, want failed and pending", got["task-1"].Status, got["task-3"].Status)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if st := a.Status(); st.State != StateIdle {
+		t.Errorf("got state %s, want idle", st.State)
+	}
+}
+
+func TestRun_RetryCountsEarlierAttempts(t *testing.T) {
+	c := task("task-1")
+	c.Execution = &casestore.Execution{StartedAt: time.Now().Add(-time.Hour), RetryCount: 1, LastError: "agent stuck"}
+	agents := &fakeAgents{turns: map[string][]turn{"task-1": {{signals: complete()}}}}
+	a, caseFile := setup(t, Config{}, []casestore.Case{c}, agents, Options{})
+
+	if _, err := a.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if exec := loadCases(t, caseFile)["task-1"].Execution; exec.RetryCount != 2 || exec.LastError != "" {
+		t.Errorf("got execution %+v, want retry 2 without an error", exec)
+	}
+	if prompts := agents.prompts("task-1"); len(prompts) != 1 || !strings.Contains(prompts[0], "agent stuck") {
+		t.Errorf("expected the earlier error in the prompt, got %q", prompts)
+	}
+}
+
+func TestPauseWithoutRun(t *testing.T) {
+	a, _ := setup(t, Config{}, nil, &fakeAgents{}, Options{})
+
+	if err := a.Pause("manual"); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("got %v, want ErrNotRunning", err)
+	}
+	if err := a.Resume(); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("got %v, want ErrNotRunning", err)
+	}
+}
@@ -0,0 +1,36 @@
+package autopilot
+
+import "fmt"
+
+// DefaultMaxConsecutiveErrors is how many tasks may fail in a row before autopilot pauses.
+const DefaultMaxConsecutiveErrors = 3
+
+// Config is the "autopilot" section of .axiom/config.json. The limits apply to one
+// autopilot run and restart when it is resumed after hitting them.
+type Config struct {
+	// MaxIterations caps the agent iterations across all tasks. 0 means no limit.
+	MaxIterations int `json:"maxIterations,omitempty"`
+
+	// MaxCostUSD caps the agent spend in US dollars. 0 means no limit.
+	MaxCostUSD float64 `json:"maxCostUSD,omitempty"`
+
+	// MaxConsecutiveErrors pauses autopilot after that many tasks fail or time
+	// out in a row. Default 3.
+	MaxConsecutiveErrors int `json:"maxConsecutiveErrors,omitempty"`
+}
+
+// Validate reports negative limits.
+func (c Config) Validate() error {
+	if c.MaxIterations < 0 || c.MaxCostUSD < 0 || c.MaxConsecutiveErrors < 0 {
+		return fmt.Errorf("autopilot limits must not be negative")
+	}
+	return nil
+}
+
+// maxConsecutiveErrors returns the configured threshold or its default.
+func (c Config) maxConsecutiveErrors() int {
+	if c.MaxConsecutiveErrors > 0 {
+		return c.MaxConsecutiveErrors
+	}
+	return DefaultMaxConsecutiveErrors
+}
@@ -0,0 +1,45 @@
+package autopilot
+
+import (
+	"time"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+// EventType names a progress event.
+type EventType string
+
+const (
+	// EventTaskStarted reports an agent starting on a task.
+	EventTaskStarted EventType = "task_started"
+	// EventIteration reports the start of an iteration.
+	EventIteration EventType = "iteration"
+	// EventVerified reports a verification run; Reason says whether it passed.
+	EventVerified EventType = "verified"
+	// EventTaskFinished reports the status a task was left in, with the error as Reason.
+	EventTaskFinished EventType = "task_finished"
+	// EventPaused and EventResumed report the run pausing and resuming.
+	EventPaused  EventType = "paused"
+	EventResumed EventType = "resumed"
+)
+
+// Event is a progress event passed to Options.OnEvent.
+type Event struct {
+	Time      time.Time        `json:"time"`
+	Type      EventType        `json:"event"`
+	TaskID    string           `json:"taskId,omitempty"`
+	AgentID   string           `json:"agentId,omitempty"`
+	Model     string           `json:"model,omitempty"`
+	Iteration int              `json:"iteration,omitempty"`
+	Status    casestore.Status `json:"status,omitempty"`
+	Reason    string           `json:"reason,omitempty"`
+}
+
+// emit passes e to Options.OnEvent, if set.
+func (a *Autopilot) emit(e Event) {
+	if a.opts.OnEvent == nil {
+		return
+	}
+	e.Time = time.Now().UTC()
+	a.opts.OnEvent(e)
+}
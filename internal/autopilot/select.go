@@ -0,0 +1,67 @@
+package autopilot
+
+import (
+	"slices"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+// Labels that raise a task's selection score.
+const (
+	LabelCritical = "critical"
+	LabelQuickWin = "quick-win"
+)
+
+// Score rates a ready task against all cases (docs/07-execution.md, "Task Selection
+// Algorithm"): +10 per unfinished task waiting on it, +50 for the critical label,
+// +30 for quick-win, +20 when more than half of its parent's children are done,
+// and -15 per retry.
+func Score(c casestore.Case, cases []casestore.Case) int {
+	score := 0
+	siblings, done := 0, 0
+	for _, other := range cases {
+		if other.ID == c.ID {
+			continue
+		}
+		if other.Status != casestore.StatusDone && slices.Contains(other.DependsOn, c.ID) {
+			score += 10
+		}
+		if c.ParentID != "" && other.ParentID == c.ParentID {
+			siblings++
+			if other.Status == casestore.StatusDone {
+				done++
+			}
+		}
+	}
+
+	if slices.Contains(c.Labels, LabelCritical) {
+		score += 50
+	}
+	if slices.Contains(c.Labels, LabelQuickWin) {
+		score += 30
+	}
+	// The task itself is one of the parent's children, and not done yet.
+	if c.ParentID != "" && 2*done > siblings+1 {
+		score += 20
+	}
+	if c.Execution != nil {
+		score -= c.Execution.RetryCount * 15
+	}
+	return score
+}
+
+// Select returns the highest scoring ready task not in exclude. Ties go to the
+// task listed first. It returns false when every ready task is excluded.
+func Select(ready, cases []casestore.Case, exclude map[string]bool) (casestore.Case, bool) {
+	var best casestore.Case
+	bestScore, found := 0, false
+	for _, c := range ready {
+		if exclude[c.ID] {
+			continue
+		}
+		if s := Score(c, cases); !found || s > bestScore {
+			best, bestScore, found = c, s, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,62 @@
+package autopilot
+
+import (
+	"testing"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+func TestScore(t *testing.T) {
+	cases := []casestore.Case{
+		{ID: "op-1", Type: casestore.CaseTypeOperation, Status: casestore.StatusActive},
+		{ID: "task-1", Type: casestore.CaseTypeTask, Status: casestore.StatusPending, ParentID: "op-1"},
+		{ID: "task-2", Type: casestore.CaseTypeTask, Status: casestore.StatusDone, ParentID: "op-1"},
+		{ID: "task-3", Type: casestore.CaseTypeTask, Status: casestore.StatusDone, ParentID: "op-1"},
+		{ID: "task-4", Type: casestore.CaseTypeTask, Status: casestore.StatusPending, DependsOn: []string{"task-1"}},
+		{ID: "task-5", Type: casestore.CaseTypeTask, Status: casestore.StatusPending, DependsOn: []string{"task-1", "task-6"}},
+		{ID: "task-6", Type: casestore.CaseTypeTask, Status: casestore.StatusPending, Labels: []string{LabelCritical, LabelQuickWin},
+			Execution: &casestore.Execution{RetryCount: 2}},
+	}
+
+	tests := []struct {
+		id   string
+		want int
+	}{
+		{"task-1", 10 + 10 + 20}, // unblocks task-4 and task-5, 2 of 3 siblings done
+		{"task-6", 10 + 50 + 30 - 2*15},
+		{"task-4", 0},
+	}
+	for _, tt := range tests {
+		var c casestore.Case
+		for _, candidate := range cases {
+			if candidate.ID == tt.id {
+				c = candidate
+			}
+		}
+		if got := Score(c, cases); got != tt.want {
+			t.Errorf("Score(%s) = %d, want %d", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	cases := []casestore.Case{
+		{ID: "task-1", Type: casestore.CaseTypeTask, Status: casestore.StatusPending},
+		{ID: "task-2", Type: casestore.CaseTypeTask, Status: casestore.StatusPending},
+		{ID: "task-3", Type: casestore.CaseTypeTask, Status: casestore.StatusPending, Labels: []string{LabelQuickWin}},
+	}
+
+	got, ok := Select(cases, cases, nil)
+	if !ok || got.ID != "task-3" {
+		t.Errorf("got %s, want the quick win task-3", got.ID)
+	}
+
+	got, ok = Select(cases, cases, map[string]bool{"task-3": true})
+	if !ok || got.ID != "task-1" {
+		t.Errorf("got %s, want task-1 first among equals", got.ID)
+	}
+
+	if _, ok := Select(cases, cases, map[string]bool{"task-1": true, "task-2": true, "task-3": true}); ok {
+		t.Error("expected no task when all are excluded")
+	}
+}
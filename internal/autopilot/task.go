@@ -0,0 +1,586 @@
+package autopilot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/escalation"
+	"github.com/deligoez/axiom/internal/models"
+	"github.com/deligoez/axiom/internal/persona"
+	"github.com/deligoez/axiom/internal/runlog"
+	"github.com/deligoez/axiom/internal/signal"
+	"github.com/deligoez/axiom/internal/supervisor"
+	"github.com/deligoez/axiom/internal/usage"
+	"github.com/deligoez/axiom/internal/verify"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// continuePrompt follows an iteration that ended without a verdict.
+const continuePrompt = "Continue working on the task. When it is implemented and the tests pass, " +
+	"emit <axiom>COMPLETE</axiom>. If you cannot proceed, emit <axiom>BLOCKED:reason</axiom>, " +
+	"or <axiom>PENDING:question</axiom> when a human has to decide."
+
+// approvedDecision is passed to an agent whose PENDING question a human approved.
+const approvedDecision = "Approved. Go ahead as you proposed."
+
+// Options offered on PENDING escalations.
+var pendingOptions = []string{
+	"Approve to let the agent go ahead as it proposes",
+	"Respond with the decision the agent should follow",
+	"Reject to leave the task blocked",
+}
+
+// runTask works on c until the agent completes, blocks or fails, and returns the
+// status it left the case in. In semi-auto mode it waits for approval before
+// starting, before merging and on BLOCKED and PENDING signals; waiting for a
+// human does not count against the task timeout.
+func (a *Autopilot) runTask(ctx context.Context, c casestore.Case, sl *Slot) (casestore.Status, error) {
+	start, err := a.await(ctx, sl, CheckpointStart, "start "+c.ID, c.Content)
+	if err != nil {
+		return casestore.StatusPending, nil
+	}
+	if start.decision == escalation.Reject {
+		return casestore.StatusPending, errRejected
+	}
+
+	var exec casestore.Execution
+	decision := ""
+	if c.Execution != nil {
+		exec.RetryCount, decision = c.Execution.RetryCount, c.Execution.Decision
+		// A task resumed with a human's answer carries on rather than starting over.
+		if !c.Execution.StartedAt.IsZero() && decision == "" {
+			exec.RetryCount++
+		}
+	}
+	exec.StartedAt = time.Now().UTC()
+
+	ws, err := a.workspace(ctx, c.ID)
+	if err != nil {
+		return a.finish(c.ID, &exec, casestore.StatusFailed, err)
+	}
+	exec.Workspace, exec.Branch = ws.Path, ws.Branch
+	if err := a.update(c.ID, casestore.StatusActive, &exec); err != nil {
+		return casestore.StatusFailed, err
+	}
+
+	taskCtx, cancel := a.opts.Timeouts.TaskContext(ctx)
+	defer cancel()
+	agentID, release, err := a.spawn(c.ID, ws.Path, cancel)
+	if err != nil {
+		return a.finish(c.ID, &exec, casestore.StatusFailed, err)
+	}
+	defer release()
+
+	choice := a.opts.Models.Select(a.opts.DefaultModel, models.Request{Persona: persona.Echo, Case: &c, Attempt: exec.RetryCount})
+	a.mu.Lock()
+	sl.AgentID, sl.Model = agentID, choice.Model
+	a.mu.Unlock()
+
+	client, err := a.client(&c, agentID, ws.Path, choice.Model)
+	if err != nil {
+		return a.finish(c.ID, &exec, casestore.StatusFailed, err)
+	}
+	defer func() { _ = client.Close() }()
+	machine := client.Machine()
+	if a.opts.Registry != nil {
+		_ = a.opts.Registry.Track(agentID, machine)
+	}
+	sup, err := supervisor.New(client, a.opts.Supervisor)
+	if err != nil {
+		return a.finish(c.ID, &exec, casestore.StatusFailed, err)
+	}
+	log.Printf("[autopilot] %s working on %s with %s (%s)", agentID, c.ID, choice.Model, choice.Reason)
+	a.emit(Event{Type: EventTaskStarted, TaskID: c.ID, AgentID: agentID, Model: choice.Model})
+
+	original := taskPrompt(c, ws, decision)
+	if start.message != "" {
+		original += humanNote("Notes from a human", start.message)
+	}
+	prompt := original
+	for {
+		if err := a.checkpoint(taskCtx, sl); err != nil {
+			return a.stop(ctx, c.ID, &exec, err)
+		}
+		exec.Iterations++
+		a.mu.Lock()
+		sl.Iteration = exec.Iterations
+		a.stats.Iterations++
+		a.mu.Unlock()
+		a.emit(Event{Type: EventIteration, TaskID: c.ID, AgentID: agentID, Iteration: exec.Iterations})
+
+		signals, err := a.iterate(taskCtx, sup, client, c.ID, agentID, choice, prompt, exec.Iterations)
+		for _, s := range signals {
+			exec.Signals = append(exec.Signals, formatSignal(s))
+		}
+		if err != nil {
+			return a.stop(ctx, c.ID, &exec, err)
+		}
+
+		verdict, ok := lastVerdict(signals)
+		switch {
+		case !ok:
+			prompt = original + "\n\n" + continuePrompt
+		case verdict.Type == signal.Complete:
+			report := a.verify(taskCtx, machine, c.ID, ws)
+			exec.VerificationPassed = report.Passed
+			if !report.Passed {
+				if taskCtx.Err() != nil {
+					return a.stop(ctx, c.ID, &exec, context.Cause(taskCtx))
+				}
+				prompt = verify.Prompt(original, report)
+				break
+			}
+			merge, err := a.review(ctx, sl, c.ID, &exec, report)
+			if err != nil {
+				return a.stop(ctx, c.ID, &exec, err)
+			}
+			switch merge.decision {
+			case escalation.Reject:
+				_ = machine.Transition(agent.StateDone, "merge rejected")
+				return a.finish(c.ID, &exec, casestore.StatusReview, rejection("merge", merge.message))
+			case escalation.Respond:
+				_ = machine.Transition(agent.StateRunning, "changes requested")
+				if err := a.update(c.ID, casestore.StatusActive, &exec); err != nil {
+					log.Printf("[WARN] %v", err)
+				}
+				prompt = original + humanNote("Review feedback", merge.message)
+			default:
+				_ = machine.Transition(agent.StateDone, "verified")
+				a.integrate(ctx, c, ws)
+				return a.finish(c.ID, &exec, casestore.StatusDone, nil)
+			}
+		case verdict.Type == signal.Blocked:
+			ans, err := a.await(ctx, sl, CheckpointBlocked, reason(verdict), c.Content)
+			if err != nil {
+				return a.stop(ctx, c.ID, &exec, err)
+			}
+			if ans.decision == escalation.Respond {
+				prompt = original + humanNote("Human answer", ans.message)
+				break
+			}
+			_ = machine.Transition(agent.StateDone, "blocked")
+			return a.finish(c.ID, &exec, casestore.StatusBlocked, errors.New(reason(verdict)))
+		default:
+			ans, err := a.await(ctx, sl, CheckpointPending, reason(verdict), c.Content)
+			if err != nil {
+				return a.stop(ctx, c.ID, &exec, err)
+			}
+			switch {
+			case ans.auto:
+				if answer := a.answerPending(c.ID, reason(verdict)); answer != "" {
+					prompt = original + humanNote("Human decision", answer)
+					break
+				}
+				_ = machine.Transition(agent.StateDone, "waiting for a human")
+				a.escalate(ctx, c, ws, reason(verdict))
+				return a.finish(c.ID, &exec, casestore.StatusBlocked, fmt.Errorf("waiting for a human: %s", reason(verdict)))
+			case ans.decision == escalation.Reject:
+				_ = machine.Transition(agent.StateDone, "rejected")
+				return a.finish(c.ID, &exec, casestore.StatusBlocked, rejection(reason(verdict), ans.message))
+			case ans.decision == escalation.Respond:
+				prompt = original + humanNote("Human decision", ans.message)
+			default:
+				prompt = original + humanNote("Human decision", approvedDecision)
+			}
+		}
+	}
+}
+
+// workspace creates the task's worktree, or reuses the one an earlier attempt left.
+func (a *Autopilot) workspace(ctx context.Context, taskID string) (*workspace.Workspace, error) {
+	a.wsMu.Lock()
+	defer a.wsMu.Unlock()
+	ws, err := a.opts.Workspaces.Create(ctx, taskID)
+	if !errors.Is(err, workspace.ErrExists) {
+		return ws, err
+	}
+	st, err := a.opts.Workspaces.Get(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if st.Missing {
+		return nil, fmt.Errorf("workspace of %s is registered but its directory is missing", taskID)
+	}
+	return &st.Workspace, nil
+}
+
+// spawn assigns the task's agent ID, registering it when a registry is set so the
+// user can stop it. The returned func unregisters it.
+func (a *Autopilot) spawn(taskID, dir string, cancel context.CancelFunc) (string, func(), error) {
+	if a.opts.Registry == nil {
+		a.mu.Lock()
+		a.nextAgent++
+		n := a.nextAgent
+		a.mu.Unlock()
+		return agent.FormatAgentID(persona.Echo, n), func() {}, nil
+	}
+	info, err := a.opts.Registry.Spawn(persona.Echo, taskID, dir, func() error {
+		cancel()
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return info.ID, func() { a.opts.Registry.Remove(info.ID) }, nil
+}
+
+// recordPID stores the agent's CLI process ID in the registry once it is known.
+func (a *Autopilot) recordPID(agentID string, client executor) {
+	if a.opts.Registry == nil {
+		return
+	}
+	if info, ok := a.opts.Registry.Get(agentID); !ok || info.PID != 0 {
+		return
+	}
+	if pid := client.PID(); pid != 0 {
+		_ = a.opts.Registry.SetPID(agentID, pid)
+	}
+}
+
+// client configures an Echo agent in dir and starts it.
+func (a *Autopilot) client(c *casestore.Case, agentID, dir, model string) (executor, error) {
+	config := &agent.AgentConfig{
+		Model:      model,
+		AgentID:    agentID,
+		TaskID:     c.ID,
+		WorkDir:    dir,
+		ProjectDir: a.opts.ProjectDir,
+	}
+	if q := a.opts.Timeouts.Query; q > 0 {
+		config.Timeout = q.String()
+	}
+	if a.opts.Personas != nil {
+		if err := a.opts.Personas.Configure(config, persona.Echo, persona.PromptData{WorkDir: dir, Case: c}); err != nil {
+			return nil, fmt.Errorf("configure echo: %w", err)
+		}
+	} else if err := agent.ApplyToolPolicy(config, persona.Echo); err != nil {
+		return nil, err
+	}
+	if a.opts.Broker != nil {
+		config.PermissionHandler = a.opts.Broker.Handler(agentID, dir)
+	}
+	return a.newExecutor(config)
+}
+
+// iterate runs one supervised iteration, metering its usage and writing the run
+// log, and returns the signals the agent emitted.
+func (a *Autopilot) iterate(ctx context.Context, sup *supervisor.Supervisor, client executor, taskID, agentID string, choice models.Choice, prompt string, n int) ([]signal.Signal, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var rl *runlog.Log
+	if a.opts.AxiomDir != "" {
+		rl = runlog.New(a.opts.AxiomDir, persona.Echo, taskID, agentID)
+	}
+	appendLog := func(e runlog.Event) {
+		if rl == nil {
+			return
+		}
+		if err := rl.Append(e); err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+	}
+	if n == 1 {
+		appendLog(runlog.Event{Event: runlog.EventStart, Model: choice.Model, Reason: choice.Reason})
+	}
+	appendLog(runlog.Event{Event: runlog.EventIteration, Number: n})
+
+	var meter *usage.Meter
+	if a.opts.Ledger != nil {
+		meter = a.opts.Ledger.Meter(persona.Echo, taskID, choice.Model, cancel)
+	}
+	var signals []signal.Signal
+	err := sup.Run(ctx, prompt, func(msg agent.AgentMessage) {
+		a.recordPID(agentID, client)
+		if meter != nil {
+			meter.Observe(msg.Raw)
+		}
+		for _, s := range msg.Signals {
+			signals = append(signals, s)
+			appendLog(runlog.Event{Event: runlog.EventSignal, Type: s.Type, Payload: s.Payload})
+		}
+	})
+
+	if meter != nil {
+		if budgetErr := meter.Err(); budgetErr != nil {
+			err = budgetErr
+		}
+		u := meter.Finish()
+		appendLog(runlog.Event{Event: runlog.EventUsage, Usage: &u})
+		if a.opts.AxiomDir != "" {
+			if saveErr := a.opts.Ledger.Save(filepath.Join(a.opts.AxiomDir, "metrics", "session.json")); saveErr != nil {
+				log.Printf("[WARN] %v", saveErr)
+			}
+		}
+	}
+	if err != nil {
+		appendLog(runlog.Event{Event: runlog.EventError, Error: err.Error()})
+	} else {
+		appendLog(runlog.Event{Event: runlog.EventComplete, Iterations: sup.Iterations()})
+	}
+	return signals, err
+}
+
+// verify runs the task's scoped verification after COMPLETE and records the report on the case.
+func (a *Autopilot) verify(ctx context.Context, machine *agent.Machine, taskID string, ws *workspace.Workspace) verify.Report {
+	_ = machine.Transition(agent.StateVerifying, "COMPLETE signalled")
+	report := verify.RunTask(ctx, a.opts.Verification, ws.Path, ws.BaseCommit, map[string]string{"AXIOM_TASK_ID": taskID})
+
+	a.caseMu.Lock()
+	err := verify.Record(a.opts.CaseFile, taskID, report)
+	a.caseMu.Unlock()
+	if err != nil {
+		log.Printf("[WARN] %v", err)
+	}
+	outcome := "passed"
+	if !report.Passed {
+		_ = machine.Transition(agent.StateRunning, "verification failed")
+		var failed []string
+		for _, r := range report.Failures() {
+			failed = append(failed, r.Name)
+		}
+		outcome = "failed: " + strings.Join(failed, ", ")
+	}
+	a.emit(Event{Type: EventVerified, TaskID: taskID, Reason: outcome})
+	return report
+}
+
+// review holds a verified task in review until a human approves its merge in
+// semi-auto mode. In autopilot mode the merge goes ahead.
+func (a *Autopilot) review(ctx context.Context, sl *Slot, taskID string, exec *casestore.Execution, report verify.Report) (answer, error) {
+	if a.Mode() == ModeSemiAuto {
+		if err := a.update(taskID, casestore.StatusReview, exec); err != nil {
+			log.Printf("[WARN] %v", err)
+		}
+	}
+	var checks []string
+	for _, r := range report.Results {
+		switch {
+		case r.Passed && !r.OutOfScope:
+			checks = append(checks, r.Name+" passed")
+		case r.OutOfScope:
+			checks = append(checks, r.Name+" out of scope")
+		}
+	}
+	detail := "No verification commands are configured."
+	if len(checks) > 0 {
+		detail = "Verification: " + strings.Join(checks, ", ") + "."
+	}
+	return a.await(ctx, sl, CheckpointMerge, "verification passed; merge "+taskID, detail)
+}
+
+// integrate queues the task's branch for merging and processes the queue in the
+// background. Dependencies integrated outside the queue are not waited for.
+func (a *Autopilot) integrate(ctx context.Context, c casestore.Case, ws *workspace.Workspace) {
+	q := a.opts.Queue
+	if q == nil {
+		return
+	}
+	var deps []string
+	for _, id := range c.DependsOn {
+		if _, err := q.Get(id); err == nil {
+			deps = append(deps, id)
+		}
+	}
+	if err := q.Enqueue(c.ID, ws.Branch, deps); err != nil {
+		log.Printf("[WARN] queue %s for integration: %v", c.ID, err)
+		return
+	}
+
+	a.mu.Lock()
+	a.integrating++
+	a.mu.Unlock()
+	go func() {
+		if err := q.Process(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[WARN] integration: %v", err)
+		}
+		a.mu.Lock()
+		a.integrating--
+		a.mu.Unlock()
+		a.signal()
+	}()
+}
+
+// answerPending asks Options.AnswerPending for an answer to the task's question.
+func (a *Autopilot) answerPending(taskID, question string) string {
+	if a.opts.AnswerPending == nil {
+		return ""
+	}
+	return strings.TrimSpace(a.opts.AnswerPending(taskID, question))
+}
+
+// escalate asks a human to answer the task's PENDING signal.
+func (a *Autopilot) escalate(ctx context.Context, c casestore.Case, ws *workspace.Workspace, question string) {
+	if a.opts.Inbox == nil {
+		return
+	}
+	_, err := a.opts.Inbox.Raise(ctx, escalation.Escalation{
+		TaskID:    c.ID,
+		Kind:      escalation.KindPending,
+		Reason:    question,
+		Context:   c.Content,
+		Options:   pendingOptions,
+		Workspace: ws.Path,
+	})
+	if err != nil {
+		log.Printf("[WARN] escalate %s: %v", c.ID, err)
+	}
+}
+
+// answered releases a task blocked on a PENDING signal once a human approves or
+// responds, or nobody answers and the timeout policy skips or retries the
+// question. The answer is passed to the agent when the task resumes.
+func (a *Autopilot) answered(e escalation.Escalation) {
+	if e.Kind != escalation.KindPending {
+		return
+	}
+	var decision string
+	switch {
+	case e.Status == escalation.StatusApproved:
+		decision = approvedDecision
+	case e.Status == escalation.StatusResponded:
+		decision = e.Response
+	case e.Status == escalation.StatusExpired && e.Action == escalation.ActionSkip:
+		decision = "Nobody answered in time. Use your best judgement and note the assumption in your commit."
+	case e.Status == escalation.StatusExpired && e.Action == escalation.ActionRetry:
+		decision = "Nobody answered in time. Try again; signal PENDING once more if you still need a decision."
+	default:
+		return
+	}
+
+	a.caseMu.Lock()
+	defer a.caseMu.Unlock()
+	cases, err := a.store.Load(a.opts.CaseFile)
+	if err != nil {
+		log.Printf("[WARN] escalation %s: %v", e.ID, err)
+		return
+	}
+	for _, c := range cases {
+		if c.ID != e.TaskID || c.Status != casestore.StatusBlocked {
+			continue
+		}
+		exec := casestore.Execution{}
+		if c.Execution != nil {
+			exec = *c.Execution
+		}
+		exec.Decision = decision
+		if err := a.updateLocked(c.ID, casestore.StatusPending, &exec); err != nil {
+			log.Printf("[WARN] escalation %s: %v", e.ID, err)
+		}
+		a.signal()
+		return
+	}
+}
+
+// stop ends the task after err: a cancelled task is released to pending, anything
+// else fails it or times it out (see supervisor.CaseStatus).
+func (a *Autopilot) stop(ctx context.Context, taskID string, exec *casestore.Execution, err error) (casestore.Status, error) {
+	status, ok := supervisor.CaseStatus(err)
+	if ok {
+		return a.finish(taskID, exec, status, err)
+	}
+	if ctx.Err() != nil {
+		return a.finish(taskID, exec, casestore.StatusPending, nil)
+	}
+	return a.finish(taskID, exec, casestore.StatusPending, fmt.Errorf("%w: %v", errStopped, err))
+}
+
+// finish records the attempt's outcome on the case and returns status and err.
+// Releasing a task to pending is not an error worth keeping on the case.
+func (a *Autopilot) finish(taskID string, exec *casestore.Execution, status casestore.Status, err error) (casestore.Status, error) {
+	exec.CompletedAt = time.Now().UTC()
+	if err != nil && status != casestore.StatusPending {
+		exec.LastError = err.Error()
+	}
+	if uerr := a.update(taskID, status, exec); uerr != nil {
+		log.Printf("[WARN] record %s: %v", taskID, uerr)
+	}
+	return status, err
+}
+
+// update writes the task's status and execution record.
+func (a *Autopilot) update(taskID string, status casestore.Status, exec *casestore.Execution) error {
+	a.caseMu.Lock()
+	defer a.caseMu.Unlock()
+	return a.updateLocked(taskID, status, exec)
+}
+
+// updateLocked is update with caseMu held.
+func (a *Autopilot) updateLocked(taskID string, status casestore.Status, exec *casestore.Execution) error {
+	if err := a.store.SetField(a.opts.CaseFile, taskID, "execution", exec); err != nil {
+		return fmt.Errorf("record execution of %s: %w", taskID, err)
+	}
+	if err := a.store.SetStatus(a.opts.CaseFile, taskID, status); err != nil {
+		return fmt.Errorf("set status of %s: %w", taskID, err)
+	}
+	return nil
+}
+
+// setStatus changes only the task's status.
+func (a *Autopilot) setStatus(taskID string, status casestore.Status) error {
+	a.caseMu.Lock()
+	defer a.caseMu.Unlock()
+	if err := a.store.SetStatus(a.opts.CaseFile, taskID, status); err != nil {
+		return fmt.Errorf("set status of %s: %w", taskID, err)
+	}
+	return nil
+}
+
+// rejection is the error recorded when a human rejects what, with their message if any.
+func rejection(what, message string) error {
+	if message == "" {
+		return fmt.Errorf("rejected: %s", what)
+	}
+	return fmt.Errorf("rejected: %s: %s", what, message)
+}
+
+// lastVerdict returns the last COMPLETE, BLOCKED or PENDING signal: an agent may
+// report a problem, then recover and finish.
+func lastVerdict(signals []signal.Signal) (signal.Signal, bool) {
+	for i := len(signals) - 1; i >= 0; i-- {
+		switch signals[i].Type {
+		case signal.Complete, signal.Blocked, signal.Pending:
+			return signals[i], true
+		}
+	}
+	return signal.Signal{}, false
+}
+
+// reason returns a signal's payload, or a placeholder when the agent gave none.
+func reason(s signal.Signal) string {
+	if p := strings.TrimSpace(s.Payload); p != "" {
+		return p
+	}
+	return "no reason given"
+}
+
+// formatSignal renders a signal as recorded on the case, e.g. "PROGRESS:50".
+func formatSignal(s signal.Signal) string {
+	if s.Payload == "" {
+		return s.Type
+	}
+	return s.Type + ":" + s.Payload
+}
+
+// taskPrompt is the first prompt of an attempt at c.
+func taskPrompt(c casestore.Case, ws *workspace.Workspace, decision string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Task %s\n\n%s\n", c.ID, c.Content)
+	fmt.Fprintf(&b, "\nYou are working in a git worktree on branch %s. Commit your work there.\n", ws.Branch)
+	if c.Execution != nil && c.Execution.LastError != "" && decision == "" {
+		fmt.Fprintf(&b, "\nAn earlier attempt at this task ended with: %s\nCheck the current state of the work before continuing.\n", c.Execution.LastError)
+	}
+	if decision != "" {
+		fmt.Fprintf(&b, "\n## Human decision\n\nYou asked for a decision earlier. The answer: %s\n", decision)
+	}
+	b.WriteString("\nWhen the task is implemented and the tests pass, emit <axiom>COMPLETE</axiom>. ")
+	b.WriteString("If you cannot proceed, emit <axiom>BLOCKED:reason</axiom>, or <axiom>PENDING:question</axiom> when a human has to decide.\n")
+	return b.String()
+}
@@ -25,6 +25,11 @@ const (
 	StatusActive  Status = "active"
 	StatusBlocked Status = "blocked"
 	StatusDone    Status = "done"
+
+	// Task-specific execution statuses.
+	StatusFailed  Status = "failed"
+	StatusTimeout Status = "timeout"
+	StatusReview  Status = "review"
 )
 
 // Case represents a work item in AXIOM.
@@ -33,5 +38,41 @@ type Case struct {
 	Type      CaseType  `json:"type"`
 	Status    Status    `json:"status"`
 	Content   string    `json:"content"`
+	Labels    []string  `json:"labels,omitempty"`
 	CreatedAt time.Time `json:"createdAt"`
+
+	// Model overrides the model chosen for agents working on the case.
+	Model string `json:"model,omitempty"`
+	// Complexity is the planner's estimate: "low", "medium" or "high".
+	Complexity string `json:"complexity,omitempty"`
+
+	// ParentID is the case this one was split from, e.g. a task's operation.
+	ParentID string `json:"parentId,omitempty"`
+	// DependsOn lists the tasks that must be done before this one can start.
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Execution records how the task's agent runs went.
+	Execution *Execution `json:"execution,omitempty"`
+}
+
+// Execution is a task's execution record (docs/04-cases.md, "Task Execution Stats").
+type Execution struct {
+	StartedAt   time.Time `json:"startedAt,omitzero"`
+	CompletedAt time.Time `json:"completedAt,omitzero"`
+
+	// Iterations counts the agent iterations of the latest attempt.
+	Iterations int `json:"iterations,omitempty"`
+	// RetryCount counts the attempts after the first.
+	RetryCount int `json:"retryCount,omitempty"`
+
+	Workspace string `json:"workspace,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+
+	VerificationPassed bool   `json:"verificationPassed,omitempty"`
+	LastError          string `json:"lastError,omitempty"`
+	// Signals lists the signals of the latest attempt, e.g. "PROGRESS:50" and "COMPLETE".
+	Signals []string `json:"signals,omitempty"`
+
+	// Decision is the human's answer to the task's last PENDING signal.
+	Decision string `json:"decision,omitempty"`
 }
@@ -69,3 +69,34 @@ func TestStatus_Constants(t *testing.T) {
 		}
 	}
 }
+
+func TestReady(t *testing.T) {
+	// Arrange
+	cases := []Case{
+		{ID: "op-001", Type: CaseTypeOperation, Status: StatusActive},
+		{ID: "op-002", Type: CaseTypeOperation, Status: StatusBlocked},
+		{ID: "op-003", Type: CaseTypeOperation, Status: StatusActive},
+		{ID: "res-001", Type: CaseTypeResearch, Status: StatusPending, ParentID: "op-003"},
+		{ID: "task-001", Type: CaseTypeTask, Status: StatusDone, ParentID: "op-001"},
+		{ID: "task-002", Type: CaseTypeTask, Status: StatusPending, ParentID: "op-001", DependsOn: []string{"task-001"}},
+		{ID: "task-003", Type: CaseTypeTask, Status: StatusPending, ParentID: "op-001", DependsOn: []string{"task-002"}},
+		{ID: "task-004", Type: CaseTypeTask, Status: StatusPending, ParentID: "op-002"},
+		{ID: "task-005", Type: CaseTypeTask, Status: StatusPending, ParentID: "op-003"},
+		{ID: "task-006", Type: CaseTypeTask, Status: StatusFailed},
+		{ID: "task-007", Type: CaseTypeTask, Status: StatusPending, DependsOn: []string{"task-404"}},
+		{ID: "task-008", Type: CaseTypeTask, Status: StatusPending},
+		{ID: "draft-001", Type: CaseTypeDraft, Status: StatusPending},
+	}
+
+	// Act
+	ready := Ready(cases)
+
+	// Assert
+	var ids []string
+	for _, c := range ready {
+		ids = append(ids, c.ID)
+	}
+	if len(ids) != 2 || ids[0] != "task-002" || ids[1] != "task-008" {
+		t.Errorf("got %v, want [task-002 task-008]", ids)
+	}
+}
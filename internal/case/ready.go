@@ -0,0 +1,44 @@
+package casestore
+
+// Ready returns the pending tasks that can start now, in file order: every task
+// they depend on is done, their parent is not blocked, and no research or pending
+// case split from the same parent is unresolved (docs/04-cases.md, "Dependency Rules").
+// Tasks in a dependency cycle never become ready.
+func Ready(cases []Case) []Case {
+	byID := make(map[string]*Case, len(cases))
+	unresolved := make(map[string]bool)
+	for i := range cases {
+		c := &cases[i]
+		byID[c.ID] = c
+		if (c.Type == CaseTypeResearch || c.Type == CaseTypePending) && c.Status != StatusDone && c.ParentID != "" {
+			unresolved[c.ParentID] = true
+		}
+	}
+
+	var ready []Case
+	for _, c := range cases {
+		if c.Type != CaseTypeTask || c.Status != StatusPending {
+			continue
+		}
+		if parent := byID[c.ParentID]; parent != nil && parent.Status == StatusBlocked {
+			continue
+		}
+		if c.ParentID != "" && unresolved[c.ParentID] {
+			continue
+		}
+		if depsDone(c, byID) {
+			ready = append(ready, c)
+		}
+	}
+	return ready
+}
+
+// depsDone reports whether every task c depends on is done. Unknown tasks are not.
+func depsDone(c Case, byID map[string]*Case) bool {
+	for _, id := range c.DependsOn {
+		if dep := byID[id]; dep == nil || dep.Status != StatusDone {
+			return false
+		}
+	}
+	return true
+}
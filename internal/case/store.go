@@ -2,10 +2,17 @@ package casestore
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 )
 
+// ErrNotFound is returned when no case has the requested ID.
+var ErrNotFound = errors.New("case not found")
+
 // CaseStore handles case persistence.
 type CaseStore struct{}
 
@@ -44,3 +51,71 @@ func (s *CaseStore) Load(path string) ([]Case, error) {
 
 	return cases, nil
 }
+
+// SetStatus changes the status of the case with the given ID. Other lines and
+// fields the Case type does not model are written back unchanged.
+func (s *CaseStore) SetStatus(path, id string, status Status) error {
+	return s.SetField(path, id, "status", status)
+}
+
+// SetField sets one JSON field of the case with the given ID, such as a result
+// another package records on the case. Other lines and fields are written back unchanged.
+func (s *CaseStore) SetField(path, id, field string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	found := false
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(line, &fields); err != nil {
+			return err
+		}
+		var caseID string
+		if err := json.Unmarshal(fields["id"], &caseID); err != nil || caseID != id {
+			continue
+		}
+
+		fields[field] = encoded
+		if lines[i], err = json.Marshal(fields); err != nil {
+			return err
+		}
+		found = true
+		break
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	return writeAtomic(path, bytes.Join(lines, []byte("\n")))
+}
+
+// writeAtomic replaces the file at path with data via a temporary file.
+func writeAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write cases: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write cases: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write cases: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write cases: %w", err)
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package casestore
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -70,3 +72,67 @@ func TestCaseStore_Load_MissingFile_ReturnsError(t *testing.T) {
 		t.Errorf("expected nil cases, got %v", cases)
 	}
 }
+
+func TestCaseStore_SetStatus_KeepsOtherFields(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "cases.jsonl")
+	content := `{"id":"task-001","type":"task","status":"active","content":"First task","parentId":"op-001","createdAt":"2026-01-26T10:00:00Z"}
+{"id":"task-002","type":"task","status":"active","content":"Second task","createdAt":"2026-01-26T11:00:00Z"}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	store := NewCaseStore()
+
+	// Act
+	err := store.SetStatus(path, "task-001", StatusTimeout)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cases, err := store.Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cases[0].Status != StatusTimeout || cases[1].Status != StatusActive {
+		t.Errorf("got statuses %s, %s", cases[0].Status, cases[1].Status)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"parentId":"op-001"`) {
+		t.Errorf("expected unmodelled field kept, got:\n%s", data)
+	}
+}
+
+func TestCaseStore_SetStatus_UnknownCase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cases.jsonl")
+	if err := os.WriteFile(path, []byte(`{"id":"task-001","status":"active"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	err := NewCaseStore().SetStatus(path, "task-999", StatusDone)
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestCaseStore_SetField(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "cases.jsonl")
+	if err := os.WriteFile(path, []byte(`{"id":"task-001","status":"active"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	// Act
+	err := NewCaseStore().SetField(path, "task-001", "verification", map[string]bool{"passed": true})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"verification":{"passed":true}`) || !strings.Contains(string(data), `"status":"active"`) {
+		t.Errorf("expected field added beside the others, got:\n%s", data)
+	}
+}
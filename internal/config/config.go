@@ -0,0 +1,277 @@
+// Package config loads .axiom/config.json.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/autopilot"
+	"github.com/deligoez/axiom/internal/escalation"
+	"github.com/deligoez/axiom/internal/integration"
+	"github.com/deligoez/axiom/internal/models"
+	"github.com/deligoez/axiom/internal/supervisor"
+	"github.com/deligoez/axiom/internal/usage"
+	"github.com/deligoez/axiom/internal/verify"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// FileName is the config file inside the .axiom directory.
+const FileName = "config.json"
+
+// BackupName is the copy of the last config file that loaded, kept next to it
+// to recover from a corrupted one.
+const BackupName = "config.json.backup"
+
+// Config is the AXIOM configuration. Sections not modelled here are ignored.
+type Config struct {
+	Version    string         `json:"version"`
+	Mode       autopilot.Mode `json:"mode"`
+	Agents     Agents         `json:"agents"`
+	Completion Completion     `json:"completion"`
+	Usage      Usage          `json:"usage"`
+
+	// Verification lists the commands a task must pass after it signals COMPLETE.
+	Verification verify.Config `json:"verification"`
+
+	// Models selects the model per persona, case label and complexity, with an escalation ladder.
+	Models models.Policy `json:"models"`
+
+	// Stuck configures stuck detection; zero values take the supervisor's defaults.
+	Stuck supervisor.Config `json:"stuck"`
+
+	// Workspaces configures the per-task git worktrees.
+	Workspaces workspace.Config `json:"workspaces"`
+
+	// Merge holds the documented merge options; Load applies them to Integration
+	// and Escalation.
+	Merge Merge `json:"merge"`
+
+	// Integration configures the merge queue for finished task branches.
+	Integration integration.Config `json:"integration"`
+
+	// Escalation configures how long human escalations wait and what happens when nobody answers.
+	Escalation escalation.Config `json:"escalation"`
+
+	// Autopilot limits the execution loop's total iterations, cost and consecutive failures.
+	Autopilot autopilot.Config `json:"autopilot"`
+
+	// NonInteractive replaces the human in headless runs, e.g. in CI.
+	NonInteractive NonInteractive `json:"nonInteractive"`
+}
+
+// Merge is the "merge" section (docs/01-configuration.md).
+type Merge struct {
+	// ConflictRetries is how many resolver attempts a conflict gets before a human is asked.
+	ConflictRetries int `json:"conflictRetries"`
+	// EscalationTimeout is how many seconds an escalation waits for a human.
+	EscalationTimeout int `json:"escalationTimeout"`
+	// EscalationAction is applied when nobody answers in time: "defer", "skip",
+	// "retry", "notify" or "pause".
+	EscalationAction escalation.Action `json:"escalationAction"`
+}
+
+// Escalation strategies of a non-interactive run.
+const (
+	// StrategyDefer sets a task with an unanswered question aside; the run continues.
+	StrategyDefer = "defer"
+	// StrategySkip lets the agent continue on its own judgement.
+	StrategySkip = "skip"
+	// StrategyFail ends the run as failed.
+	StrategyFail = "fail"
+)
+
+// NonInteractive configures runs without a human (docs/02-modes.md, "Non-Interactive Mode").
+type NonInteractive struct {
+	// AutoApprove lets a semi-auto run approve its own checkpoints; without it a
+	// headless run needs autopilot mode.
+	AutoApprove bool `json:"autoApprove"`
+	// EscalationStrategy handles PENDING questions: "defer" (default), "skip" or "fail".
+	EscalationStrategy string `json:"escalationStrategy"`
+	// MaxDeferredTasks fails the run once more tasks are set aside. Default 10.
+	MaxDeferredTasks int `json:"maxDeferredTasks"`
+}
+
+// Validate checks the escalation strategy and the deferred task limit.
+func (n NonInteractive) Validate() error {
+	switch n.EscalationStrategy {
+	case StrategyDefer, StrategySkip, StrategyFail:
+	default:
+		return fmt.Errorf("unknown nonInteractive.escalationStrategy %q (want defer, skip or fail)", n.EscalationStrategy)
+	}
+	if n.MaxDeferredTasks < 0 {
+		return fmt.Errorf("nonInteractive.maxDeferredTasks must not be negative")
+	}
+	return nil
+}
+
+// Agents configures agent slots and defaults.
+type Agents struct {
+	MaxParallel    int    `json:"maxParallel"`
+	TimeoutMinutes int    `json:"timeoutMinutes"`
+	DefaultModel   string `json:"defaultModel"`
+
+	// QueryTimeout limits one agent query and SessionTimeout the whole AXIOM session,
+	// e.g. "10m" or "8h". Empty means no limit.
+	QueryTimeout   string `json:"queryTimeout,omitempty"`
+	SessionTimeout string `json:"sessionTimeout,omitempty"`
+}
+
+// Completion configures when a task's iterations stop.
+type Completion struct {
+	Signal         string `json:"signal"`
+	MaxIterations  int    `json:"maxIterations"`
+	StuckThreshold int    `json:"stuckThreshold"`
+}
+
+// Usage configures cost accounting and budgets.
+type Usage struct {
+	// Prices override or extend the built-in price table (USD per million tokens).
+	Prices  usage.PriceTable `json:"prices,omitempty"`
+	Budgets usage.Budgets    `json:"budgets"`
+}
+
+// Default returns the documented defaults.
+func Default() Config {
+	return Config{
+		Version: "1.0.0",
+		Mode:    autopilot.ModeSemiAuto,
+		Agents: Agents{
+			MaxParallel:    3,
+			TimeoutMinutes: 30,
+			DefaultModel:   "sonnet",
+		},
+		Completion: Completion{
+			Signal:         "<axiom>COMPLETE</axiom>",
+			MaxIterations:  50,
+			StuckThreshold: 5,
+		},
+		Models:     models.DefaultPolicy(),
+		Workspaces: workspace.Config{MinFreeMB: workspace.DefaultMinFreeMB},
+		NonInteractive: NonInteractive{
+			EscalationStrategy: StrategyDefer,
+			MaxDeferredTasks:   10,
+		},
+	}
+}
+
+// Path returns the config file path for an .axiom directory.
+func Path(axiomDir string) string {
+	return filepath.Join(axiomDir, FileName)
+}
+
+// Load reads config.json from axiomDir. A missing file yields Default;
+// fields left out of the file keep their defaults, and an unknown mode falls
+// back to semi-auto with a warning. A file that loads is copied to BackupName.
+func Load(axiomDir string) (Config, error) {
+	data, err := os.ReadFile(Path(axiomDir))
+	if os.IsNotExist(err) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Default(), fmt.Errorf("read config: %w", err)
+	}
+	cfg, err := parse(data)
+	if err != nil {
+		return cfg, err
+	}
+	if err := os.WriteFile(filepath.Join(axiomDir, BackupName), data, 0o644); err != nil {
+		log.Printf("[WARN] Could not back up config: %v", err)
+	}
+	return cfg, nil
+}
+
+// Recover loads config.json like Load, but when it cannot be loaded uses the
+// backup Load keeps and, failing that, Default (docs/01-configuration.md,
+// "Non-Interactive Recovery"). It returns what it fell back to and why, or an
+// empty string when config.json loaded.
+func Recover(axiomDir string) (Config, string) {
+	cfg, err := Load(axiomDir)
+	if err == nil {
+		return cfg, ""
+	}
+	note := fmt.Sprintf("%s: %v; restored %s", FileName, err, BackupName)
+	data, backupErr := os.ReadFile(filepath.Join(axiomDir, BackupName))
+	if backupErr == nil {
+		cfg, backupErr = parse(data)
+	}
+	if backupErr != nil {
+		cfg = Default()
+		note = fmt.Sprintf("%s: %v; using defaults (%s: %v)", FileName, err, BackupName, backupErr)
+	}
+	log.Printf("[WARN] %s", note)
+	return cfg, note
+}
+
+// parse decodes a config file over the defaults and validates it.
+func parse(data []byte) (Config, error) {
+	cfg := Default()
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse config: %w", err)
+	}
+	if !cfg.Mode.Valid() {
+		log.Printf("[WARN] Invalid mode='%s' (must be %s|%s), using default: %s", cfg.Mode, autopilot.ModeSemiAuto, autopilot.ModeAutopilot, autopilot.ModeSemiAuto)
+		cfg.Mode = autopilot.ModeSemiAuto
+	}
+	if err := cfg.Models.Validate(); err != nil {
+		return cfg, fmt.Errorf("config: %w", err)
+	}
+	if err := cfg.Verification.Validate(); err != nil {
+		return cfg, fmt.Errorf("config: %w", err)
+	}
+	cfg.Integration.ConflictRetries = cfg.Merge.ConflictRetries
+	cfg.Escalation.Timeout, cfg.Escalation.Action = cfg.Merge.EscalationTimeout, cfg.Merge.EscalationAction
+	if err := cfg.Escalation.Validate(); err != nil {
+		return cfg, fmt.Errorf("config: %w", err)
+	}
+	if err := cfg.Autopilot.Validate(); err != nil {
+		return cfg, fmt.Errorf("config: %w", err)
+	}
+	if err := cfg.NonInteractive.Validate(); err != nil {
+		return cfg, fmt.Errorf("config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Prices returns the built-in price table with the config's overrides applied.
+func (c Config) Prices() usage.PriceTable {
+	prices := usage.DefaultPrices()
+	for model, p := range c.Usage.Prices {
+		prices[model] = p
+	}
+	return prices
+}
+
+// Supervisor returns the stuck detection config, capped at completion.maxIterations
+// unless the stuck section sets its own limit.
+func (c Config) Supervisor() supervisor.Config {
+	s := c.Stuck
+	if s.MaxIterations == 0 {
+		s.MaxIterations = c.Completion.MaxIterations
+	}
+	return s
+}
+
+// Timeouts parses the agent timeouts: timeoutMinutes per task, queryTimeout and sessionTimeout.
+func (c Config) Timeouts() (agent.Timeouts, error) {
+	if c.Agents.TimeoutMinutes < 0 {
+		return agent.Timeouts{}, fmt.Errorf("%w: timeoutMinutes %d is negative", agent.ErrInvalidTimeout, c.Agents.TimeoutMinutes)
+	}
+	query, err := agent.ParseTimeout(c.Agents.QueryTimeout)
+	if err != nil {
+		return agent.Timeouts{}, fmt.Errorf("queryTimeout: %w", err)
+	}
+	session, err := agent.ParseTimeout(c.Agents.SessionTimeout)
+	if err != nil {
+		return agent.Timeouts{}, fmt.Errorf("sessionTimeout: %w", err)
+	}
+	return agent.Timeouts{
+		Query:   query,
+		Task:    time.Duration(c.Agents.TimeoutMinutes) * time.Minute,
+		Session: session,
+	}, nil
+}
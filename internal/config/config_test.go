@@ -0,0 +1,274 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/autopilot"
+	"github.com/deligoez/axiom/internal/escalation"
+)
+
+func TestLoad_MissingFileUsesDefaults(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Agents.MaxParallel != 3 || cfg.Completion.MaxIterations != 50 || cfg.Mode != "semi-auto" {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoad_MergesWithDefaults(t *testing.T) {
+	dir := t.TempDir()
+	content := `{
+  "version": "1.0.0",
+  "agents": {"maxParallel": 5},
+  "verification": ["go test ./..."],
+  "usage": {
+    "prices": {"my-model": {"input": 1, "output": 2}},
+    "budgets": {"run": {"costUSD": 2.5}, "session": {"tokens": 1000000}}
+  }
+}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Agents.MaxParallel != 5 {
+		t.Errorf("got maxParallel %d, want 5", cfg.Agents.MaxParallel)
+	}
+	if cfg.Agents.TimeoutMinutes != 30 {
+		t.Errorf("expected default timeoutMinutes kept, got %d", cfg.Agents.TimeoutMinutes)
+	}
+	if cfg.Usage.Budgets.Run.CostUSD != 2.5 || cfg.Usage.Budgets.Session.Tokens != 1000000 {
+		t.Errorf("unexpected budgets: %+v", cfg.Usage.Budgets)
+	}
+
+	prices := cfg.Prices()
+	if p, ok := prices.Lookup("my-model"); !ok || p.Output != 2 {
+		t.Errorf("expected configured price, got %+v (ok=%v)", p, ok)
+	}
+	if _, ok := prices.Lookup("claude-sonnet-4-20250514"); !ok {
+		t.Error("expected built-in prices kept")
+	}
+}
+
+func TestLoad_InvalidJSON(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("{"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	if _, err := Load(dir); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestRecover_FallsBackToBackupThenDefaults(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+	}
+
+	write(`{"agents": {"maxParallel": 5}}`)
+	cfg, note := Recover(dir)
+	if note != "" || cfg.Agents.MaxParallel != 5 {
+		t.Fatalf("got maxParallel %d (%q), want the config loaded", cfg.Agents.MaxParallel, note)
+	}
+	if _, err := os.Stat(filepath.Join(dir, BackupName)); err != nil {
+		t.Fatalf("expected a backup of the loaded config: %v", err)
+	}
+
+	write("{")
+	cfg, note = Recover(dir)
+	if cfg.Agents.MaxParallel != 5 || !strings.Contains(note, "restored "+BackupName) {
+		t.Errorf("got maxParallel %d (%q), want the backup restored", cfg.Agents.MaxParallel, note)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, BackupName), []byte("{"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	cfg, note = Recover(dir)
+	if cfg.Agents.MaxParallel != 3 || !strings.Contains(note, "using defaults") {
+		t.Errorf("got maxParallel %d (%q), want the defaults", cfg.Agents.MaxParallel, note)
+	}
+}
+
+func TestConfig_SupervisorUsesMaxIterations(t *testing.T) {
+	cfg := Default()
+	if got := cfg.Supervisor().MaxIterations; got != 50 {
+		t.Errorf("got maxIterations %d, want 50", got)
+	}
+
+	cfg.Stuck.MaxIterations = 10
+	if got := cfg.Supervisor().MaxIterations; got != 10 {
+		t.Errorf("got maxIterations %d, want 10", got)
+	}
+}
+
+func TestConfig_Timeouts(t *testing.T) {
+	cfg := Default()
+	cfg.Agents.QueryTimeout = "10m"
+
+	timeouts, err := cfg.Timeouts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if timeouts.Task != 30*time.Minute || timeouts.Query != 10*time.Minute || timeouts.Session != 0 {
+		t.Errorf("unexpected timeouts: %+v", timeouts)
+	}
+
+	cfg.Agents.SessionTimeout = "all day"
+	if _, err := cfg.Timeouts(); !errors.Is(err, agent.ErrInvalidTimeout) {
+		t.Errorf("got %v, want ErrInvalidTimeout", err)
+	}
+}
+
+func TestLoad_ModelPolicy(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"models": {"personas": {"rex": "opus"}, "labels": {"docs": "haiku"}}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Models.Personas["rex"] != "opus" || len(cfg.Models.Ladder) != 3 {
+		t.Errorf("expected configured personas and default ladder, got %+v", cfg.Models)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(`{"models": {"ladder": ["opus", "opus"]}}`), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("expected error for invalid model policy")
+	}
+}
+
+func TestLoad_Escalation(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"merge": {"conflictRetries": 5, "escalationTimeout": 900, "escalationAction": "pause"}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Escalation.Timeout != 900 || cfg.Escalation.Action != escalation.ActionPause {
+		t.Errorf("expected configured escalation, got %+v", cfg.Escalation)
+	}
+	if cfg.Integration.ConflictRetries != 5 {
+		t.Errorf("expected 5 conflict retries, got %d", cfg.Integration.ConflictRetries)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(`{"merge": {"escalationAction": "ignore"}}`), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("expected error for unknown escalation action")
+	}
+}
+
+func TestLoad_Verification(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"verification": ["go test ./...", {"command": "golangci-lint run", "required": false}]}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmds := cfg.Verification.Commands
+	if len(cmds) != 2 || cmds[0].Command != "go test ./..." || cmds[1].Required == nil || *cmds[1].Required {
+		t.Errorf("expected both command forms, got %+v", cmds)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(`{"verification": [""]}`), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("expected error for an empty verification command")
+	}
+}
+
+func TestLoad_Autopilot(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"autopilot": {"maxIterations": 200, "maxCostUSD": 25.5}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Autopilot.MaxIterations != 200 || cfg.Autopilot.MaxCostUSD != 25.5 {
+		t.Errorf("expected configured autopilot limits, got %+v", cfg.Autopilot)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(`{"autopilot": {"maxCostUSD": -1}}`), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("expected error for a negative cost limit")
+	}
+}
+
+func TestLoad_Mode(t *testing.T) {
+	dir := t.TempDir()
+	for content, want := range map[string]autopilot.Mode{
+		`{"mode": "autopilot"}`: autopilot.ModeAutopilot,
+		`{"mode": "invalid"}`:   autopilot.ModeSemiAuto,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644); err != nil {
+			t.Fatalf("setup failed: %v", err)
+		}
+		cfg, err := Load(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.Mode != want {
+			t.Errorf("%s: got mode %s, want %s", content, cfg.Mode, want)
+		}
+	}
+}
+
+func TestLoad_NonInteractive(t *testing.T) {
+	dir := t.TempDir()
+	content := `{"nonInteractive": {"autoApprove": true, "escalationStrategy": "skip"}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (NonInteractive{AutoApprove: true, EscalationStrategy: StrategySkip, MaxDeferredTasks: 10}); cfg.NonInteractive != want {
+		t.Errorf("got %+v, want %+v", cfg.NonInteractive, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(`{"nonInteractive": {"escalationStrategy": "ask"}}`), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := Load(dir); err == nil {
+		t.Error("expected error for an unknown escalation strategy")
+	}
+}
@@ -0,0 +1,461 @@
+// Package escalation keeps the inbox of decisions that need a human: conflicts
+// nobody could resolve and agents' PENDING signals. Escalations nobody answers
+// within the timeout get the configured default policy.
+package escalation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deligoez/axiom/internal/hook"
+)
+
+// Defaults applied to zero-valued Config fields.
+const (
+	DefaultTimeout = 3600
+	DefaultAction  = ActionDefer
+	HookName       = "on-escalation"
+)
+
+// Kind is what needs the human.
+type Kind string
+
+const (
+	// KindConflict is a merge conflict the integration queue could not resolve.
+	KindConflict Kind = "conflict"
+	// KindPending is an agent's PENDING signal asking for a decision.
+	KindPending Kind = "pending"
+)
+
+// Status is where an escalation is in its lifecycle.
+type Status string
+
+const (
+	StatusOpen      Status = "open"
+	StatusApproved  Status = "approved"
+	StatusRejected  Status = "rejected"
+	StatusResponded Status = "responded"
+	StatusExpired   Status = "expired"
+)
+
+// Decision is a human's answer to an escalation.
+type Decision string
+
+const (
+	Approve Decision = "approve"
+	Reject  Decision = "reject"
+	// Respond answers with a free-form message.
+	Respond Decision = "respond"
+)
+
+// Action is the default policy applied when an escalation times out.
+type Action string
+
+const (
+	// ActionDefer sets the task aside and lets the rest of the work continue.
+	ActionDefer Action = "defer"
+	// ActionSkip drops the blocked step; the task stays pending.
+	ActionSkip Action = "skip"
+	// ActionRetry retries the blocked step: the merge is queued again, the task
+	// runs again without an answer.
+	ActionRetry Action = "retry"
+	// ActionNotify runs the on-escalation hook again and keeps waiting for another timeout.
+	ActionNotify Action = "notify"
+	// ActionPause pauses the session until a human resumes it.
+	ActionPause Action = "pause"
+)
+
+var (
+	// ErrNotFound is returned for unknown escalation IDs.
+	ErrNotFound = errors.New("escalation not found")
+	// ErrClosed is returned when answering an escalation that is no longer open.
+	ErrClosed = errors.New("escalation already closed")
+	// ErrInvalidDecision is returned for decisions other than approve, reject and respond.
+	ErrInvalidDecision = errors.New("invalid decision")
+	// ErrPaused is returned while an expired escalation keeps the session paused.
+	ErrPaused = errors.New("session paused by an unanswered escalation")
+)
+
+// Config is the "escalation" section of .axiom/config.json. Timeout and Action
+// are set from merge.escalationTimeout and merge.escalationAction.
+type Config struct {
+	// Timeout is how many seconds an escalation waits for a human. Default 3600.
+	Timeout int `json:"-"`
+
+	// Action is applied on timeout: "defer" (default), "skip", "retry", "notify" or "pause".
+	Action Action `json:"-"`
+
+	// Hook is the on-escalation script relative to the project.
+	// Default .axiom/hooks/on-escalation.sh.
+	Hook string `json:"hook,omitempty"`
+
+	// HookTimeout limits the hook in seconds. Default 30.
+	HookTimeout int `json:"hookTimeout,omitempty"`
+}
+
+// Validate reports unknown timeout actions and negative timeouts.
+func (c Config) Validate() error {
+	switch c.Action {
+	case "", ActionDefer, ActionSkip, ActionRetry, ActionNotify, ActionPause:
+	default:
+		return fmt.Errorf("unknown escalation action %q", c.Action)
+	}
+	if c.Timeout < 0 || c.HookTimeout < 0 {
+		return fmt.Errorf("escalation timeouts must not be negative")
+	}
+	return nil
+}
+
+// Escalation is one decision waiting for, or answered by, a human.
+type Escalation struct {
+	ID     string `json:"id"`
+	TaskID string `json:"taskId"`
+	Kind   Kind   `json:"kind"`
+
+	// Reason says why a human is needed.
+	Reason string `json:"reason"`
+	// Context is what the human needs to decide, e.g. the conflicted files.
+	Context string `json:"context,omitempty"`
+	// Options are the suggested answers.
+	Options []string `json:"options,omitempty"`
+
+	// Files, Level and Workspace describe a conflict for the human and the hook.
+	Files     []string `json:"files,omitempty"`
+	Level     string   `json:"level,omitempty"`
+	Workspace string   `json:"workspace,omitempty"`
+
+	Status Status `json:"status"`
+	// Response is the human's message.
+	Response string `json:"response,omitempty"`
+	// Action is the default policy applied when the escalation expired.
+	Action Action `json:"action,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	Deadline  time.Time `json:"deadline"`
+	ClosedAt  time.Time `json:"closedAt,omitzero"`
+}
+
+// Handler is told about every escalation that closes, answered or expired.
+type Handler func(Escalation)
+
+// state is the persisted inbox.
+type state struct {
+	NextID      int           `json:"nextId"`
+	Paused      bool          `json:"paused,omitempty"`
+	Escalations []*Escalation `json:"escalations"`
+}
+
+// Inbox is the persistent list of escalations.
+type Inbox struct {
+	path    string
+	timeout time.Duration
+	action  Action
+	hook    hook.Hook
+
+	mu       sync.Mutex
+	state    state
+	handlers []Handler
+	now      func() time.Time
+
+	// hooks tracks on-escalation hooks still running.
+	hooks sync.WaitGroup
+}
+
+// Open loads the inbox persisted at path. The hook runs in projectDir, and
+// its output is logged under axiomDir.
+func Open(path, projectDir, axiomDir string, cfg Config) (*Inbox, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.Action == "" {
+		cfg.Action = DefaultAction
+	}
+	script := hook.Path(axiomDir, HookName)
+	if cfg.Hook != "" {
+		script = cfg.Hook
+	}
+	if !filepath.IsAbs(script) {
+		script = filepath.Join(projectDir, script)
+	}
+
+	in := &Inbox{
+		path:    path,
+		timeout: time.Duration(cfg.Timeout) * time.Second,
+		action:  cfg.Action,
+		hook: hook.Hook{
+			Name:    HookName,
+			Script:  script,
+			Dir:     projectDir,
+			Timeout: time.Duration(cfg.HookTimeout) * time.Second,
+			LogPath: filepath.Join(axiomDir, hook.LogFile),
+		},
+		now: time.Now,
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read escalations: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &in.state); err != nil {
+			return nil, fmt.Errorf("parse escalations: %w", err)
+		}
+	}
+	return in, nil
+}
+
+// OnClose registers h for escalations that are answered or expire.
+func (in *Inbox) OnClose(h Handler) {
+	in.mu.Lock()
+	in.handlers = append(in.handlers, h)
+	in.mu.Unlock()
+}
+
+// Raise records e as open and starts the on-escalation hook in the background,
+// so a slow hook never holds up the caller. A task already
+// waiting on an open escalation of the same kind gets that escalation back,
+// refreshed with e's details, without running the hook again.
+func (in *Inbox) Raise(ctx context.Context, e Escalation) (Escalation, error) {
+	in.mu.Lock()
+	now := in.now()
+	for _, open := range in.state.Escalations {
+		if open.Status == StatusOpen && open.TaskID == e.TaskID && open.Kind == e.Kind {
+			open.Reason, open.Context, open.Options = e.Reason, e.Context, e.Options
+			open.Files, open.Level, open.Workspace = e.Files, e.Level, e.Workspace
+			existing := *open
+			err := in.save()
+			in.mu.Unlock()
+			return existing, err
+		}
+	}
+
+	in.state.NextID++
+	e.ID = fmt.Sprintf("esc-%03d", in.state.NextID)
+	e.Status, e.Response, e.Action = StatusOpen, "", ""
+	e.CreatedAt, e.Deadline, e.ClosedAt = now, now.Add(in.timeout), time.Time{}
+	in.state.Escalations = append(in.state.Escalations, &e)
+	err := in.save()
+	in.mu.Unlock()
+	if err != nil {
+		return e, err
+	}
+
+	log.Printf("[escalation] %s: %s needs a human: %s", e.ID, e.TaskID, e.Reason)
+	in.runHook(ctx, e)
+	return e, nil
+}
+
+// runHook runs the on-escalation hook for e in the background. The hook outlives
+// ctx's cancellation; its own timeout bounds it.
+func (in *Inbox) runHook(ctx context.Context, e Escalation) {
+	ctx = context.WithoutCancel(ctx)
+	in.hooks.Add(1)
+	go func() {
+		defer in.hooks.Done()
+		_, _ = in.hook.Run(ctx, hookEnv(e))
+	}()
+}
+
+// Wait waits for the on-escalation hooks still running, e.g. before the process exits.
+func (in *Inbox) Wait() {
+	in.hooks.Wait()
+}
+
+// Pending returns open escalations, oldest first.
+func (in *Inbox) Pending() []Escalation {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	list := []Escalation{}
+	for _, e := range in.state.Escalations {
+		if e.Status == StatusOpen {
+			list = append(list, *e)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// Get returns a copy of escalation id.
+func (in *Inbox) Get(id string) (Escalation, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if e := in.find(id); e != nil {
+		return *e, nil
+	}
+	return Escalation{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// Answer closes an open escalation with the human's decision. Respond requires a message.
+func (in *Inbox) Answer(id string, d Decision, message string) (Escalation, error) {
+	status := map[Decision]Status{Approve: StatusApproved, Reject: StatusRejected, Respond: StatusResponded}[d]
+	if status == "" || (d == Respond && strings.TrimSpace(message) == "") {
+		return Escalation{}, fmt.Errorf("%w: %q", ErrInvalidDecision, d)
+	}
+
+	in.mu.Lock()
+	e := in.find(id)
+	if e == nil {
+		in.mu.Unlock()
+		return Escalation{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if e.Status != StatusOpen {
+		in.mu.Unlock()
+		return Escalation{}, fmt.Errorf("%w: %s is %s", ErrClosed, id, e.Status)
+	}
+	e.Status, e.Response, e.ClosedAt = status, message, in.now()
+	closed := *e
+	err := in.save()
+	handlers := in.handlers
+	in.mu.Unlock()
+	if err != nil {
+		return closed, err
+	}
+
+	log.Printf("[escalation] %s %s", id, status)
+	notify(handlers, closed)
+	return closed, nil
+}
+
+// Expire closes open escalations past their deadline with the default action,
+// pausing the session when that action is pause. With notify they stay open for
+// another timeout and the hook runs again. It returns the expired escalations.
+func (in *Inbox) Expire() ([]Escalation, error) {
+	in.mu.Lock()
+	now := in.now()
+	var expired, renotify []Escalation
+	for _, e := range in.state.Escalations {
+		if e.Status != StatusOpen || now.Before(e.Deadline) {
+			continue
+		}
+		if in.action == ActionNotify {
+			e.Deadline = now.Add(in.timeout)
+			renotify = append(renotify, *e)
+			continue
+		}
+		e.Status, e.Action, e.ClosedAt = StatusExpired, in.action, now
+		if in.action == ActionPause {
+			in.state.Paused = true
+		}
+		expired = append(expired, *e)
+	}
+	if len(expired) == 0 && len(renotify) == 0 {
+		in.mu.Unlock()
+		return nil, nil
+	}
+	err := in.save()
+	handlers := in.handlers
+	in.mu.Unlock()
+	if err != nil {
+		return expired, err
+	}
+
+	for _, e := range renotify {
+		log.Printf("[escalation] %s for %s is still unanswered, notifying again", e.ID, e.TaskID)
+		in.runHook(context.Background(), e)
+	}
+	for _, e := range expired {
+		log.Printf("[escalation] %s for %s expired unanswered, applying %s", e.ID, e.TaskID, e.Action)
+		notify(handlers, e)
+	}
+	return expired, nil
+}
+
+// Watch expires escalations every interval until ctx is done.
+func (in *Inbox) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := in.Expire(); err != nil {
+				log.Printf("[WARN] %v", err)
+			}
+		}
+	}
+}
+
+// Paused reports whether an expired escalation paused the session.
+func (in *Inbox) Paused() bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.state.Paused
+}
+
+// Resume lifts a pause applied by an expired escalation.
+func (in *Inbox) Resume() error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if !in.state.Paused {
+		return nil
+	}
+	in.state.Paused = false
+	log.Printf("[escalation] session resumed")
+	return in.save()
+}
+
+// find returns escalation id. The caller holds in.mu.
+func (in *Inbox) find(id string) *Escalation {
+	for _, e := range in.state.Escalations {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// save atomically writes the inbox. The caller holds in.mu.
+func (in *Inbox) save() error {
+	data, err := json.MarshalIndent(in.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(in.path), 0o755); err != nil {
+		return fmt.Errorf("create escalation dir: %w", err)
+	}
+	tmp := in.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write escalations: %w", err)
+	}
+	if err := os.Rename(tmp, in.path); err != nil {
+		return fmt.Errorf("write escalations: %w", err)
+	}
+	return nil
+}
+
+// notify calls each handler with e.
+func notify(handlers []Handler, e Escalation) {
+	for _, h := range handlers {
+		h(e)
+	}
+}
+
+// hookEnv is the on-escalation hook's environment.
+func hookEnv(e Escalation) map[string]string {
+	return map[string]string{
+		"AXIOM_ESCALATION_ID":       e.ID,
+		"AXIOM_ESCALATION_KIND":     string(e.Kind),
+		"AXIOM_ESCALATION_REASON":   e.Reason,
+		"AXIOM_ESCALATION_DEADLINE": e.Deadline.UTC().Format(time.RFC3339),
+		"AXIOM_TASK_ID":             e.TaskID,
+		"AXIOM_CONFLICT_FILES":      strings.Join(e.Files, ","),
+		"AXIOM_CONFLICT_LEVEL":      strings.ToUpper(e.Level),
+		"AXIOM_WORKSPACE":           e.Workspace,
+	}
+}
@@ -0,0 +1,495 @@
+package escalation
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+	"github.com/deligoez/axiom/internal/integration"
+)
+
+// openInbox opens an inbox in a temporary project with a fixed clock.
+func openInbox(t *testing.T, cfg Config) (*Inbox, string) {
+	t.Helper()
+	project := t.TempDir()
+	axiomDir := filepath.Join(project, ".axiom")
+	in, err := Open(filepath.Join(axiomDir, "escalations.json"), project, axiomDir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	in.now = func() time.Time { return now }
+	return in, project
+}
+
+// advance moves the inbox clock forward by d.
+func advance(in *Inbox, d time.Duration) {
+	now := in.now().Add(d)
+	in.now = func() time.Time { return now }
+}
+
+func TestRaise_RecordsAndRunsHook(t *testing.T) {
+	// Arrange
+	in, project := openInbox(t, Config{Timeout: 60})
+	hooks := filepath.Join(project, ".axiom", "hooks")
+	if err := os.MkdirAll(hooks, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"$AXIOM_ESCALATION_ID $AXIOM_TASK_ID $AXIOM_CONFLICT_LEVEL $AXIOM_CONFLICT_FILES\" > hook.out\n"
+	if err := os.WriteFile(filepath.Join(hooks, HookName+".sh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	e, err := in.Raise(context.Background(), Escalation{
+		TaskID: "task-042",
+		Kind:   KindConflict,
+		Reason: "resolver gave up",
+		Files:  []string{"a.go", "b.go"},
+		Level:  "complex",
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Raise() error = %v", err)
+	}
+	if e.ID != "esc-001" || e.Status != StatusOpen || !e.Deadline.Equal(e.CreatedAt.Add(time.Minute)) {
+		t.Errorf("Raise() = %+v", e)
+	}
+	in.Wait()
+	out, err := os.ReadFile(filepath.Join(project, "hook.out"))
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "esc-001 task-042 COMPLEX a.go,b.go" {
+		t.Errorf("hook env = %q", got)
+	}
+}
+
+func TestRaise_DoesNotWaitForHook(t *testing.T) {
+	// Arrange
+	in, project := openInbox(t, Config{})
+	hooks := filepath.Join(project, ".axiom", "hooks")
+	if err := os.MkdirAll(hooks, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\nsleep 1\ntouch hook.done\n"
+	if err := os.WriteFile(filepath.Join(hooks, HookName+".sh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Act
+	start := time.Now()
+	_, err := in.Raise(ctx, Escalation{TaskID: "task-1", Kind: KindPending, Reason: "which API?"})
+	elapsed := time.Since(start)
+	cancel()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Raise() error = %v", err)
+	}
+	if elapsed >= time.Second {
+		t.Errorf("Raise() took %s, want it to return before the hook finishes", elapsed)
+	}
+	in.Wait()
+	if _, err := os.Stat(filepath.Join(project, "hook.done")); err != nil {
+		t.Errorf("hook did not finish after the caller's context ended: %v", err)
+	}
+}
+
+func TestRaise_ReusesOpenEscalationForTask(t *testing.T) {
+	// Arrange
+	in, _ := openInbox(t, Config{})
+	first, err := in.Raise(context.Background(), Escalation{TaskID: "task-1", Kind: KindConflict, Reason: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	again, err := in.Raise(context.Background(), Escalation{TaskID: "task-1", Kind: KindConflict, Reason: "second"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Raise() error = %v", err)
+	}
+	if again.ID != first.ID || again.Reason != "second" {
+		t.Errorf("Raise() = %+v, want %s refreshed", again, first.ID)
+	}
+	if n := len(in.Pending()); n != 1 {
+		t.Errorf("Pending() has %d escalations, want 1", n)
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision Decision
+		message  string
+		want     Status
+		wantErr  error
+	}{
+		{"approve", Approve, "", StatusApproved, nil},
+		{"reject", Reject, "not now", StatusRejected, nil},
+		{"respond", Respond, "use the v2 API", StatusResponded, nil},
+		{"respond needs a message", Respond, " ", "", ErrInvalidDecision},
+		{"unknown decision", Decision("maybe"), "", "", ErrInvalidDecision},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			in, _ := openInbox(t, Config{})
+			var closed []Escalation
+			in.OnClose(func(e Escalation) { closed = append(closed, e) })
+			e, err := in.Raise(context.Background(), Escalation{TaskID: "task-1", Kind: KindPending, Reason: "which API?"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Act
+			got, err := in.Answer(e.ID, tt.decision, tt.message)
+
+			// Assert
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Answer() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(closed) != 0 || len(in.Pending()) != 1 {
+					t.Error("a rejected answer closed the escalation")
+				}
+				return
+			}
+			if got.Status != tt.want || got.Response != tt.message || got.ClosedAt.IsZero() {
+				t.Errorf("Answer() = %+v", got)
+			}
+			if len(closed) != 1 || closed[0].ID != e.ID {
+				t.Errorf("handlers got %+v", closed)
+			}
+			if _, err := in.Answer(e.ID, Approve, ""); !errors.Is(err, ErrClosed) {
+				t.Errorf("second Answer() error = %v, want ErrClosed", err)
+			}
+		})
+	}
+}
+
+func TestAnswer_UnknownID(t *testing.T) {
+	in, _ := openInbox(t, Config{})
+
+	if _, err := in.Answer("esc-404", Approve, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Answer() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestExpire_AppliesDefaultAction(t *testing.T) {
+	tests := []struct {
+		action     Action
+		wantPaused bool
+	}{
+		{"", false},
+		{ActionSkip, false},
+		{ActionRetry, false},
+		{ActionPause, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			// Arrange
+			in, _ := openInbox(t, Config{Timeout: 60, Action: tt.action})
+			var closed []Escalation
+			in.OnClose(func(e Escalation) { closed = append(closed, e) })
+			if _, err := in.Raise(context.Background(), Escalation{TaskID: "task-1", Kind: KindPending}); err != nil {
+				t.Fatal(err)
+			}
+			advance(in, 30*time.Second)
+			if expired, _ := in.Expire(); len(expired) != 0 {
+				t.Fatalf("expired %d escalations before the deadline", len(expired))
+			}
+
+			// Act
+			advance(in, 30*time.Second)
+			expired, err := in.Expire()
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expire() error = %v", err)
+			}
+			want := tt.action
+			if want == "" {
+				want = DefaultAction
+			}
+			if len(expired) != 1 || expired[0].Status != StatusExpired || expired[0].Action != want {
+				t.Fatalf("Expire() = %+v, want one expired with %s", expired, want)
+			}
+			if len(closed) != 1 || len(in.Pending()) != 0 {
+				t.Errorf("handlers got %d, pending %d; want 1 and 0", len(closed), len(in.Pending()))
+			}
+			if in.Paused() != tt.wantPaused {
+				t.Errorf("Paused() = %v, want %v", in.Paused(), tt.wantPaused)
+			}
+			if err := in.Resume(); err != nil || in.Paused() {
+				t.Errorf("Resume() = %v, paused %v", err, in.Paused())
+			}
+		})
+	}
+}
+
+func TestExpire_NotifyKeepsWaiting(t *testing.T) {
+	// Arrange
+	in, project := openInbox(t, Config{Timeout: 60, Action: ActionNotify})
+	hooks := filepath.Join(project, ".axiom", "hooks")
+	if err := os.MkdirAll(hooks, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"$AXIOM_ESCALATION_DEADLINE\" >> hook.out\n"
+	if err := os.WriteFile(filepath.Join(hooks, HookName+".sh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var closed []Escalation
+	in.OnClose(func(e Escalation) { closed = append(closed, e) })
+	e, err := in.Raise(context.Background(), Escalation{TaskID: "task-1", Kind: KindPending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.Wait()
+
+	// Act
+	advance(in, time.Minute)
+	expired, err := in.Expire()
+	in.Wait()
+
+	// Assert
+	if err != nil || len(expired) != 0 || len(closed) != 0 {
+		t.Fatalf("Expire() = %+v, %v; closed %d; want nothing closed", expired, err, len(closed))
+	}
+	pending := in.Pending()
+	if len(pending) != 1 || !pending[0].Deadline.Equal(e.Deadline.Add(time.Minute)) {
+		t.Errorf("Pending() = %+v, want %s open for another minute", pending, e.ID)
+	}
+	out, err := os.ReadFile(filepath.Join(project, "hook.out"))
+	if err != nil {
+		t.Fatalf("hook did not run: %v", err)
+	}
+	if lines := strings.Fields(string(out)); len(lines) != 2 {
+		t.Errorf("hook ran %d time(s), want 2: %q", len(lines), out)
+	}
+}
+
+func TestOpen_Persists(t *testing.T) {
+	// Arrange
+	in, project := openInbox(t, Config{Action: ActionPause, Timeout: 1})
+	if _, err := in.Raise(context.Background(), Escalation{TaskID: "task-1", Kind: KindPending}); err != nil {
+		t.Fatal(err)
+	}
+	advance(in, time.Second)
+	if _, err := in.Expire(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Raise(context.Background(), Escalation{TaskID: "task-2", Kind: KindPending}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	axiomDir := filepath.Join(project, ".axiom")
+	reopened, err := Open(filepath.Join(axiomDir, "escalations.json"), project, axiomDir, Config{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	pending := reopened.Pending()
+	if len(pending) != 1 || pending[0].ID != "esc-002" || !reopened.Paused() {
+		t.Errorf("reopened pending %+v, paused %v", pending, reopened.Paused())
+	}
+	if _, err := Open(filepath.Join(axiomDir, "escalations.json"), project, axiomDir, Config{Action: "ignore"}); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
+
+func TestQueueHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		esc       Escalation
+		wantState integration.State
+		wantGone  bool
+	}{
+		{"approve re-queues", Escalation{Status: StatusApproved}, integration.StateQueued, false},
+		{"reject drops", Escalation{Status: StatusRejected}, "", true},
+		{"skip drops", Escalation{Status: StatusExpired, Action: ActionSkip}, "", true},
+		{"retry re-queues", Escalation{Status: StatusExpired, Action: ActionRetry}, integration.StateQueued, false},
+		{"defer sets aside", Escalation{Status: StatusExpired, Action: ActionDefer}, integration.StateDeferred, false},
+		{"pause waits", Escalation{Status: StatusExpired, Action: ActionPause}, integration.StateConflict, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			dir := t.TempDir()
+			path := filepath.Join(dir, "queue.json")
+			conflicted := `[{"taskId": "task-1", "branch": "axiom/task-1", "state": "conflict", "escalated": true}]`
+			if err := os.WriteFile(path, []byte(conflicted), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			q, err := integration.Open(path, dir, integration.Config{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.esc.ID, tt.esc.TaskID, tt.esc.Kind = "esc-001", "task-1", KindConflict
+
+			// Act
+			QueueHandler(q)(tt.esc)
+
+			// Assert
+			e, err := q.Get("task-1")
+			if tt.wantGone {
+				if !errors.Is(err, integration.ErrNotFound) {
+					t.Errorf("Get() = %+v, %v; want the entry removed", e, err)
+				}
+				return
+			}
+			if err != nil || e.State != tt.wantState {
+				t.Errorf("Get() = %+v, %v; want state %s", e, err, tt.wantState)
+			}
+		})
+	}
+}
+
+// conflictingTask makes a repository where axiom/task-1 conflicts with main and
+// returns it with a function that resolves the conflict on the task branch.
+func conflictingTask(t *testing.T) (string, func()) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := gitcmd.Run(context.Background(), dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "small.go"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "-A")
+		git("commit", "-q", "-m", content)
+	}
+
+	git("init", "-q", "-b", "main")
+	commit("x\n")
+	git("checkout", "-q", "-b", "axiom/task-1")
+	commit("theirs\n")
+	git("checkout", "-q", "main")
+	commit("ours\n")
+	return dir, func() {
+		git("checkout", "-q", "axiom/task-1")
+		git("reset", "-q", "--hard", "main")
+		commit("resolved\n")
+		git("checkout", "-q", "main")
+	}
+}
+
+func TestQueueHandler_MergesResolvedConflict(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		close func(in *Inbox, id string) error
+	}{
+		{"approve", Config{}, func(in *Inbox, id string) error {
+			_, err := in.Answer(id, Approve, "")
+			return err
+		}},
+		{"retry", Config{Action: ActionRetry, Timeout: 1}, func(in *Inbox, _ string) error {
+			advance(in, time.Second)
+			_, err := in.Expire()
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			dir, resolve := conflictingTask(t)
+			in, _ := openInbox(t, tt.cfg)
+			q, err := integration.Open(filepath.Join(t.TempDir(), "queue.json"), dir, integration.Config{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q.SetEscalator(in.Escalator(nil))
+			in.OnClose(QueueHandler(q))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go q.Run(ctx)
+			if err := q.Enqueue("task-1", "axiom/task-1", nil); err != nil {
+				t.Fatal(err)
+			}
+			waitState(t, q, integration.StateConflict)
+			pending := in.Pending()
+			if len(pending) != 1 {
+				t.Fatalf("got %d escalations, want the conflict raised", len(pending))
+			}
+			resolve()
+
+			// Act
+			err = tt.close(in, pending[0].ID)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("close escalation: %v", err)
+			}
+			waitState(t, q, integration.StateMerged)
+		})
+	}
+}
+
+// waitState waits for task-1's queue entry to reach want.
+func waitState(t *testing.T, q *integration.Queue, want integration.State) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		e, err := q.Get("task-1")
+		if err == nil && e.State == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Get() = %+v, %v; want state %s", e, err, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConflict(t *testing.T) {
+	// Arrange
+	entry := integration.Entry{
+		TaskID:    "task-7",
+		Branch:    "axiom/task-7",
+		Error:     "resolver gave up",
+		Conflicts: []string{"auth.go"},
+		Report: &integration.ConflictReport{
+			Level: integration.LevelMedium,
+			Files: []integration.FileConflict{{Path: "auth.go", Kind: integration.KindSemantic, Level: integration.LevelMedium}},
+		},
+		ResolverAttempts: 3,
+	}
+
+	// Act
+	e := Conflict(entry, ".workspaces/task-7")
+
+	// Assert
+	if e.Kind != KindConflict || e.Reason != "resolver gave up" || e.Level != "medium" || len(e.Options) == 0 {
+		t.Errorf("Conflict() = %+v", e)
+	}
+	for _, want := range []string{"axiom/task-7", "auth.go: semantic (medium)", "tried 3 time(s)"} {
+		if !strings.Contains(e.Context, want) {
+			t.Errorf("context %q lacks %q", e.Context, want)
+		}
+	}
+}
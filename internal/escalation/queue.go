@@ -0,0 +1,90 @@
+package escalation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/integration"
+)
+
+// Options offered on conflict escalations.
+var conflictOptions = []string{
+	"Approve after resolving the conflict on the task branch to merge it again",
+	"Reject to drop the merge; the task stays pending",
+}
+
+// Conflict builds the escalation for an integration entry whose conflict needs a
+// human. workspace is the task's worktree, where the human resolves it.
+func Conflict(e integration.Entry, workspace string) Escalation {
+	esc := Escalation{
+		TaskID:    e.TaskID,
+		Kind:      KindConflict,
+		Reason:    e.Error,
+		Options:   conflictOptions,
+		Files:     e.Conflicts,
+		Workspace: workspace,
+	}
+	if e.Report == nil {
+		return esc
+	}
+
+	esc.Level = string(e.Report.Level)
+	lines := []string{fmt.Sprintf("Merging %s into the target conflicts:", e.Branch)}
+	for _, f := range e.Report.Files {
+		line := fmt.Sprintf("- %s: %s (%s)", f.Path, f.Kind, f.Level)
+		if f.Resolved {
+			line += ", resolved automatically"
+		}
+		lines = append(lines, line)
+	}
+	if e.ResolverAttempts > 0 {
+		lines = append(lines, fmt.Sprintf("The resolver agent tried %d time(s).", e.ResolverAttempts))
+	}
+	esc.Context = strings.Join(lines, "\n")
+	return esc
+}
+
+// Escalator returns an integration.Escalator that raises queue conflicts in the
+// inbox. workspace maps a task ID to its worktree and may be nil.
+func (in *Inbox) Escalator(workspace func(taskID string) string) integration.Escalator {
+	return func(ctx context.Context, e integration.Entry) {
+		dir := ""
+		if workspace != nil {
+			dir = workspace(e.TaskID)
+		}
+		if _, err := in.Raise(ctx, Conflict(e, dir)); err != nil {
+			log.Printf("[WARN] escalate %s: %v", e.TaskID, err)
+		}
+	}
+}
+
+// QueueHandler carries closed conflict escalations back to q. Approving,
+// responding or retrying queues the task again, which wakes q.Run to merge it;
+// rejecting or skipping drops it from the queue, and deferring sets it aside.
+// A pause leaves the entry waiting.
+func QueueHandler(q *integration.Queue) Handler {
+	return func(esc Escalation) {
+		if esc.Kind != KindConflict {
+			return
+		}
+		entry, err := q.Get(esc.TaskID)
+		if err != nil {
+			log.Printf("[WARN] escalation %s: %v", esc.ID, err)
+			return
+		}
+
+		switch {
+		case esc.Status == StatusApproved || esc.Status == StatusResponded || esc.Action == ActionRetry:
+			err = q.Enqueue(entry.TaskID, entry.Branch, entry.Deps)
+		case esc.Status == StatusRejected || esc.Action == ActionSkip:
+			err = q.Remove(entry.TaskID)
+		case esc.Action == ActionDefer:
+			err = q.Defer(entry.TaskID, fmt.Sprintf("escalation %s expired unanswered", esc.ID))
+		}
+		if err != nil {
+			log.Printf("[WARN] escalation %s: %v", esc.ID, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Package gitcmd runs git commands for the packages that work on worktrees:
+// workspaces, integration and verification.
+package gitcmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Run runs git in dir and returns its trimmed stdout. A failure is reported with
+// git's stderr, or its stdout when stderr is empty (e.g. merge conflicts).
+func Run(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// OK runs git in dir and reports whether it exited zero, for predicates such as
+// merge-base --is-ancestor. Errors other than a non-zero exit are returned.
+func OK(ctx context.Context, dir string, args ...string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+	if _, ok := err.(*exec.ExitError); ok {
+		return false, nil
+	}
+	return false, fmt.Errorf("git %s: %w", args[0], err)
+}
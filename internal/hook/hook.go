@@ -0,0 +1,138 @@
+// Package hook runs the user's event scripts in .axiom/hooks (docs/12-hooks.md).
+// Hooks never block AXIOM: failures and timeouts are logged, not returned to the workflow.
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/deligoez/axiom/internal/procenv"
+)
+
+// Defaults applied to zero-valued Hook fields.
+const (
+	DefaultTimeout = 30 * time.Second
+	GracePeriod    = 5 * time.Second
+)
+
+// LogFile is the hook output log inside the .axiom directory.
+const LogFile = "logs/hooks.jsonl"
+
+// logMu serializes appends to hook logs.
+var logMu sync.Mutex
+
+// Path returns the default script for the named hook, e.g. .axiom/hooks/on-escalation.sh.
+func Path(axiomDir, name string) string {
+	return filepath.Join(axiomDir, "hooks", name+".sh")
+}
+
+// Hook is one event script.
+type Hook struct {
+	// Name is the event, e.g. "on-escalation".
+	Name string
+	// Script is the executable to run. A missing script is not an error.
+	Script string
+	// Dir is the working directory, the project root.
+	Dir string
+	// Timeout limits the run; the script gets SIGTERM, then SIGKILL after GracePeriod.
+	Timeout time.Duration
+	// LogPath receives one JSON line per run. Empty disables the log.
+	LogPath string
+}
+
+// Record is one line of the hook log.
+type Record struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Hook       string    `json:"hook"`
+	Script     string    `json:"script"`
+	ExitCode   int       `json:"exitCode"`
+	DurationMs int64     `json:"durationMs"`
+	Stdout     string    `json:"stdout,omitempty"`
+	Stderr     string    `json:"stderr,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Run executes the script with env added to AXIOM's environment. It reports
+// whether the script ran; a missing script does not run and is not logged.
+func (h Hook) Run(ctx context.Context, env map[string]string) (bool, error) {
+	if h.Script == "" {
+		return false, nil
+	}
+	if _, err := os.Stat(h.Script); os.IsNotExist(err) {
+		return false, nil
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.Script)
+	cmd.Dir = h.Dir
+	cmd.Env = procenv.With(env)
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = GracePeriod
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	rec := Record{
+		Timestamp:  start.UTC(),
+		Hook:       h.Name,
+		Script:     h.Script,
+		ExitCode:   cmd.ProcessState.ExitCode(),
+		DurationMs: time.Since(start).Milliseconds(),
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("%s timed out after %s", h.Name, timeout)
+	} else if err != nil {
+		err = fmt.Errorf("%s: %w", h.Name, err)
+	}
+	if err != nil {
+		rec.Error = err.Error()
+		log.Printf("[WARN] hook %v", err)
+	}
+	if logErr := h.log(rec); logErr != nil {
+		log.Printf("[WARN] hook log: %v", logErr)
+	}
+	return true, err
+}
+
+// log appends rec to the hook log.
+func (h Hook) log(rec Record) error {
+	if h.LogPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(h.LogPath), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.LogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return errors.Join(err, f.Close())
+}
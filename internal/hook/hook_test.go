@@ -0,0 +1,125 @@
+package hook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeScript writes an executable shell script into dir.
+func writeScript(t *testing.T, dir, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readLog returns the records in a hook log.
+func readLog(t *testing.T, path string) []Record {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestRun_PassesEnvAndLogsOutput(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	h := Hook{
+		Name:    "on-escalation",
+		Script:  writeScript(t, dir, `echo "$AXIOM_TASK_ID in $(pwd)"`),
+		Dir:     dir,
+		LogPath: filepath.Join(dir, LogFile),
+	}
+
+	// Act
+	ran, err := h.Run(context.Background(), map[string]string{"AXIOM_TASK_ID": "task-042"})
+
+	// Assert
+	if !ran || err != nil {
+		t.Fatalf("Run() = %v, %v; want true, nil", ran, err)
+	}
+	records := readLog(t, h.LogPath)
+	if len(records) != 1 {
+		t.Fatalf("got %d log records, want 1", len(records))
+	}
+	if records[0].Hook != "on-escalation" || records[0].ExitCode != 0 {
+		t.Errorf("record = %+v", records[0])
+	}
+	if !strings.HasPrefix(records[0].Stdout, "task-042 in ") {
+		t.Errorf("stdout = %q", records[0].Stdout)
+	}
+}
+
+func TestRun_MissingScriptDoesNotRun(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	h := Hook{Name: "on-escalation", Script: Path(dir, "on-escalation"), LogPath: filepath.Join(dir, LogFile)}
+
+	// Act
+	ran, err := h.Run(context.Background(), nil)
+
+	// Assert
+	if ran || err != nil {
+		t.Fatalf("Run() = %v, %v; want false, nil", ran, err)
+	}
+	if _, err := os.Stat(h.LogPath); !os.IsNotExist(err) {
+		t.Errorf("log written for a missing script: %v", err)
+	}
+}
+
+func TestRun_FailureIsLogged(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	h := Hook{Name: "on-error", Script: writeScript(t, dir, "echo oops >&2\nexit 3"), LogPath: filepath.Join(dir, LogFile)}
+
+	// Act
+	ran, err := h.Run(context.Background(), nil)
+
+	// Assert
+	if !ran || err == nil {
+		t.Fatalf("Run() = %v, %v; want true and an error", ran, err)
+	}
+	rec := readLog(t, h.LogPath)[0]
+	if rec.ExitCode != 3 || strings.TrimSpace(rec.Stderr) != "oops" || rec.Error == "" {
+		t.Errorf("record = %+v", rec)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	h := Hook{Name: "on-escalation", Script: writeScript(t, dir, "exec sleep 10"), Timeout: 100 * time.Millisecond}
+
+	// Act
+	start := time.Now()
+	_, err := h.Run(context.Background(), nil)
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("Run() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Run() took %s after the timeout", elapsed)
+	}
+}
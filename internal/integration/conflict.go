@@ -0,0 +1,395 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+	"github.com/deligoez/axiom/internal/pathglob"
+)
+
+// Level is how hard a conflict is to resolve (docs/06-integration.md).
+type Level string
+
+const (
+	// LevelSimple conflicts are resolved automatically.
+	LevelSimple Level = "simple"
+	// LevelMedium conflicts go to the resolver agent.
+	LevelMedium Level = "medium"
+	// LevelComplex conflicts need a human.
+	LevelComplex Level = "complex"
+)
+
+// rank orders levels from simple to complex.
+func (l Level) rank() int {
+	switch l {
+	case LevelComplex:
+		return 2
+	case LevelMedium:
+		return 1
+	}
+	return 0
+}
+
+// Kind is what a conflicted file's hunks look like.
+type Kind string
+
+const (
+	// KindWhitespace hunks differ only in whitespace; the target's side is kept.
+	KindWhitespace Kind = "whitespace"
+	// KindImports hunks only touch import lines; both sides' imports are kept.
+	KindImports Kind = "imports"
+	// KindDisjoint hunks have both sides editing different lines; both edits are applied.
+	KindDisjoint Kind = "disjoint"
+	// KindRegenerable files are lockfiles or generated files rebuilt by a command.
+	KindRegenerable Kind = "regenerable"
+	// KindSemantic hunks have both sides changing the same lines.
+	KindSemantic Kind = "semantic"
+	// KindDeleted files were deleted or renamed on one side and changed on the other.
+	KindDeleted Kind = "deleted"
+	// KindBinary files cannot be merged line by line.
+	KindBinary Kind = "binary"
+)
+
+// complexLines is the size from which a semantic conflict is complex rather than medium.
+const complexLines = 20
+
+// DefaultRegenerate maps lockfiles to the commands that rebuild them.
+var DefaultRegenerate = map[string]string{
+	"go.sum":            "go mod tidy",
+	"package-lock.json": "npm install --package-lock-only",
+	"yarn.lock":         "yarn install --mode update-lockfile",
+	"pnpm-lock.yaml":    "pnpm install --lockfile-only",
+	"Cargo.lock":        "cargo generate-lockfile",
+	"composer.lock":     "composer update --lock",
+	"Gemfile.lock":      "bundle lock",
+	"poetry.lock":       "poetry lock --no-update",
+}
+
+// FileConflict is the classification of one conflicted file.
+type FileConflict struct {
+	Path  string `json:"path"`
+	Kind  Kind   `json:"kind"`
+	Level Level  `json:"level"`
+
+	// Hunks and Lines measure the conflicted regions.
+	Hunks int `json:"hunks,omitempty"`
+	Lines int `json:"lines,omitempty"`
+
+	// Command regenerates a KindRegenerable file.
+	Command string `json:"command,omitempty"`
+
+	Resolved bool   `json:"resolved"`
+	Detail   string `json:"detail,omitempty"`
+
+	// resolution is the merged content of a file whose hunks all resolve.
+	resolution []byte
+}
+
+// ConflictReport classifies every conflicted file of a failed merge.
+type ConflictReport struct {
+	// Level is the hardest level among the files.
+	Level Level          `json:"level"`
+	Files []FileConflict `json:"files"`
+}
+
+// Resolved reports whether every file was resolved automatically.
+func (r *ConflictReport) Resolved() bool {
+	for _, f := range r.Files {
+		if !f.Resolved {
+			return false
+		}
+	}
+	return len(r.Files) > 0
+}
+
+// Paths returns the conflicted file paths.
+func (r *ConflictReport) Paths() []string {
+	paths := make([]string, len(r.Files))
+	for i, f := range r.Files {
+		paths[i] = f.Path
+	}
+	return paths
+}
+
+// Classify inspects the unmerged files of the merge or rebase in progress in dir.
+// regenerate maps path patterns to commands that rebuild the file; DefaultRegenerate
+// applies for patterns it does not override.
+func Classify(ctx context.Context, dir string, regenerate map[string]string) (*ConflictReport, error) {
+	paths := unmerged(ctx, dir)
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no unmerged files in %s", dir)
+	}
+
+	report := &ConflictReport{Level: LevelSimple}
+	for _, path := range paths {
+		f := classifyFile(ctx, dir, path, regenerators(regenerate))
+		if f.Level.rank() > report.Level.rank() {
+			report.Level = f.Level
+		}
+		report.Files = append(report.Files, f)
+	}
+	return report, nil
+}
+
+// regenerators merges the configured commands over the defaults.
+func regenerators(configured map[string]string) map[string]string {
+	all := make(map[string]string, len(DefaultRegenerate)+len(configured))
+	for pattern, cmd := range DefaultRegenerate {
+		all[pattern] = cmd
+	}
+	for pattern, cmd := range configured {
+		all[pattern] = cmd
+	}
+	return all
+}
+
+// classifyFile classifies one unmerged file.
+func classifyFile(ctx context.Context, dir, path string, regenerate map[string]string) FileConflict {
+	f := FileConflict{Path: path}
+
+	stages, err := gitcmd.Run(ctx, dir, "ls-files", "-u", "--", path)
+	if err != nil {
+		return complexFile(f, KindSemantic, err.Error())
+	}
+	if !strings.Contains(stages, " 2\t") || !strings.Contains(stages, " 3\t") {
+		return complexFile(f, KindDeleted, "deleted or renamed on one side")
+	}
+
+	patterns := make([]string, 0, len(regenerate))
+	for pattern := range regenerate {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if pathglob.Match(pattern, path) {
+			f.Kind, f.Level, f.Command = KindRegenerable, LevelSimple, regenerate[pattern]
+			return f
+		}
+	}
+
+	// Rewrite the markers with the base version between them.
+	if _, err := gitcmd.Run(ctx, dir, "checkout", "--conflict=diff3", "--", path); err != nil {
+		return complexFile(f, KindBinary, err.Error())
+	}
+	content, err := os.ReadFile(filepath.Join(dir, path))
+	if err != nil {
+		return complexFile(f, KindSemantic, err.Error())
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return complexFile(f, KindBinary, "binary content")
+	}
+
+	segments := parseConflicts(string(content))
+	var merged strings.Builder
+	f.Kind, f.Level = KindWhitespace, LevelSimple
+	for _, seg := range segments {
+		if seg.hunk == nil {
+			merged.WriteString(strings.Join(seg.text, ""))
+			continue
+		}
+		h := seg.hunk
+		f.Hunks++
+		f.Lines += max(len(h.ours), len(h.theirs))
+
+		kind, resolution := classifyHunk(h, path)
+		if kindRank(kind) > kindRank(f.Kind) {
+			f.Kind = kind
+		}
+		merged.WriteString(strings.Join(resolution, ""))
+	}
+	if f.Hunks == 0 {
+		return complexFile(f, KindSemantic, "no conflict markers")
+	}
+
+	if f.Kind == KindSemantic {
+		f.Level = LevelMedium
+		if f.Lines >= complexLines {
+			f.Level = LevelComplex
+		}
+		return f
+	}
+	f.resolution = []byte(merged.String())
+	return f
+}
+
+// complexFile marks f as needing a human.
+func complexFile(f FileConflict, kind Kind, detail string) FileConflict {
+	f.Kind, f.Level, f.Detail = kind, LevelComplex, detail
+	return f
+}
+
+// kindRank orders hunk kinds from harmless to semantic.
+func kindRank(k Kind) int {
+	switch k {
+	case KindImports:
+		return 1
+	case KindDisjoint:
+		return 2
+	case KindSemantic:
+		return 3
+	}
+	return 0
+}
+
+// hunk is one conflicted region, each side as lines including their newlines.
+type hunk struct {
+	ours, base, theirs []string
+	hasBase            bool
+}
+
+// segment is either unconflicted text or a hunk.
+type segment struct {
+	text []string
+	hunk *hunk
+}
+
+// parseConflicts splits diff3-style content into text and conflict hunks.
+func parseConflicts(content string) []segment {
+	var segments []segment
+	var text []string
+	var h *hunk
+	section := 0 // 1 ours, 2 base, 3 theirs
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		marker := strings.TrimRight(line, "\r\n")
+		switch {
+		case h == nil && strings.HasPrefix(marker, "<<<<<<<"):
+			if len(text) > 0 {
+				segments = append(segments, segment{text: text})
+				text = nil
+			}
+			h, section = &hunk{}, 1
+		case h != nil && section == 1 && strings.HasPrefix(marker, "|||||||"):
+			h.hasBase, section = true, 2
+		case h != nil && section != 3 && marker == "=======":
+			section = 3
+		case h != nil && section == 3 && strings.HasPrefix(marker, ">>>>>>>"):
+			segments = append(segments, segment{hunk: h})
+			h, section = nil, 0
+		case h != nil && section == 1:
+			h.ours = append(h.ours, line)
+		case h != nil && section == 2:
+			h.base = append(h.base, line)
+		case h != nil && section == 3:
+			h.theirs = append(h.theirs, line)
+		default:
+			text = append(text, line)
+		}
+	}
+	if len(text) > 0 {
+		segments = append(segments, segment{text: text})
+	}
+	return segments
+}
+
+// classifyHunk returns the hunk's kind and, unless it is semantic, its resolution.
+func classifyHunk(h *hunk, path string) (Kind, []string) {
+	if equalIgnoringSpace(h.ours, h.theirs, indentSensitive(path)) {
+		return KindWhitespace, h.ours
+	}
+	if onlyImports(h.ours) && onlyImports(h.theirs) && onlyImports(h.base) {
+		return KindImports, mergeImports(h)
+	}
+	if h.hasBase {
+		if merged, ok := merge3(h.base, h.ours, h.theirs); ok {
+			return KindDisjoint, merged
+		}
+	}
+	return KindSemantic, nil
+}
+
+// indentSensitive reports files where leading whitespace carries meaning.
+func indentSensitive(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".py", ".yaml", ".yml", ".mk":
+		return true
+	}
+	return filepath.Base(path) == "Makefile"
+}
+
+// equalIgnoringSpace compares two sides ignoring blank lines and whitespace runs,
+// keeping leading indentation when keepIndent is set.
+func equalIgnoringSpace(a, b []string, keepIndent bool) bool {
+	normalize := func(lines []string) []string {
+		var out []string
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			norm := strings.Join(fields, " ")
+			if keepIndent {
+				norm = line[:len(line)-len(strings.TrimLeft(line, " \t"))] + norm
+			}
+			out = append(out, norm)
+		}
+		return out
+	}
+	na, nb := normalize(a), normalize(b)
+	if len(na) != len(nb) {
+		return false
+	}
+	for i := range na {
+		if na[i] != nb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// importLine matches import statements in common languages, including lines inside a Go import block.
+var importLine = regexp.MustCompile(`^\s*(` +
+	`import\s.*|from\s+\S+\s+import\s.*|` + // Go, JS/TS, Java, Kotlin, Python
+	`(\w+\s+|_\s+|\.\s+)?"[^"]+"\s*|` + // Go import block entry
+	`use\s+[\w\\:{}, ]+;\s*|` + // PHP, Rust
+	`#include\s.*|` +
+	`(const|let|var)\s+[\w{}, ]+\s*=\s*require\(.*\);?\s*` +
+	`)$`)
+
+// onlyImports reports whether every non-blank line is an import.
+func onlyImports(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && !importLine.MatchString(strings.TrimRight(line, "\r\n")) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeImports keeps the target's imports, drops those the task removed from
+// the base, and appends those the task added.
+func mergeImports(h *hunk) []string {
+	key := func(line string) string { return strings.TrimSpace(line) }
+	set := func(lines []string) map[string]bool {
+		s := make(map[string]bool, len(lines))
+		for _, line := range lines {
+			s[key(line)] = true
+		}
+		return s
+	}
+	base, ours, theirs := set(h.base), set(h.ours), set(h.theirs)
+
+	var merged []string
+	for _, line := range h.ours {
+		if k := key(line); k != "" && base[k] && !theirs[k] {
+			continue
+		}
+		merged = append(merged, line)
+	}
+	for _, line := range h.theirs {
+		if k := key(line); k != "" && !ours[k] && !base[k] {
+			merged = append(merged, line)
+		}
+	}
+	return merged
+}
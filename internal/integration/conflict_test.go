@@ -0,0 +1,219 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyHunk(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		h    hunk
+		want Kind
+		res  string
+	}{
+		{
+			name: "whitespace",
+			path: "main.go",
+			h:    hunk{ours: []string{"x := 1\n"}, theirs: []string{"x  :=  1\n", "\n"}},
+			want: KindWhitespace,
+			res:  "x := 1\n",
+		},
+		{
+			name: "indentation matters in python",
+			path: "app.py",
+			h:    hunk{ours: []string{"    return x\n"}, theirs: []string{"return x\n"}, base: []string{"  return x\n"}, hasBase: true},
+			want: KindSemantic,
+		},
+		{
+			name: "go imports",
+			path: "main.go",
+			h: hunk{
+				base:    []string{"\t\"fmt\"\n", "\t\"os\"\n"},
+				ours:    []string{"\t\"fmt\"\n", "\t\"os\"\n", "\t\"strings\"\n"},
+				theirs:  []string{"\t\"fmt\"\n", "\t\"time\"\n"},
+				hasBase: true,
+			},
+			want: KindImports,
+			res:  "\t\"fmt\"\n\t\"strings\"\n\t\"time\"\n",
+		},
+		{
+			name: "adjacent edits",
+			path: "main.go",
+			h: hunk{
+				base:    []string{"a := 1\n", "b := 2\n"},
+				ours:    []string{"a := 10\n", "b := 2\n"},
+				theirs:  []string{"a := 1\n", "b := 20\n"},
+				hasBase: true,
+			},
+			want: KindDisjoint,
+			res:  "a := 10\nb := 20\n",
+		},
+		{
+			name: "same line",
+			path: "main.go",
+			h: hunk{
+				base:    []string{"a := 1\n"},
+				ours:    []string{"a := 2\n"},
+				theirs:  []string{"a := 3\n"},
+				hasBase: true,
+			},
+			want: KindSemantic,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, res := classifyHunk(&tt.h, tt.path)
+			if kind != tt.want {
+				t.Fatalf("got %s, want %s", kind, tt.want)
+			}
+			if got := strings.Join(res, ""); tt.res != "" && got != tt.res {
+				t.Errorf("got resolution %q, want %q", got, tt.res)
+			}
+		})
+	}
+}
+
+func TestParseConflicts(t *testing.T) {
+	content := "head\n<<<<<<< ours\nA\n||||||| base\nB\n=======\nC\n>>>>>>> theirs\ntail\n"
+
+	segments := parseConflicts(content)
+
+	if len(segments) != 3 || segments[1].hunk == nil {
+		t.Fatalf("unexpected segments: %+v", segments)
+	}
+	h := segments[1].hunk
+	if !h.hasBase || h.ours[0] != "A\n" || h.base[0] != "B\n" || h.theirs[0] != "C\n" {
+		t.Errorf("unexpected hunk: %+v", h)
+	}
+}
+
+// conflictRepo makes main and axiom/task change the given files from a common base.
+func conflictRepo(t *testing.T, base, target, task map[string]string) (string, string) {
+	t.Helper()
+	dir := initRepo(t)
+	for name, content := range base {
+		writeFile(t, dir, name, content)
+	}
+	run(t, dir, "add", "-A")
+	run(t, dir, "commit", "-q", "-m", "base")
+	branch := taskBranch(t, dir, "task", task)
+	for name, content := range target {
+		if content == "" {
+			run(t, dir, "rm", "-q", name)
+			continue
+		}
+		writeFile(t, dir, name, content)
+	}
+	run(t, dir, "add", "-A")
+	run(t, dir, "commit", "-q", "-m", "target")
+	return dir, branch
+}
+
+func TestQueue_AutoResolvesSimpleConflicts(t *testing.T) {
+	for _, strategy := range []Strategy{StrategyMerge, StrategyRebase} {
+		t.Run(string(strategy), func(t *testing.T) {
+			dir, branch := conflictRepo(t,
+				map[string]string{"calc.go": "package calc\n\nconst A = 1\nconst B = 2\n", "deps.lock": "v1\n"},
+				map[string]string{"calc.go": "package calc\n\nconst A = 10\nconst B = 2\n", "deps.lock": "v2\n"},
+				map[string]string{"calc.go": "package calc\n\nconst A = 1\nconst B = 20\n", "deps.lock": "v3\n"},
+			)
+			q := openQueue(t, dir, Config{Strategy: strategy, Regenerate: map[string]string{"*.lock": "echo regenerated > deps.lock"}}, nil)
+			if err := q.Enqueue("task", branch, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := q.Process(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			e := state(t, q, "task")
+			if e.State != StateMerged || e.Report == nil || !e.Report.Resolved() {
+				t.Fatalf("got %+v, want merged with a resolved report", e)
+			}
+			kinds := map[string]Kind{}
+			for _, f := range e.Report.Files {
+				kinds[f.Path] = f.Kind
+			}
+			if kinds["calc.go"] != KindDisjoint || kinds["deps.lock"] != KindRegenerable {
+				t.Errorf("unexpected kinds: %v", kinds)
+			}
+			if got := run(t, dir, "show", "main:calc.go"); !strings.Contains(got, "A = 10") || !strings.Contains(got, "B = 20") {
+				t.Errorf("expected both edits on main, got:\n%s", got)
+			}
+			if got := run(t, dir, "show", "main:deps.lock"); got != "regenerated" {
+				t.Errorf("expected regenerated lockfile, got %q", got)
+			}
+		})
+	}
+}
+
+func TestQueue_ReportsUnresolvableConflicts(t *testing.T) {
+	long := strings.Repeat("line\n", complexLines)
+	dir, branch := conflictRepo(t,
+		map[string]string{"small.go": "x\n", "big.go": "start\n", "gone.go": "old\n"},
+		map[string]string{"small.go": "ours\n", "big.go": "ours\n" + long, "gone.go": ""},
+		map[string]string{"small.go": "theirs\n", "big.go": "theirs\n" + strings.ToUpper(long), "gone.go": "changed\n"},
+	)
+	q := openQueue(t, dir, Config{}, nil)
+	if err := q.Enqueue("task", branch, nil); err != nil {
+		t.Fatal(err)
+	}
+	before := run(t, dir, "rev-parse", "main")
+
+	if err := q.Process(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := state(t, q, "task")
+	if e.State != StateConflict || e.Report == nil || e.Report.Level != LevelComplex {
+		t.Fatalf("got %+v, want complex conflict", e)
+	}
+	got := map[string]FileConflict{}
+	for _, f := range e.Report.Files {
+		got[f.Path] = f
+	}
+	if f := got["small.go"]; f.Kind != KindSemantic || f.Level != LevelMedium {
+		t.Errorf("small.go: %+v", f)
+	}
+	if f := got["big.go"]; f.Kind != KindSemantic || f.Level != LevelComplex {
+		t.Errorf("big.go: %+v", f)
+	}
+	if f := got["gone.go"]; f.Kind != KindDeleted || f.Level != LevelComplex {
+		t.Errorf("gone.go: %+v", f)
+	}
+	if after := run(t, dir, "rev-parse", "main"); after != before {
+		t.Error("expected main to stay put")
+	}
+}
+
+func TestQueue_AutoResolutionFailingVerificationEscalates(t *testing.T) {
+	dir, branch := conflictRepo(t,
+		map[string]string{"a.txt": "one\ntwo\n"},
+		map[string]string{"a.txt": "ONE\ntwo\n"},
+		map[string]string{"a.txt": "one\nTWO\n"},
+	)
+	q := openQueue(t, dir, Config{}, func(_ context.Context, dir string) error {
+		if data, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(data) != "ONE\nTWO\n" {
+			t.Errorf("expected verification of the resolved file, got %q", data)
+		}
+		return errors.New("tests failed")
+	})
+	if err := q.Enqueue("task", branch, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Process(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := state(t, q, "task")
+	if e.State != StateConflict || e.Report.Level != LevelMedium || !strings.Contains(e.Error, "failed verification") {
+		t.Errorf("got %+v, want escalation to medium", e)
+	}
+}
@@ -0,0 +1,14 @@
+package integration
+
+import "strings"
+
+// lines splits git output into non-empty lines.
+func lines(out string) []string {
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
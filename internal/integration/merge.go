@@ -0,0 +1,377 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// Process integrates every ready entry in queue order and returns once no entry
+// is ready. An entry is ready when all its dependencies are merged. Conflicts and
+// verification failures are recorded on the entry; the returned error reports
+// problems with the repository or the queue file.
+func (q *Queue) Process(ctx context.Context) error {
+	q.run.Lock()
+	defer q.run.Unlock()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		e, ok, err := q.next()
+		if err != nil || !ok {
+			return err
+		}
+		if err := q.integrate(ctx, e); err != nil {
+			return err
+		}
+	}
+}
+
+// Run processes the queue until ctx is done: once when it starts, for entries
+// queued by an earlier session, and again whenever Enqueue or Requeue queues one.
+// Errors are logged and the queue is tried again on the next wake.
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		if err := q.Process(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[WARN] integration: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		}
+	}
+}
+
+// next returns the first ready entry, moving entries with unmerged dependencies to waiting-deps.
+func (q *Queue) next() (Entry, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	merged := make(map[string]bool, len(q.entries))
+	for _, e := range q.entries {
+		merged[e.TaskID] = e.State == StateMerged
+	}
+
+	changed := false
+	defer func() {
+		if changed {
+			if err := q.save(); err != nil {
+				log.Printf("[WARN] %v", err)
+			}
+		}
+	}()
+	for _, e := range q.entries {
+		if e.State != StateQueued && e.State != StateWaitingDeps {
+			continue
+		}
+		var missing []string
+		for _, dep := range e.Deps {
+			if !merged[dep] {
+				missing = append(missing, dep)
+			}
+		}
+		if len(missing) == 0 {
+			return *e, true, nil
+		}
+		if e.State != StateWaitingDeps || e.Error != waitingFor(missing) {
+			e.State, e.Error = StateWaitingDeps, waitingFor(missing)
+			changed = true
+		}
+	}
+	return Entry{}, false, nil
+}
+
+// waitingFor describes the dependencies an entry is waiting for.
+func waitingFor(deps []string) string {
+	return "waiting for " + strings.Join(deps, ", ")
+}
+
+// integrate brings e's branch onto the target in a scratch worktree, verifies the
+// result and fast-forwards the target to it.
+func (q *Queue) integrate(ctx context.Context, e Entry) error {
+	if err := q.update(e.TaskID, func(e *Entry) {
+		e.State, e.Error, e.Conflicts = StateMerging, "", nil
+		e.Attempts++
+	}); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	rebaser := q.rebaser
+	q.mu.Unlock()
+	if rebaser != nil {
+		if err := rebaser(ctx, e.TaskID); err != nil {
+			return q.fail(e.TaskID, fmt.Errorf("rebase onto %s: %w", q.cfg.Target, err))
+		}
+	}
+
+	target := "refs/heads/" + q.cfg.Target
+	base, err := gitcmd.Run(ctx, q.repoDir, "rev-parse", "--verify", target)
+	if err != nil {
+		return q.fail(e.TaskID, fmt.Errorf("resolve target %s: %w", q.cfg.Target, err))
+	}
+
+	scratch, cleanup, err := q.scratch(ctx, base, e.TaskID)
+	if err != nil {
+		return q.fail(e.TaskID, err)
+	}
+	defer cleanup()
+
+	var report *ConflictReport
+	switch q.cfg.Strategy {
+	case StrategyRebase:
+		report, err = q.rebase(ctx, scratch, e.Branch, base)
+	default:
+		report, err = q.merge(ctx, scratch, e)
+	}
+	if err != nil {
+		return q.fail(e.TaskID, err)
+	}
+	if report != nil && !report.Resolved() {
+		msg := fmt.Sprintf("%s conflicts with %s in %d file(s)", report.Level, q.cfg.Target, len(report.Files))
+		return q.escalate(ctx, e, base, report, msg)
+	}
+
+	if q.verify != nil {
+		if err := q.verify(ctx, scratch); err != nil {
+			if report != nil {
+				// Automatic resolution never lands unverified.
+				report.Level = LevelMedium
+				return q.escalate(ctx, e, base, report, fmt.Sprintf("auto-resolved conflicts failed verification: %v", err))
+			}
+			return q.fail(e.TaskID, fmt.Errorf("verification failed: %w", err))
+		}
+	}
+	return q.land(ctx, e, scratch, base, report)
+}
+
+// land fast-forwards the target to the verified head of dir and marks e merged.
+func (q *Queue) land(ctx context.Context, e Entry, dir, base string, report *ConflictReport) error {
+	head, err := gitcmd.Run(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return q.fail(e.TaskID, err)
+	}
+	if err := q.advance(ctx, base, head); err != nil {
+		return q.fail(e.TaskID, err)
+	}
+
+	log.Printf("[integration] merged %s into %s at %s", e.TaskID, q.cfg.Target, head)
+	return q.update(e.TaskID, func(e *Entry) {
+		e.State, e.Commit, e.Report = StateMerged, head, report
+	})
+}
+
+// escalate hands an unresolved conflict to the resolver agent when there is one
+// and the conflict is not too hard for it, and to a human otherwise.
+func (q *Queue) escalate(ctx context.Context, e Entry, base string, report *ConflictReport, msg string) error {
+	if q.resolver != nil && report.Level.rank() <= q.cfg.ResolverLevel.rank() {
+		return q.resolveWithAgent(ctx, e, base, report)
+	}
+	return q.toHuman(ctx, e.TaskID, report, msg)
+}
+
+// toHuman records a conflict that is waiting for a human on taskID's entry
+// and passes the entry to the escalator, if any.
+func (q *Queue) toHuman(ctx context.Context, taskID string, report *ConflictReport, msg string) error {
+	log.Printf("[integration] %s needs a human: %s (%s)", taskID, msg, strings.Join(report.Paths(), ", "))
+	var escalated Entry
+	err := q.update(taskID, func(e *Entry) {
+		e.State, e.Error, e.Escalated = StateConflict, msg, true
+		e.Conflicts, e.Report = report.Paths(), report
+		escalated = *e
+	})
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	escalator := q.escalator
+	q.mu.Unlock()
+	if escalator != nil {
+		escalator(ctx, escalated)
+	}
+	return nil
+}
+
+// fail records err on taskID's entry. Only a failure to persist the queue is returned.
+func (q *Queue) fail(taskID string, err error) error {
+	log.Printf("[integration] %s failed: %v", taskID, err)
+	return q.update(taskID, func(e *Entry) {
+		e.State, e.Error = StateFailed, err.Error()
+	})
+}
+
+// scratch adds a detached worktree at commit for integrating taskID.
+func (q *Queue) scratch(ctx context.Context, commit, taskID string) (string, func(), error) {
+	if err := os.MkdirAll(q.cfg.ScratchDir, 0o755); err != nil {
+		return "", nil, err
+	}
+	dir, err := os.MkdirTemp(q.cfg.ScratchDir, taskID+"-")
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := gitcmd.Run(ctx, q.repoDir, "worktree", "add", "--detach", dir, commit); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("create integration worktree: %w", err)
+	}
+	cleanup := func() {
+		if _, err := gitcmd.Run(context.Background(), q.repoDir, "worktree", "remove", "--force", dir); err != nil {
+			log.Printf("[WARN] %v", err)
+			_ = os.RemoveAll(dir)
+			_, _ = gitcmd.Run(context.Background(), q.repoDir, "worktree", "prune")
+		}
+	}
+	return dir, cleanup, nil
+}
+
+// merge merges e's branch into the scratch worktree with a merge commit. Conflicts
+// are classified and the simple ones resolved; when any remain the merge is aborted.
+// The report is nil when nothing conflicted.
+func (q *Queue) merge(ctx context.Context, dir string, e Entry) (*ConflictReport, error) {
+	msg := fmt.Sprintf("Merge %s (%s)", e.Branch, e.TaskID)
+	_, err := gitcmd.Run(ctx, dir, "merge", "--no-ff", "--no-edit", "-m", msg, e.Branch)
+	if err == nil {
+		return nil, nil
+	}
+	if len(unmerged(ctx, dir)) == 0 {
+		return nil, err
+	}
+
+	report, err := q.resolve(ctx, dir)
+	if err != nil || !report.Resolved() {
+		_, _ = gitcmd.Run(ctx, dir, "merge", "--abort")
+		return report, err
+	}
+	if _, err := gitcmd.Run(ctx, dir, "commit", "--no-edit"); err != nil {
+		return report, fmt.Errorf("commit resolved merge: %w", err)
+	}
+	return report, nil
+}
+
+// rebase replays branch onto base. The branch itself is moved, in the task's own
+// worktree when it has one, and the scratch worktree is left at the rebased head.
+// Each conflicting commit is classified and its simple conflicts resolved; when any
+// remain the rebase is aborted. The report, nil without conflicts, covers every commit.
+func (q *Queue) rebase(ctx context.Context, scratch, branch, base string) (*ConflictReport, error) {
+	dir, err := q.worktreeFor(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		if _, err := gitcmd.Run(ctx, scratch, "checkout", "-q", branch); err != nil {
+			return nil, err
+		}
+		dir = scratch
+	}
+
+	var report *ConflictReport
+	_, err = gitcmd.Run(ctx, dir, "rebase", base)
+	for err != nil {
+		if len(unmerged(ctx, dir)) == 0 {
+			_, _ = gitcmd.Run(ctx, dir, "rebase", "--abort")
+			return report, err
+		}
+		step, resolveErr := q.resolve(ctx, dir)
+		report = combine(report, step)
+		if resolveErr != nil || !step.Resolved() {
+			_, _ = gitcmd.Run(ctx, dir, "rebase", "--abort")
+			return report, resolveErr
+		}
+		_, err = gitcmd.Run(ctx, dir, "-c", "core.editor=true", "rebase", "--continue")
+	}
+
+	if dir != scratch {
+		if _, err := gitcmd.Run(ctx, scratch, "checkout", "-q", "--detach", branch); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// resolve classifies the conflicts in dir and resolves the simple ones.
+func (q *Queue) resolve(ctx context.Context, dir string) (*ConflictReport, error) {
+	report, err := Classify(ctx, dir, q.cfg.Regenerate)
+	if err != nil {
+		return nil, err
+	}
+	if report.Level == LevelSimple {
+		Resolve(ctx, dir, report)
+	}
+	return report, nil
+}
+
+// combine adds the files of a rebase step's report to the running report.
+func combine(total, step *ConflictReport) *ConflictReport {
+	if total == nil {
+		return step
+	}
+	total.Files = append(total.Files, step.Files...)
+	if step.Level.rank() > total.Level.rank() {
+		total.Level = step.Level
+	}
+	return total
+}
+
+// unmerged lists files with unresolved conflicts in dir.
+func unmerged(ctx context.Context, dir string) []string {
+	out, err := gitcmd.Run(ctx, dir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil
+	}
+	return lines(out)
+}
+
+// advance fast-forwards the target from base to head. When the target is checked
+// out, its worktree is fast-forwarded too; otherwise only the ref moves, and only
+// if it still points at base.
+func (q *Queue) advance(ctx context.Context, base, head string) error {
+	dir, err := q.worktreeFor(ctx, q.cfg.Target)
+	if err != nil {
+		return err
+	}
+	if dir != "" {
+		current, err := gitcmd.Run(ctx, dir, "rev-parse", "HEAD")
+		if err != nil {
+			return err
+		}
+		if current != base {
+			return fmt.Errorf("%s moved during integration", q.cfg.Target)
+		}
+		if _, err := gitcmd.Run(ctx, dir, "merge", "--ff-only", "-q", head); err != nil {
+			return fmt.Errorf("fast-forward %s: %w", q.cfg.Target, err)
+		}
+		return nil
+	}
+	if _, err := gitcmd.Run(ctx, q.repoDir, "update-ref", "refs/heads/"+q.cfg.Target, head, base); err != nil {
+		return fmt.Errorf("fast-forward %s: %w", q.cfg.Target, err)
+	}
+	return nil
+}
+
+// worktreeFor returns the worktree that has branch checked out, or "".
+func (q *Queue) worktreeFor(ctx context.Context, branch string) (string, error) {
+	out, err := gitcmd.Run(ctx, q.repoDir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	var path string
+	for _, line := range strings.Split(out, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "worktree":
+			path = filepath.Clean(value)
+		case "branch":
+			if value == "refs/heads/"+branch {
+				return path, nil
+			}
+		}
+	}
+	return "", nil
+}
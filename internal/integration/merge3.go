@@ -0,0 +1,100 @@
+package integration
+
+import "slices"
+
+// change replaces base[start:end] with lines.
+type change struct {
+	start, end int
+	lines      []string
+}
+
+// merge3 applies both sides' changes to base when they touch different lines.
+// It reports false when the changes overlap or insert at the same place.
+func merge3(base, ours, theirs []string) ([]string, bool) {
+	a, b := changes(base, ours), changes(base, theirs)
+
+	all := append([]change{}, a...)
+	for _, cb := range b {
+		dup := false
+		for _, ca := range a {
+			if ca.start == cb.start && ca.end == cb.end && slices.Equal(ca.lines, cb.lines) {
+				dup = true
+				break
+			}
+			if overlaps(ca, cb) {
+				return nil, false
+			}
+		}
+		if !dup {
+			all = append(all, cb)
+		}
+	}
+	slices.SortStableFunc(all, func(x, y change) int { return x.start - y.start })
+
+	var merged []string
+	pos := 0
+	for _, c := range all {
+		merged = append(merged, base[pos:c.start]...)
+		merged = append(merged, c.lines...)
+		pos = c.end
+	}
+	return append(merged, base[pos:]...), true
+}
+
+// overlaps reports whether two changes touch the same base lines or insert at the same point.
+func overlaps(a, b change) bool {
+	if a.start == b.start {
+		return true
+	}
+	return a.start < b.end && b.start < a.end
+}
+
+// changes returns the edits turning base into side, from a longest common subsequence.
+func changes(base, side []string) []change {
+	n, m := len(base), len(side)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if base[i] == side[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var result []change
+	var cur *change
+	flush := func() {
+		if cur != nil {
+			result = append(result, *cur)
+			cur = nil
+		}
+	}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && base[i] == side[j]:
+			flush()
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			if cur == nil {
+				cur = &change{start: i, end: i}
+			}
+			cur.lines = append(cur.lines, side[j])
+			j++
+		default:
+			if cur == nil {
+				cur = &change{start: i, end: i}
+			}
+			i++
+			cur.end = i
+		}
+	}
+	flush()
+	return result
+}
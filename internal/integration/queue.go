@@ -0,0 +1,385 @@
+// Package integration merges finished task branches into the target branch
+// through a serialized, persistent queue that respects task dependencies.
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Defaults applied to zero-valued Config fields.
+const (
+	DefaultTarget          = "main"
+	DefaultStrategy        = StrategyMerge
+	DefaultScratchDir      = ".axiom/integration/scratch"
+	DefaultConflictRetries = 3
+	DefaultResolverLevel   = LevelMedium
+)
+
+// Path returns the queue file inside the .axiom directory.
+func Path(axiomDir string) string {
+	return filepath.Join(axiomDir, "integration", "queue.json")
+}
+
+// State is where an entry is in the queue.
+type State string
+
+const (
+	StateQueued      State = "queued"
+	StateWaitingDeps State = "waiting-deps"
+	StateMerging     State = "merging"
+	StateConflict    State = "conflict"
+	StateMerged      State = "merged"
+	StateFailed      State = "failed"
+	// StateDeferred is a conflict set aside after nobody answered its escalation.
+	StateDeferred State = "deferred"
+)
+
+// Terminal reports whether the entry leaves the queue in this state.
+// Conflicts, failures and deferred entries stay until the entry is retried.
+func (s State) Terminal() bool {
+	return s == StateMerged
+}
+
+// Strategy is how a task branch is brought onto the target.
+type Strategy string
+
+const (
+	// StrategyMerge creates a merge commit on the target.
+	StrategyMerge Strategy = "merge"
+	// StrategyRebase replays the task's commits onto the target for linear history.
+	StrategyRebase Strategy = "rebase"
+)
+
+var (
+	// ErrAlreadyQueued is returned by Enqueue for a task that is already in the queue.
+	ErrAlreadyQueued = errors.New("task already queued")
+	// ErrNotFound is returned for tasks that are not in the queue.
+	ErrNotFound = errors.New("task not in queue")
+	// ErrBusy is returned when removing a task that is being merged.
+	ErrBusy = errors.New("task is being merged")
+)
+
+// Config is the "integration" section of .axiom/config.json.
+type Config struct {
+	// Target is the branch task branches are merged into. Default "main".
+	Target string `json:"target,omitempty"`
+
+	// Strategy is "merge" (default) or "rebase".
+	Strategy Strategy `json:"strategy,omitempty"`
+
+	// Regenerate maps path patterns of lockfiles and generated files to the
+	// command that rebuilds them after a conflict, extending DefaultRegenerate.
+	Regenerate map[string]string `json:"regenerate,omitempty"`
+
+	// ScratchDir holds the temporary worktrees merges are made in, relative to the
+	// repository. It is inside the project so resolver agents may work there, and
+	// outside the workspace root so workspace cleanup leaves live merges alone.
+	ScratchDir string `json:"scratchDir,omitempty"`
+
+	// ConflictRetries is how many resolver attempts a conflict gets before a human
+	// is asked. It is set from merge.conflictRetries.
+	ConflictRetries int `json:"-"`
+
+	// ResolverLevel is the hardest conflict level handed to the resolver agent;
+	// harder conflicts go straight to a human. Default "medium".
+	ResolverLevel Level `json:"resolverLevel,omitempty"`
+}
+
+// Entry is one task branch waiting for or going through integration.
+type Entry struct {
+	TaskID string   `json:"taskId"`
+	Branch string   `json:"branch"`
+	Deps   []string `json:"deps,omitempty"`
+	State  State    `json:"state"`
+
+	// Error explains a conflict or failure.
+	Error string `json:"error,omitempty"`
+	// Conflicts lists the files that conflicted.
+	Conflicts []string `json:"conflicts,omitempty"`
+	// Report classifies the last conflict, including any that were resolved automatically.
+	Report *ConflictReport `json:"report,omitempty"`
+	// Commit is the target commit the task was merged as.
+	Commit string `json:"commit,omitempty"`
+	// ResolverAttempts counts resolver agent runs on the last conflict.
+	ResolverAttempts int `json:"resolverAttempts,omitempty"`
+	// Escalated reports that the conflict is waiting for a human.
+	Escalated bool `json:"escalated,omitempty"`
+
+	Attempts   int       `json:"attempts"`
+	EnqueuedAt time.Time `json:"enqueuedAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// Escalator is called with an entry whose conflict is waiting for a human.
+type Escalator func(ctx context.Context, e Entry)
+
+// Verifier re-runs verification in dir, a checkout of the target with the task integrated.
+type Verifier func(ctx context.Context, dir string) error
+
+// Rebaser moves taskID's branch onto the target when the target was rewritten
+// since the branch was created, e.g. by a force-push. An error fails the entry.
+type Rebaser func(ctx context.Context, taskID string) error
+
+// Queue is the persistent merge queue. Entries are integrated one at a time,
+// in the order they were enqueued, once their dependencies are merged.
+type Queue struct {
+	path    string
+	repoDir string
+	cfg     Config
+	verify  Verifier
+
+	// resolver handles conflicts automatic resolution cannot; nil escalates them to a human.
+	resolver Resolver
+
+	// escalator is told about conflicts left for a human.
+	escalator Escalator
+
+	// rebaser checks each branch against a rewritten target before it is merged.
+	rebaser Rebaser
+
+	// run serializes Process so only one merge touches the target at a time.
+	run sync.Mutex
+	// wake tells Run an entry was queued.
+	wake chan struct{}
+
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+// Open loads the queue persisted at path for the repository at repoDir.
+// Entries interrupted mid-merge by a crash are queued again.
+func Open(path, repoDir string, cfg Config, verify Verifier) (*Queue, error) {
+	if cfg.Target == "" {
+		cfg.Target = DefaultTarget
+	}
+	switch cfg.Strategy {
+	case "":
+		cfg.Strategy = DefaultStrategy
+	case StrategyMerge, StrategyRebase:
+	default:
+		return nil, fmt.Errorf("unknown integration strategy %q", cfg.Strategy)
+	}
+
+	switch cfg.ResolverLevel {
+	case "":
+		cfg.ResolverLevel = DefaultResolverLevel
+	case LevelMedium, LevelComplex:
+	default:
+		return nil, fmt.Errorf("invalid resolver level %q", cfg.ResolverLevel)
+	}
+	if cfg.ScratchDir == "" {
+		cfg.ScratchDir = DefaultScratchDir
+	}
+	if !filepath.IsAbs(cfg.ScratchDir) {
+		cfg.ScratchDir = filepath.Join(repoDir, cfg.ScratchDir)
+	}
+	if cfg.ConflictRetries <= 0 {
+		cfg.ConflictRetries = DefaultConflictRetries
+	}
+
+	q := &Queue{path: path, repoDir: repoDir, cfg: cfg, verify: verify, wake: make(chan struct{}, 1)}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read integration queue: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &q.entries); err != nil {
+			return nil, fmt.Errorf("parse integration queue: %w", err)
+		}
+	}
+
+	recovered := false
+	for _, e := range q.entries {
+		if e.State == StateMerging {
+			log.Printf("[WARN] integration of %s was interrupted, queuing it again", e.TaskID)
+			e.State = StateQueued
+			recovered = true
+		}
+	}
+	if recovered {
+		if err := q.save(); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// Target returns the branch tasks are merged into.
+func (q *Queue) Target() string { return q.cfg.Target }
+
+// Enqueue adds taskID's branch to the end of the queue. deps are task IDs that must
+// be merged first. A task that conflicted, failed or was deferred is queued again for a retry.
+func (q *Queue) Enqueue(taskID, branch string, deps []string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	if e := q.find(taskID); e != nil {
+		if e.State != StateConflict && e.State != StateFailed && e.State != StateDeferred {
+			return fmt.Errorf("%w: %s is %s", ErrAlreadyQueued, taskID, e.State)
+		}
+		e.Branch, e.Deps = branch, deps
+		e.State, e.Error, e.Conflicts, e.Report = StateQueued, "", nil, nil
+		e.ResolverAttempts, e.Escalated = 0, false
+		e.UpdatedAt = now
+		return q.saveAndWake()
+	}
+
+	q.entries = append(q.entries, &Entry{
+		TaskID:     taskID,
+		Branch:     branch,
+		Deps:       deps,
+		State:      StateQueued,
+		EnqueuedAt: now,
+		UpdatedAt:  now,
+	})
+	return q.saveAndWake()
+}
+
+// Requeue queues taskID again after its branch was rewritten, clearing any earlier
+// conflict or failure; queued entries keep their place. It reports false for
+// merged entries and tasks that are not in the queue, which are left alone.
+func (q *Queue) Requeue(taskID string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e := q.find(taskID)
+	if e == nil || e.State == StateMerged {
+		return false, nil
+	}
+	if e.State == StateMerging {
+		return false, fmt.Errorf("%w: %s is merging", ErrBusy, taskID)
+	}
+	e.State, e.Error, e.Conflicts, e.Report = StateQueued, "", nil, nil
+	e.ResolverAttempts, e.Escalated = 0, false
+	e.UpdatedAt = time.Now()
+	return true, q.saveAndWake()
+}
+
+// SetEscalator calls fn whenever a conflict is left for a human.
+func (q *Queue) SetEscalator(fn Escalator) {
+	q.mu.Lock()
+	q.escalator = fn
+	q.mu.Unlock()
+}
+
+// SetRebaser calls fn before each merge, so a branch left on commits a rewrite
+// removed from the target is moved onto it first.
+func (q *Queue) SetRebaser(fn Rebaser) {
+	q.mu.Lock()
+	q.rebaser = fn
+	q.mu.Unlock()
+}
+
+// Defer sets aside taskID's conflict so the entry no longer counts as waiting
+// for a human. Enqueue retries it later.
+func (q *Queue) Defer(taskID, reason string) error {
+	return q.update(taskID, func(e *Entry) {
+		e.State, e.Error, e.Escalated = StateDeferred, reason, false
+	})
+}
+
+// Remove drops taskID from the queue. Merging entries cannot be removed.
+func (q *Queue) Remove(taskID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, e := range q.entries {
+		if e.TaskID != taskID {
+			continue
+		}
+		if e.State == StateMerging {
+			return fmt.Errorf("%w: %s is merging", ErrBusy, taskID)
+		}
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+		return q.save()
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, taskID)
+}
+
+// Entries returns a copy of every entry in queue order.
+func (q *Queue) Entries() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]Entry, len(q.entries))
+	for i, e := range q.entries {
+		entries[i] = *e
+	}
+	return entries
+}
+
+// Get returns a copy of taskID's entry.
+func (q *Queue) Get(taskID string) (Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if e := q.find(taskID); e != nil {
+		return *e, nil
+	}
+	return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, taskID)
+}
+
+// find returns taskID's entry. The caller holds q.mu.
+func (q *Queue) find(taskID string) *Entry {
+	for _, e := range q.entries {
+		if e.TaskID == taskID {
+			return e
+		}
+	}
+	return nil
+}
+
+// update applies fn to taskID's entry and persists the queue.
+func (q *Queue) update(taskID string, fn func(*Entry)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e := q.find(taskID)
+	if e == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, taskID)
+	}
+	fn(e)
+	e.UpdatedAt = time.Now()
+	return q.save()
+}
+
+// saveAndWake saves the queue after an entry was queued and wakes Run. The
+// caller holds q.mu.
+func (q *Queue) saveAndWake() error {
+	if err := q.save(); err != nil {
+		return err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// save atomically writes the queue. The caller holds q.mu.
+func (q *Queue) save() error {
+	data, err := json.MarshalIndent(q.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return fmt.Errorf("create integration dir: %w", err)
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write integration queue: %w", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return fmt.Errorf("write integration queue: %w", err)
+	}
+	return nil
+}
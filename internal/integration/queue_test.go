@@ -0,0 +1,393 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// initRepo creates a git repository with one commit on main.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	run(t, dir, "init", "-q", "-b", "main")
+	writeFile(t, dir, "shared.txt", "base\n")
+	run(t, dir, "add", "-A")
+	run(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := gitcmd.Run(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return out
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// taskBranch commits files on a new branch axiom/<taskID> from main, leaving main checked out.
+func taskBranch(t *testing.T, dir, taskID string, files map[string]string) string {
+	t.Helper()
+	branch := "axiom/" + taskID
+	run(t, dir, "checkout", "-q", "-b", branch, "main")
+	for name, content := range files {
+		writeFile(t, dir, name, content)
+	}
+	run(t, dir, "add", "-A")
+	run(t, dir, "commit", "-q", "-m", taskID)
+	run(t, dir, "checkout", "-q", "main")
+	return branch
+}
+
+func openQueue(t *testing.T, dir string, cfg Config, verify Verifier) *Queue {
+	t.Helper()
+	q, err := Open(filepath.Join(t.TempDir(), "queue.json"), dir, cfg, verify)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return q
+}
+
+func state(t *testing.T, q *Queue, taskID string) Entry {
+	t.Helper()
+	e, err := q.Get(taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestQueue_MergesInDependencyOrder(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	api := taskBranch(t, dir, "api", map[string]string{"api.go": "package api\n"})
+	ui := taskBranch(t, dir, "ui", map[string]string{"ui.go": "package ui\n"})
+	q := openQueue(t, dir, Config{}, nil)
+
+	if err := q.Enqueue("ui", ui, []string{"api"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Process(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e := state(t, q, "ui"); e.State != StateWaitingDeps || e.Error != "waiting for api" {
+		t.Errorf("got %+v, want waiting for api", e)
+	}
+
+	if err := q.Enqueue("api", api, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue("api", api, nil); !errors.Is(err, ErrAlreadyQueued) {
+		t.Errorf("got %v, want ErrAlreadyQueued", err)
+	}
+	if err := q.Process(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	apiEntry, uiEntry := state(t, q, "api"), state(t, q, "ui")
+	if apiEntry.State != StateMerged || uiEntry.State != StateMerged {
+		t.Fatalf("got api %s, ui %s; want both merged", apiEntry.State, uiEntry.State)
+	}
+	if head := run(t, dir, "rev-parse", "main"); head != uiEntry.Commit {
+		t.Errorf("main at %s, want ui merge %s", head, uiEntry.Commit)
+	}
+	run(t, dir, "merge-base", "--is-ancestor", apiEntry.Commit, uiEntry.Commit)
+	if got := run(t, dir, "status", "--porcelain"); got != "" {
+		t.Errorf("expected main checkout to be fast-forwarded cleanly, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ui.go")); err != nil {
+		t.Errorf("expected ui.go in main checkout: %v", err)
+	}
+}
+
+func TestQueue_Conflict(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	first := taskBranch(t, dir, "first", map[string]string{"shared.txt": "first\n"})
+	second := taskBranch(t, dir, "second", map[string]string{"shared.txt": "second\n"})
+	q := openQueue(t, dir, Config{}, nil)
+	var escalated []Entry
+	q.SetEscalator(func(_ context.Context, e Entry) { escalated = append(escalated, e) })
+	if err := q.Enqueue("first", first, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue("second", second, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Process(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := state(t, q, "second")
+	if e.State != StateConflict || len(e.Conflicts) != 1 || e.Conflicts[0] != "shared.txt" {
+		t.Errorf("got %+v, want conflict in shared.txt", e)
+	}
+	if len(escalated) != 1 || escalated[0].TaskID != "second" || !escalated[0].Escalated {
+		t.Errorf("escalator got %+v, want the conflicted entry", escalated)
+	}
+	if state(t, q, "first").State != StateMerged {
+		t.Error("expected first to merge")
+	}
+	if err := q.Enqueue("second", second, nil); err != nil {
+		t.Errorf("expected a conflicted entry to be re-queued, got %v", err)
+	}
+}
+
+func TestQueue_VerificationFailureLeavesTarget(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	branch := taskBranch(t, dir, "broken", map[string]string{"broken.go": "package broken\n"})
+	before := run(t, dir, "rev-parse", "main")
+
+	var verified string
+	q := openQueue(t, dir, Config{}, func(_ context.Context, dir string) error {
+		if _, err := os.Stat(filepath.Join(dir, "broken.go")); err != nil {
+			return err
+		}
+		verified = dir
+		return errors.New("tests failed")
+	})
+	if err := q.Enqueue("broken", branch, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Process(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := state(t, q, "broken")
+	if e.State != StateFailed || !strings.Contains(e.Error, "tests failed") {
+		t.Errorf("got %+v, want failed verification", e)
+	}
+	if verified == "" {
+		t.Error("expected verification to run on the integrated checkout")
+	}
+	if after := run(t, dir, "rev-parse", "main"); after != before {
+		t.Error("expected main to stay put")
+	}
+	if out := run(t, dir, "worktree", "list"); strings.Count(out, "\n") != 0 {
+		t.Errorf("expected scratch worktree removed, got:\n%s", out)
+	}
+}
+
+func TestQueue_RebaserRunsBeforeMerge(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	moved := taskBranch(t, dir, "moved", map[string]string{"moved.go": "package moved\n"})
+	stuck := taskBranch(t, dir, "stuck", map[string]string{"stuck.go": "package stuck\n"})
+	before := run(t, dir, "rev-parse", "main")
+	q := openQueue(t, dir, Config{}, nil)
+	var rebased []string
+	q.SetRebaser(func(_ context.Context, taskID string) error {
+		rebased = append(rebased, taskID)
+		if taskID == "stuck" {
+			return errors.New("rebase onto the new base conflicts in stuck.go")
+		}
+		return nil
+	})
+	for taskID, branch := range map[string]string{"stuck": stuck, "moved": moved} {
+		if err := q.Enqueue(taskID, branch, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := q.Process(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(rebased) != 2 {
+		t.Errorf("rebaser got %v, want both tasks", rebased)
+	}
+	if e := state(t, q, "stuck"); e.State != StateFailed || !strings.Contains(e.Error, "rebase onto main") {
+		t.Errorf("got %+v, want stuck failed on the rewritten target", e)
+	}
+	if e := state(t, q, "moved"); e.State != StateMerged {
+		t.Errorf("got %+v, want moved merged", e)
+	}
+	run(t, dir, "merge-base", "--is-ancestor", before, "main")
+	if _, err := os.Stat(filepath.Join(dir, "stuck.go")); !os.IsNotExist(err) {
+		t.Error("expected stuck to stay off main")
+	}
+}
+
+func TestQueue_Rebase(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	branch := taskBranch(t, dir, "linear", map[string]string{"linear.go": "package linear\n"})
+	writeFile(t, dir, "other.txt", "moved on\n")
+	run(t, dir, "add", "-A")
+	run(t, dir, "commit", "-q", "-m", "main moves")
+	q := openQueue(t, dir, Config{Strategy: StrategyRebase}, nil)
+	if err := q.Enqueue("linear", branch, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Process(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e := state(t, q, "linear"); e.State != StateMerged {
+		t.Fatalf("got %+v, want merged", e)
+	}
+	if merges := run(t, dir, "rev-list", "--merges", "main"); merges != "" {
+		t.Errorf("expected linear history, found merges %s", merges)
+	}
+	if run(t, dir, "rev-parse", "main") != run(t, dir, "rev-parse", branch) {
+		t.Error("expected the task branch to point at the rebased head")
+	}
+}
+
+func TestQueue_SurvivesRestart(t *testing.T) {
+	dir := initRepo(t)
+	path := filepath.Join(t.TempDir(), "integration", "queue.json")
+	q, err := Open(path, dir, Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Enqueue("task-001", "axiom/task-001", []string{"task-000"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.update("task-001", func(e *Entry) { e.State = StateMerging }); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path, dir, Config{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := state(t, reopened, "task-001")
+	if e.State != StateQueued || len(e.Deps) != 1 || e.Deps[0] != "task-000" {
+		t.Errorf("got %+v, want interrupted merge queued again with its deps", e)
+	}
+	if _, err := Open(path, dir, Config{Strategy: "squash"}, nil); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
+
+// waitMerged waits for Run to merge taskID.
+func waitMerged(t *testing.T, q *Queue, taskID string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for state(t, q, taskID).State != StateMerged {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %+v, want %s merged", state(t, q, taskID), taskID)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQueue_RunMergesWithoutProcess(t *testing.T) {
+	dir := initRepo(t)
+	path := filepath.Join(t.TempDir(), "queue.json")
+	earlier, err := Open(path, dir, Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := earlier.Enqueue("before", taskBranch(t, dir, "before", map[string]string{"before.go": "package before\n"}), nil); err != nil {
+		t.Fatal(err)
+	}
+	q, err := Open(path, dir, Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	waitMerged(t, q, "before")
+
+	if err := q.Enqueue("after", taskBranch(t, dir, "after", map[string]string{"after.go": "package after\n"}), nil); err != nil {
+		t.Fatal(err)
+	}
+	waitMerged(t, q, "after")
+	cancel()
+	<-done
+	if _, err := os.Stat(filepath.Join(dir, "after.go")); err != nil {
+		t.Errorf("expected after.go on main: %v", err)
+	}
+}
+
+func TestQueue_DeferAndRemove(t *testing.T) {
+	dir := initRepo(t)
+	q := openQueue(t, dir, Config{}, nil)
+	if err := q.Enqueue("task-001", "axiom/task-001", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Defer("task-001", "nobody answered"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e := state(t, q, "task-001"); e.State != StateDeferred || e.Error != "nobody answered" {
+		t.Errorf("got %+v, want deferred", e)
+	}
+	if err := q.Enqueue("task-001", "axiom/task-001", nil); err != nil {
+		t.Errorf("expected a deferred entry to be re-queued, got %v", err)
+	}
+
+	if err := q.Remove("task-001"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := q.Get("task-001"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound after Remove", err)
+	}
+	if err := q.Remove("task-001"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound removing twice", err)
+	}
+}
+
+func TestQueue_Requeue(t *testing.T) {
+	dir := initRepo(t)
+	q := openQueue(t, dir, Config{}, nil)
+	for _, id := range []string{"failed", "merged", "merging"} {
+		if err := q.Enqueue(id, "axiom/"+id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = q.update("failed", func(e *Entry) { e.State, e.Error, e.Escalated = StateConflict, "conflict", true })
+	_ = q.update("merged", func(e *Entry) { e.State = StateMerged })
+	_ = q.update("merging", func(e *Entry) { e.State = StateMerging })
+
+	if ok, err := q.Requeue("failed"); !ok || err != nil {
+		t.Fatalf("got %v, %v; want the conflicted entry queued again", ok, err)
+	}
+	if e := state(t, q, "failed"); e.State != StateQueued || e.Error != "" || e.Escalated {
+		t.Errorf("got %+v, want a fresh queued entry", e)
+	}
+	if ok, err := q.Requeue("merged"); ok || err != nil {
+		t.Errorf("got %v, %v; want merged entries left alone", ok, err)
+	}
+	if ok, err := q.Requeue("unknown"); ok || err != nil {
+		t.Errorf("got %v, %v; want unknown tasks ignored", ok, err)
+	}
+	if _, err := q.Requeue("merging"); !errors.Is(err, ErrBusy) {
+		t.Errorf("got %v, want ErrBusy", err)
+	}
+}
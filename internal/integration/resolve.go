@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// Resolve resolves the simple files of report in dir and stages them. Files that
+// fail to resolve are escalated to medium. It reports whether every file is resolved.
+func Resolve(ctx context.Context, dir string, report *ConflictReport) bool {
+	for i := range report.Files {
+		f := &report.Files[i]
+		if f.Level != LevelSimple || f.Resolved {
+			continue
+		}
+		if err := resolveFile(ctx, dir, f); err != nil {
+			f.Level, f.Detail = LevelMedium, err.Error()
+			if report.Level.rank() < LevelMedium.rank() {
+				report.Level = LevelMedium
+			}
+			continue
+		}
+		f.Resolved = true
+	}
+	return report.Resolved()
+}
+
+// resolveFile writes f's resolution, or regenerates it, and stages the result.
+func resolveFile(ctx context.Context, dir string, f *FileConflict) error {
+	switch {
+	case f.Kind == KindRegenerable:
+		// Start from the task's version so the command sees a well-formed file.
+		if _, err := gitcmd.Run(ctx, dir, "checkout", "--theirs", "--", f.Path); err != nil {
+			return err
+		}
+		cmd := exec.CommandContext(ctx, "sh", "-c", f.Command)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("regenerate with %q: %v: %s", f.Command, err, strings.TrimSpace(string(out)))
+		}
+	case f.resolution != nil:
+		if err := os.WriteFile(filepath.Join(dir, f.Path), f.resolution, 0o644); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("no automatic resolution for %s", f.Kind)
+	}
+	_, err := gitcmd.Run(ctx, dir, "add", "--", f.Path)
+	return err
+}
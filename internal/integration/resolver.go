@@ -0,0 +1,226 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// recentCommits is how many commits of each side a resolver is shown.
+const recentCommits = 10
+
+// regionContext is how many unconflicted lines surround each conflict region shown to a resolver.
+const regionContext = 3
+
+// ErrEscalate is returned by a Resolver that judges the conflict to need a human.
+var ErrEscalate = errors.New("conflict needs a human")
+
+// Resolver resolves conflicts automatic resolution cannot, such as an agent.
+type Resolver interface {
+	// Resolve edits req.Dir, where a merge of req.Branch into req.Target is in
+	// progress, until no conflicts remain, staging and committing the result.
+	// It returns ErrEscalate when a human should take over.
+	Resolve(ctx context.Context, req ResolveRequest) error
+}
+
+// ResolveRequest is the context a Resolver works from.
+type ResolveRequest struct {
+	TaskID string
+	Branch string
+	Target string
+
+	// Dir is the conflict worktree with the merge in progress.
+	Dir string
+
+	// Report classifies the conflicted files; simple ones are already resolved and staged.
+	Report *ConflictReport
+
+	// Regions holds each unresolved file's conflict regions with surrounding lines, keyed by path.
+	Regions map[string]string
+
+	// TargetTasks are the tasks merged into the target since the branch was created.
+	TargetTasks []string
+
+	// TargetCommits and BranchCommits are the latest commits on each side since
+	// they diverged, one line each.
+	TargetCommits []string
+	BranchCommits []string
+
+	// Attempt counts resolver runs on this conflict, starting at 1.
+	Attempt int
+}
+
+// SetResolver hands conflicts up to Config.ResolverLevel to r before asking a human.
+func (q *Queue) SetResolver(r Resolver) {
+	q.mu.Lock()
+	q.resolver = r
+	q.mu.Unlock()
+}
+
+// resolveWithAgent gives the resolver up to ConflictRetries attempts, each in a
+// fresh conflict worktree. A resolution lands only when no conflicts remain and
+// verification passes; otherwise the conflict goes to a human.
+func (q *Queue) resolveWithAgent(ctx context.Context, e Entry, base string, report *ConflictReport) error {
+	reason := "resolver gave up"
+	for attempt := 1; attempt <= q.cfg.ConflictRetries; attempt++ {
+		if err := q.update(e.TaskID, func(e *Entry) { e.ResolverAttempts = attempt }); err != nil {
+			return err
+		}
+
+		landed, err := q.resolveAttempt(ctx, e, base, attempt)
+		if landed || ctx.Err() != nil {
+			return err
+		}
+		if errors.Is(err, ErrEscalate) {
+			return q.toHuman(ctx, e.TaskID, report, err.Error())
+		}
+		reason = fmt.Sprintf("resolver failed %d time(s), last: %v", attempt, err)
+		log.Printf("[integration] %s: resolver attempt %d: %v", e.TaskID, attempt, err)
+	}
+	return q.toHuman(ctx, e.TaskID, report, reason)
+}
+
+// resolveAttempt recreates the conflicted merge in a new worktree, runs the resolver
+// and lands the result. It reports whether the entry was landed; the error then
+// reports a failure to persist it, and otherwise why the attempt failed.
+func (q *Queue) resolveAttempt(ctx context.Context, e Entry, base string, attempt int) (bool, error) {
+	dir, cleanup, err := q.scratch(ctx, base, e.TaskID+"-conflict")
+	if err != nil {
+		return false, err
+	}
+	defer cleanup()
+
+	// The merge succeeds without a resolver when the target moved past the conflict.
+	var report *ConflictReport
+	msg := fmt.Sprintf("Merge %s (%s)", e.Branch, e.TaskID)
+	if _, err := gitcmd.Run(ctx, dir, "merge", "--no-ff", "--no-edit", "-m", msg, e.Branch); err != nil {
+		if report, err = q.runResolver(ctx, e, dir, base, attempt); err != nil {
+			return false, err
+		}
+	}
+	if q.verify != nil {
+		if err := q.verify(ctx, dir); err != nil {
+			return false, fmt.Errorf("verification failed: %w", err)
+		}
+	}
+	return true, q.land(ctx, e, dir, base, report)
+}
+
+// runResolver has the resolver finish the merge in progress in dir and checks
+// that no conflicts are left and the merge is committed.
+func (q *Queue) runResolver(ctx context.Context, e Entry, dir, base string, attempt int) (*ConflictReport, error) {
+	report, err := q.resolve(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	req := ResolveRequest{
+		TaskID:  e.TaskID,
+		Branch:  e.Branch,
+		Target:  q.cfg.Target,
+		Dir:     dir,
+		Report:  report,
+		Regions: make(map[string]string),
+		Attempt: attempt,
+	}
+	for _, f := range report.Files {
+		if !f.Resolved {
+			if regions, err := conflictRegions(filepath.Join(dir, f.Path), regionContext); err == nil {
+				req.Regions[f.Path] = regions
+			}
+		}
+	}
+	req.TargetCommits, req.BranchCommits, req.TargetTasks = q.history(ctx, base, e.Branch)
+
+	if err := q.resolver.Resolve(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if remaining := unmerged(ctx, dir); len(remaining) > 0 {
+		return nil, fmt.Errorf("%d file(s) still unmerged: %s", len(remaining), strings.Join(remaining, ", "))
+	}
+	for _, f := range report.Files {
+		if data, err := os.ReadFile(filepath.Join(dir, f.Path)); err == nil && hasMarkers(string(data)) {
+			return nil, fmt.Errorf("conflict markers left in %s", f.Path)
+		}
+	}
+	if _, err := gitcmd.Run(ctx, dir, "rev-parse", "-q", "--verify", "MERGE_HEAD"); err == nil {
+		if _, err := gitcmd.Run(ctx, dir, "commit", "--no-edit"); err != nil {
+			return nil, fmt.Errorf("commit resolution: %w", err)
+		}
+	}
+	for i := range report.Files {
+		report.Files[i].Resolved = true
+	}
+	return report, nil
+}
+
+// history returns the latest commits on each side since the target and branch
+// diverged, and the tasks merged into the target in that time.
+func (q *Queue) history(ctx context.Context, target, branch string) (targetCommits, branchCommits, tasks []string) {
+	limit := fmt.Sprintf("-%d", recentCommits)
+	if out, err := gitcmd.Run(ctx, q.repoDir, "log", "--oneline", limit, branch+".."+target); err == nil {
+		targetCommits = lines(out)
+	}
+	if out, err := gitcmd.Run(ctx, q.repoDir, "log", "--oneline", limit, target+".."+branch); err == nil {
+		branchCommits = lines(out)
+	}
+
+	onTarget := make(map[string]bool)
+	if out, err := gitcmd.Run(ctx, q.repoDir, "rev-list", branch+".."+target); err == nil {
+		for _, c := range lines(out) {
+			onTarget[c] = true
+		}
+	}
+	for _, entry := range q.Entries() {
+		if entry.State == StateMerged && onTarget[entry.Commit] {
+			tasks = append(tasks, entry.TaskID)
+		}
+	}
+	return targetCommits, branchCommits, tasks
+}
+
+// conflictRegions returns the conflict regions of the file at path, each with
+// context unconflicted lines around it, separated by "...".
+func conflictRegions(path string, context int) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	all := strings.SplitAfter(string(data), "\n")
+
+	var b strings.Builder
+	last := -1
+	for i := 0; i < len(all); i++ {
+		if !strings.HasPrefix(all[i], "<<<<<<<") {
+			continue
+		}
+		end := i
+		for end < len(all) && !strings.HasPrefix(all[end], ">>>>>>>") {
+			end++
+		}
+		from, to := max(i-context, last+1), min(end+context+1, len(all))
+		if last >= 0 && from > last+1 {
+			b.WriteString("...\n")
+		}
+		b.WriteString(strings.Join(all[from:to], ""))
+		last, i = to-1, to-1
+	}
+	return b.String(), nil
+}
+
+// hasMarkers reports whether content still contains conflict markers.
+func hasMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") || line == "<<<<<<<" || line == ">>>>>>>" {
+			return true
+		}
+	}
+	return false
+}
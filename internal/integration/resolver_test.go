@@ -0,0 +1,169 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// fakeResolver applies fn to each request and counts calls.
+type fakeResolver struct {
+	calls []ResolveRequest
+	fn    func(req ResolveRequest) error
+}
+
+func (f *fakeResolver) Resolve(_ context.Context, req ResolveRequest) error {
+	f.calls = append(f.calls, req)
+	return f.fn(req)
+}
+
+func semanticConflict(t *testing.T) (string, string) {
+	t.Helper()
+	return conflictRepo(t,
+		map[string]string{"login.go": "package auth\n\nfunc login() {\n\tcheck()\n}\n"},
+		map[string]string{"login.go": "package auth\n\nfunc login() {\n\trateLimit()\n}\n"},
+		map[string]string{"login.go": "package auth\n\nfunc login() {\n\tcheck2FA()\n}\n"},
+	)
+}
+
+func TestQueue_ResolverLandsVerifiedResolution(t *testing.T) {
+	dir, branch := semanticConflict(t)
+	resolver := &fakeResolver{fn: func(req ResolveRequest) error {
+		resolved := "package auth\n\nfunc login() {\n\trateLimit()\n\tcheck2FA()\n}\n"
+		if err := os.WriteFile(filepath.Join(req.Dir, "login.go"), []byte(resolved), 0o644); err != nil {
+			return err
+		}
+		_, err := gitcmd.Run(context.Background(), req.Dir, "add", "login.go")
+		return err
+	}}
+	q := openQueue(t, dir, Config{}, nil)
+	q.SetResolver(resolver)
+	if err := q.Enqueue("task", branch, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Process(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := state(t, q, "task")
+	if e.State != StateMerged || e.ResolverAttempts != 1 || !e.Report.Resolved() {
+		t.Fatalf("got %+v, want merged after one resolver attempt", e)
+	}
+	req := resolver.calls[0]
+	if !strings.HasPrefix(req.Dir, filepath.Join(dir, DefaultScratchDir)) {
+		t.Errorf("expected conflict worktree inside the project, got %s", req.Dir)
+	}
+	if !strings.Contains(req.Regions["login.go"], "rateLimit()") || !strings.Contains(req.Regions["login.go"], "check2FA()") {
+		t.Errorf("expected both sides in the regions, got:\n%s", req.Regions["login.go"])
+	}
+	if len(req.TargetCommits) != 1 || len(req.BranchCommits) != 1 {
+		t.Errorf("unexpected history: %v / %v", req.TargetCommits, req.BranchCommits)
+	}
+	if got := run(t, dir, "show", "main:login.go"); !strings.Contains(got, "rateLimit()\n\tcheck2FA()") {
+		t.Errorf("expected resolution on main, got:\n%s", got)
+	}
+}
+
+func TestQueue_ResolverFailuresEscalateToHuman(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        func(ResolveRequest) error
+		verify    Verifier
+		wantCalls int
+		wantError string
+	}{
+		{
+			name:      "resolver errors",
+			fn:        func(ResolveRequest) error { return errors.New("agent crashed") },
+			wantCalls: 2,
+			wantError: "resolver failed 2 time(s)",
+		},
+		{
+			name:      "resolver escalates",
+			fn:        func(ResolveRequest) error { return fmt.Errorf("%w: product decision", ErrEscalate) },
+			wantCalls: 1,
+			wantError: "product decision",
+		},
+		{
+			name: "markers left",
+			fn: func(req ResolveRequest) error {
+				_, err := gitcmd.Run(context.Background(), req.Dir, "add", "login.go")
+				return err
+			},
+			wantCalls: 2,
+			wantError: "conflict markers left in login.go",
+		},
+		{
+			name: "verification fails",
+			fn: func(req ResolveRequest) error {
+				if err := os.WriteFile(filepath.Join(req.Dir, "login.go"), []byte("broken"), 0o644); err != nil {
+					return err
+				}
+				_, err := gitcmd.Run(context.Background(), req.Dir, "add", "login.go")
+				return err
+			},
+			verify:    func(context.Context, string) error { return errors.New("does not compile") },
+			wantCalls: 2,
+			wantError: "does not compile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, branch := semanticConflict(t)
+			before := run(t, dir, "rev-parse", "main")
+			resolver := &fakeResolver{fn: tt.fn}
+			q := openQueue(t, dir, Config{ConflictRetries: 2}, tt.verify)
+			q.SetResolver(resolver)
+			if err := q.Enqueue("task", branch, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := q.Process(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			e := state(t, q, "task")
+			if e.State != StateConflict || !e.Escalated || !strings.Contains(e.Error, tt.wantError) {
+				t.Errorf("got %+v, want escalation with %q", e, tt.wantError)
+			}
+			if len(resolver.calls) != tt.wantCalls {
+				t.Errorf("got %d resolver calls, want %d", len(resolver.calls), tt.wantCalls)
+			}
+			if after := run(t, dir, "rev-parse", "main"); after != before {
+				t.Error("expected main to stay put")
+			}
+		})
+	}
+}
+
+func TestQueue_ComplexConflictsSkipResolver(t *testing.T) {
+	dir, branch := conflictRepo(t,
+		map[string]string{"gone.go": "old\n"},
+		map[string]string{"gone.go": ""},
+		map[string]string{"gone.go": "changed\n"},
+	)
+	resolver := &fakeResolver{fn: func(ResolveRequest) error { return nil }}
+	q := openQueue(t, dir, Config{}, nil)
+	q.SetResolver(resolver)
+	if err := q.Enqueue("task", branch, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Process(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e := state(t, q, "task"); e.State != StateConflict || !e.Escalated {
+		t.Errorf("got %+v, want escalation to a human", e)
+	}
+	if len(resolver.calls) != 0 {
+		t.Errorf("expected complex conflict to skip the resolver, got %d calls", len(resolver.calls))
+	}
+}
@@ -0,0 +1,158 @@
+// Package models chooses the Claude model for an agent run from the persona,
+// the case it works on and how many times the task has already failed.
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+// DefaultModel is used when neither the policy nor the config names a model.
+const DefaultModel = "sonnet"
+
+// ErrInvalidPolicy is returned by Validate for unusable policies.
+var ErrInvalidPolicy = errors.New("invalid model policy")
+
+// Policy is the "models" section of .axiom/config.json.
+type Policy struct {
+	// Personas maps a persona name to its default model.
+	Personas map[string]string `json:"personas,omitempty"`
+
+	// Labels maps a case label to the model for cases carrying it.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Complexity maps a case's complexity estimate ("low", "medium", "high") to a model.
+	Complexity map[string]string `json:"complexity,omitempty"`
+
+	// Ladder lists models from weakest to strongest. A retried task moves one step up per failed attempt.
+	Ladder []string `json:"ladder,omitempty"`
+}
+
+// DefaultPolicy returns the built-in policy: every persona on the default model,
+// escalating haiku → sonnet → opus.
+func DefaultPolicy() Policy {
+	return Policy{Ladder: []string{"haiku", "sonnet", "opus"}}
+}
+
+// Validate reports empty model names and duplicate ladder entries.
+func (p Policy) Validate() error {
+	for _, section := range []struct {
+		name string
+		m    map[string]string
+	}{{"personas", p.Personas}, {"labels", p.Labels}, {"complexity", p.Complexity}} {
+		for key, model := range section.m {
+			if model == "" {
+				return fmt.Errorf("%w: %s.%s has no model", ErrInvalidPolicy, section.name, key)
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(p.Ladder))
+	for _, model := range p.Ladder {
+		if model == "" || seen[model] {
+			return fmt.Errorf("%w: ladder entry %q is empty or repeated", ErrInvalidPolicy, model)
+		}
+		seen[model] = true
+	}
+	return nil
+}
+
+// Request describes the run a model is chosen for.
+type Request struct {
+	Persona string
+
+	// Case is the case being worked on, or nil for runs outside a case.
+	Case *casestore.Case
+
+	// Attempt counts earlier failed attempts at the task; 0 is the first try.
+	Attempt int
+}
+
+// Choice is the selected model and why it was chosen.
+type Choice struct {
+	Model  string `json:"model"`
+	Reason string `json:"reason"`
+}
+
+// Select chooses the model for req. Precedence, highest first: the case's own model,
+// its labels (strongest match wins), its complexity estimate, the persona default,
+// then defaultModel. Failed attempts then escalate the choice up the ladder.
+func (p Policy) Select(defaultModel string, req Request) Choice {
+	choice := p.base(defaultModel, req)
+	if req.Attempt <= 0 {
+		return choice
+	}
+
+	escalated := p.escalate(choice.Model, req.Attempt)
+	if escalated == choice.Model {
+		return choice
+	}
+	return Choice{
+		Model:  escalated,
+		Reason: fmt.Sprintf("escalated from %s (%s) after %d failed attempt(s)", choice.Model, choice.Reason, req.Attempt),
+	}
+}
+
+// base chooses the model before escalation.
+func (p Policy) base(defaultModel string, req Request) Choice {
+	if c := req.Case; c != nil {
+		if c.Model != "" {
+			return Choice{Model: c.Model, Reason: "case " + c.ID}
+		}
+		if label, model := p.labelModel(c.Labels); model != "" {
+			return Choice{Model: model, Reason: "label " + label}
+		}
+		if model := p.Complexity[c.Complexity]; c.Complexity != "" && model != "" {
+			return Choice{Model: model, Reason: "complexity " + c.Complexity}
+		}
+	}
+	if model := p.Personas[req.Persona]; model != "" {
+		return Choice{Model: model, Reason: "persona " + req.Persona}
+	}
+	if defaultModel != "" {
+		return Choice{Model: defaultModel, Reason: "default"}
+	}
+	return Choice{Model: DefaultModel, Reason: "default"}
+}
+
+// labelModel returns the strongest model configured for any of labels.
+// Models off the ladder rank below those on it; ties keep label order.
+func (p Policy) labelModel(labels []string) (string, string) {
+	var bestLabel, bestModel string
+	bestRank := -2
+	for _, label := range labels {
+		model := p.Labels[label]
+		if model == "" {
+			continue
+		}
+		if rank := p.rank(model); rank > bestRank {
+			bestLabel, bestModel, bestRank = label, model, rank
+		}
+	}
+	return bestLabel, bestModel
+}
+
+// escalate moves model steps rungs up the ladder, stopping at the top.
+// Models off the ladder escalate straight to its strongest entry.
+func (p Policy) escalate(model string, steps int) string {
+	if len(p.Ladder) == 0 {
+		return model
+	}
+	rank := p.rank(model)
+	if rank < 0 {
+		return p.Ladder[len(p.Ladder)-1]
+	}
+	return p.Ladder[min(rank+steps, len(p.Ladder)-1)]
+}
+
+// rank returns model's position on the ladder, or -1.
+func (p Policy) rank(model string) int {
+	for i, m := range p.Ladder {
+		if m == model {
+			return i
+		}
+	}
+	return -1
+}
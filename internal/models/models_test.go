@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+func testPolicy() Policy {
+	return Policy{
+		Personas:   map[string]string{"rex": "opus", "ash": "haiku"},
+		Labels:     map[string]string{"docs": "haiku", "security": "opus", "infra": "custom-model"},
+		Complexity: map[string]string{"low": "haiku", "high": "opus"},
+		Ladder:     []string{"haiku", "sonnet", "opus"},
+	}
+}
+
+func TestPolicy_SelectPrecedence(t *testing.T) {
+	p := testPolicy()
+	tests := []struct {
+		name       string
+		req        Request
+		want       string
+		wantReason string
+	}{
+		{"default", Request{Persona: "echo"}, "sonnet", "default"},
+		{"persona", Request{Persona: "rex"}, "opus", "persona rex"},
+		{"complexity over persona", Request{Persona: "ash", Case: &casestore.Case{Complexity: "high"}}, "opus", "complexity high"},
+		{"unknown complexity falls through", Request{Persona: "ash", Case: &casestore.Case{Complexity: "medium"}}, "haiku", "persona ash"},
+		{"label over complexity", Request{Persona: "echo", Case: &casestore.Case{Labels: []string{"docs"}, Complexity: "high"}}, "haiku", "label docs"},
+		{"strongest label wins", Request{Persona: "echo", Case: &casestore.Case{Labels: []string{"docs", "infra", "security"}}}, "opus", "label security"},
+		{"case model over all", Request{Persona: "rex", Case: &casestore.Case{ID: "task-007", Model: "haiku", Labels: []string{"security"}}}, "haiku", "case task-007"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Select("sonnet", tt.req)
+			if got.Model != tt.want || got.Reason != tt.wantReason {
+				t.Errorf("got %+v, want %s (%s)", got, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPolicy_SelectEscalates(t *testing.T) {
+	p := testPolicy()
+
+	tests := []struct {
+		req  Request
+		want string
+	}{
+		{Request{Persona: "ash", Attempt: 1}, "sonnet"},
+		{Request{Persona: "ash", Attempt: 2}, "opus"},
+		{Request{Persona: "ash", Attempt: 5}, "opus"},
+		{Request{Persona: "echo", Case: &casestore.Case{Labels: []string{"infra"}}, Attempt: 1}, "opus"},
+	}
+	for _, tt := range tests {
+		if got := p.Select("sonnet", tt.req); got.Model != tt.want {
+			t.Errorf("attempt %d from %+v: got %s, want %s", tt.req.Attempt, tt.req, got.Model, tt.want)
+		}
+	}
+
+	got := p.Select("sonnet", Request{Persona: "ash", Attempt: 1})
+	if !strings.Contains(got.Reason, "escalated from haiku (persona ash)") {
+		t.Errorf("unexpected reason %q", got.Reason)
+	}
+	if top := p.Select("sonnet", Request{Persona: "rex", Attempt: 1}); top.Reason != "persona rex" {
+		t.Errorf("expected no escalation at the top of the ladder, got %+v", top)
+	}
+}
+
+func TestPolicy_EmptyPolicyUsesDefaults(t *testing.T) {
+	if got := (Policy{}).Select("", Request{Persona: "ava", Attempt: 2}); got.Model != DefaultModel {
+		t.Errorf("got %s, want %s", got.Model, DefaultModel)
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	if err := testPolicy().Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (Policy{Labels: map[string]string{"docs": ""}}).Validate(); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("got %v, want ErrInvalidPolicy for empty model", err)
+	}
+	if err := (Policy{Ladder: []string{"haiku", "haiku"}}).Validate(); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("got %v, want ErrInvalidPolicy for repeated ladder entry", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Package pathglob matches slash-separated paths against glob patterns with ** support.
+package pathglob
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether the slash-separated name matches pattern.
+//
+// Patterns follow path.Match syntax per segment, plus:
+//   - "**" matches zero or more whole segments ("internal/**/*.go")
+//   - a pattern without "/" matches the base name at any depth ("*.go")
+//   - a trailing "/" matches everything below a directory ("web/")
+//
+// Malformed patterns never match.
+func Match(pattern, name string) bool {
+	name = strings.TrimPrefix(path.Clean(strings.ReplaceAll(name, "\\", "/")), "./")
+	pattern = strings.TrimPrefix(pattern, "./")
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(pattern, "/") {
+		ok, err := path.Match(pattern, path.Base(name))
+		return err == nil && ok
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// MatchAny reports whether name matches at least one pattern.
+func MatchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches pattern segments against name segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,46 @@
+package pathglob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "internal/agent/client.go", true},
+		{"*.go", "web/input.css", false},
+		{"internal/*.go", "internal/foo.go", true},
+		{"internal/*.go", "internal/agent/foo.go", false},
+		{"internal/**/*.go", "internal/agent/foo.go", true},
+		{"internal/**/*.go", "internal/foo.go", true},
+		{"internal/**", "internal/a/b/c.txt", true},
+		{"web/", "web/static/js/app.js", true},
+		{"web/", "internal/web/server.go", false},
+		{"**/package-lock.json", "frontend/package-lock.json", true},
+		{"**/package-lock.json", "package-lock.json", true},
+		{"./cmd/**", "cmd/axiom/main.go", true},
+		{"[", "x", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	patterns := []string{"*.md", "docs/**"}
+
+	if !MatchAny(patterns, "docs/adr/001.txt") {
+		t.Error("expected docs/adr/001.txt to match")
+	}
+	if MatchAny(patterns, "main.go") {
+		t.Error("expected main.go not to match")
+	}
+	if MatchAny(nil, "main.go") {
+		t.Error("expected no match for empty pattern list")
+	}
+}
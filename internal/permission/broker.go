@@ -0,0 +1,188 @@
+package permission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
+)
+
+// ErrNotFound is returned when resolving an escalation that is not pending.
+var ErrNotFound = errors.New("escalation not found")
+
+// Escalation is a request waiting for a human decision.
+type Escalation struct {
+	ID        string    `json:"id"`
+	Request   Request   `json:"request"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// answer is a human's decision on an escalation.
+type answer struct {
+	allow   bool
+	message string
+}
+
+// pending is an escalation with the channel its waiter listens on.
+type pending struct {
+	escalation Escalation
+	answer     chan answer
+}
+
+// Broker evaluates agent tool-use requests against a policy, answering clear
+// cases itself and escalating the rest to the human with a timeout.
+type Broker struct {
+	policy  Policy
+	timeout time.Duration
+	// unattended denies escalations at once; no human is there to answer them.
+	unattended bool
+
+	mu      sync.Mutex
+	pending map[string]*pending
+	nextID  int
+	now     func() time.Time
+}
+
+// NewBroker creates a Broker for policy.
+func NewBroker(policy Policy) (*Broker, error) {
+	timeout, err := policy.Timeout()
+	if err != nil {
+		return nil, err
+	}
+	return &Broker{
+		policy:  policy,
+		timeout: timeout,
+		pending: make(map[string]*pending),
+		now:     time.Now,
+	}, nil
+}
+
+// SetUnattended makes the broker deny requests the policy would escalate instead
+// of waiting for a human, as in a non-interactive run.
+func (b *Broker) SetUnattended(unattended bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.unattended = unattended
+}
+
+// Handler returns the CanUseTool callback for one agent working in workDir.
+func (b *Broker) Handler(agentID, workDir string) claude.CanUseToolCallback {
+	return func(ctx context.Context, toolName string, input map[string]any, _ claude.CanUseToolOptions) (claude.PermissionResult, error) {
+		req := Request{AgentID: agentID, Tool: toolName, Input: input, WorkDir: workDir}
+		allow, message := b.Decide(ctx, req)
+		if allow {
+			return claude.NewPermissionResultAllow(), nil
+		}
+		return claude.NewPermissionResultDeny(message), nil
+	}
+}
+
+// Decide evaluates req, waiting for the human when the policy asks.
+// It returns whether the tool may run and, when denied, why.
+func (b *Broker) Decide(ctx context.Context, req Request) (bool, string) {
+	verdict := b.policy.Evaluate(req)
+	switch verdict.Decision {
+	case Allow:
+		return true, ""
+	case Deny:
+		log.Printf("[permission] %s denied %s: %s", req.AgentID, req.Tool, verdict.Reason)
+		return false, verdict.Reason
+	}
+	b.mu.Lock()
+	unattended := b.unattended
+	b.mu.Unlock()
+	if unattended {
+		log.Printf("[permission] %s denied %s without a human to ask: %s", req.AgentID, req.Tool, verdict.Reason)
+		return false, "no human available to approve: " + verdict.Reason
+	}
+
+	p := b.escalate(req, verdict.Reason)
+	defer b.remove(p.escalation.ID)
+
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+
+	select {
+	case a := <-p.answer:
+		if a.allow {
+			return true, ""
+		}
+		if a.message == "" {
+			a.message = "denied by user"
+		}
+		return false, a.message
+	case <-timer.C:
+		log.Printf("[permission] %s escalation %s timed out", req.AgentID, p.escalation.ID)
+		return false, fmt.Sprintf("no answer within %s", b.timeout)
+	case <-ctx.Done():
+		return false, ctx.Err().Error()
+	}
+}
+
+// Pending returns escalations waiting for a decision, oldest first.
+func (b *Broker) Pending() []Escalation {
+	b.mu.Lock()
+	list := make([]Escalation, 0, len(b.pending))
+	for _, p := range b.pending {
+		list = append(list, p.escalation)
+	}
+	b.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt) ||
+			(list[i].CreatedAt.Equal(list[j].CreatedAt) && list[i].ID < list[j].ID)
+	})
+	return list
+}
+
+// Resolve answers a pending escalation. message explains a denial to the agent.
+func (b *Broker) Resolve(id string, allow bool, message string) error {
+	b.mu.Lock()
+	p, ok := b.pending[id]
+	if ok {
+		delete(b.pending, id)
+	}
+	b.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	p.answer <- answer{allow: allow, message: message}
+	return nil
+}
+
+// escalate records a new pending escalation.
+func (b *Broker) escalate(req Request, reason string) *pending {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	now := b.now()
+	p := &pending{
+		escalation: Escalation{
+			ID:        fmt.Sprintf("perm-%03d", b.nextID),
+			Request:   req,
+			Reason:    reason,
+			CreatedAt: now,
+			ExpiresAt: now.Add(b.timeout),
+		},
+		answer: make(chan answer, 1),
+	}
+	b.pending[p.escalation.ID] = p
+	log.Printf("[permission] %s escalated %s: %s", req.AgentID, p.escalation.ID, reason)
+	return p
+}
+
+// remove forgets an escalation once its waiter returns.
+func (b *Broker) remove(id string) {
+	b.mu.Lock()
+	delete(b.pending, id)
+	b.mu.Unlock()
+}
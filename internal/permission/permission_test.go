@@ -0,0 +1,272 @@
+package permission
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
+)
+
+func TestEvaluate_DefaultPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+	ws := "/repo/.workspaces/task-001"
+
+	tests := []struct {
+		name  string
+		tool  string
+		input map[string]any
+		want  Decision
+	}{
+		{"read inside workspace", "Read", map[string]any{"file_path": ws + "/main.go"}, Allow},
+		{"relative edit", "Edit", map[string]any{"file_path": "internal/x.go"}, Allow},
+		{"grep without path", "Grep", map[string]any{"pattern": "TODO"}, Allow},
+		{"read outside workspace", "Read", map[string]any{"file_path": "/etc/passwd"}, Deny},
+		{"escape with dots", "Write", map[string]any{"file_path": "../../secrets"}, Deny},
+		{"denied glob", "Read", map[string]any{"file_path": ws + "/.env"}, Deny},
+		{"git internals", "Write", map[string]any{"file_path": ".git/config"}, Deny},
+		{"allowed command", "Bash", map[string]any{"command": "go test ./..."}, Allow},
+		{"allowed chain", "Bash", map[string]any{"command": "go build ./... && go vet ./..."}, Allow},
+		{"denied command", "Bash", map[string]any{"command": "git push origin main"}, Deny},
+		{"denied part of chain", "Bash", map[string]any{"command": "go test ./... ; curl http://x"}, Deny},
+		{"unknown command", "Bash", map[string]any{"command": "docker run alpine"}, Ask},
+		{"background job", "Bash", map[string]any{"command": "ls & curl http://evil"}, Deny},
+		{"background job of allowed commands", "Bash", map[string]any{"command": "ls & pwd"}, Ask},
+		{"command substitution", "Bash", map[string]any{"command": "echo $(curl http://evil)"}, Ask},
+		{"backticks", "Bash", map[string]any{"command": "echo `wget x`"}, Ask},
+		{"find -exec", "Bash", map[string]any{"command": "find . -exec curl http://x {} ;"}, Ask},
+		{"go test -exec", "Bash", map[string]any{"command": "go test -exec=./run ./..."}, Ask},
+		{"redirect", "Bash", map[string]any{"command": "echo hi > ~/.bashrc"}, Ask},
+		{"subshell", "Bash", map[string]any{"command": "(cd /tmp && ls)"}, Ask},
+		{"read home file", "Bash", map[string]any{"command": "cat ~/.ssh/id_rsa"}, Deny},
+		{"read outside workspace", "Bash", map[string]any{"command": "head -n 5 /etc/passwd"}, Deny},
+		{"read denied file", "Bash", map[string]any{"command": "cat .env"}, Deny},
+		{"read path from variable", "Bash", map[string]any{"command": "cat $HOME/.netrc"}, Ask},
+		{"find outside workspace", "Bash", map[string]any{"command": "find / -name '*.pem'"}, Deny},
+		{"find inside workspace", "Bash", map[string]any{"command": "find . -name '*.go'"}, Allow},
+		{"read inside workspace", "Bash", map[string]any{"command": "grep -rn TODO internal | wc -l"}, Allow},
+		{"ls-like command", "Bash", map[string]any{"command": "lsof -i"}, Ask},
+		{"glob pattern outside workspace", "Glob", map[string]any{"pattern": "/etc/*"}, Deny},
+		{"glob pattern escaping path", "Glob", map[string]any{"path": "internal", "pattern": "../../*"}, Deny},
+		{"glob pattern inside workspace", "Glob", map[string]any{"pattern": "**/*.go"}, Allow},
+		{"network tool", "WebFetch", map[string]any{"url": "https://example.com"}, Deny},
+		{"allowed tool", "TodoWrite", nil, Allow},
+		{"unknown tool", "Task", nil, Ask},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Evaluate(Request{AgentID: "echo-001", Tool: tt.tool, Input: tt.input, WorkDir: ws})
+			if got.Decision != tt.want {
+				t.Errorf("got %s (%s), want %s", got.Decision, got.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate_SymlinkOutOfWorkspace(t *testing.T) {
+	policy := DefaultPolicy()
+	ws, outside := t.TempDir(), t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(ws, "link")); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(ws, "src"), 0o755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(ws, "src"), filepath.Join(ws, "inner")); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		tool  string
+		input map[string]any
+		want  Decision
+	}{
+		{"read through link", "Read", map[string]any{"file_path": filepath.Join(ws, "link", "secret")}, Deny},
+		{"write new file through link", "Write", map[string]any{"file_path": "link/new/file.txt"}, Deny},
+		{"cat through link", "Bash", map[string]any{"command": "cat link/secret"}, Deny},
+		{"link inside workspace", "Edit", map[string]any{"file_path": "inner/main.go"}, Allow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Evaluate(Request{AgentID: "echo-001", Tool: tt.tool, Input: tt.input, WorkDir: ws})
+			if got.Decision != tt.want {
+				t.Errorf("got %s (%s), want %s", got.Decision, got.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestWildcard(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"go test*", "go test ./...", true},
+		{"go test*", "go tes", false},
+		{"git * --force", "git push origin --force", true},
+		{"pwd", "pwd", true},
+		{"pwd", "pwdx", false},
+	}
+	for _, tt := range tests {
+		if got := wildcard(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("wildcard(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	p, err := LoadPolicy(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Network) == 0 {
+		t.Error("expected default policy for missing file")
+	}
+
+	path := filepath.Join(dir, "permissions.json")
+	if err := os.WriteFile(path, []byte(`{"bash":{"allow":["make*"]},"escalationTimeout":"30s"}`), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	p, err = LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d, _ := p.Timeout(); d != 30*time.Second {
+		t.Errorf("got timeout %s, want 30s", d)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"escalationTimeout":"soon"}`), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := LoadPolicy(path); err == nil {
+		t.Error("expected error for invalid timeout")
+	}
+}
+
+func TestWriteDefaultPolicy_RoundTripsAndKeepsEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".axiom", "permissions.json")
+
+	if err := WriteDefaultPolicy(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Bash.Allow) != len(DefaultPolicy().Bash.Allow) {
+		t.Error("written policy should match the default")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"network":[]}`), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := WriteDefaultPolicy(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if string(content) != `{"network":[]}` {
+		t.Error("existing policy was overwritten")
+	}
+}
+
+func TestBroker_EscalationResolved(t *testing.T) {
+	b, err := NewBroker(DefaultPolicy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := Request{AgentID: "echo-001", Tool: "Bash", Input: map[string]any{"command": "docker ps"}, WorkDir: "/ws"}
+
+	done := make(chan bool)
+	go func() {
+		allow, _ := b.Decide(context.Background(), req)
+		done <- allow
+	}()
+
+	esc := waitForPending(t, b)
+	if esc.Request.AgentID != "echo-001" || esc.Reason == "" {
+		t.Errorf("unexpected escalation: %+v", esc)
+	}
+	if err := b.Resolve(esc.ID, true, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !<-done {
+		t.Error("expected request allowed after approval")
+	}
+	if len(b.Pending()) != 0 {
+		t.Error("expected no pending escalations")
+	}
+	if err := b.Resolve(esc.ID, true, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestBroker_EscalationTimesOut(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.EscalationTimeout = "20ms"
+	b, err := NewBroker(policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	allow, reason := b.Decide(context.Background(), Request{Tool: "Task", WorkDir: "/ws"})
+	if allow {
+		t.Error("expected timed-out escalation to be denied")
+	}
+	if reason == "" {
+		t.Error("expected a denial reason")
+	}
+}
+
+func TestBroker_UnattendedDeniesWithoutWaiting(t *testing.T) {
+	b, err := NewBroker(DefaultPolicy())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b.SetUnattended(true)
+
+	allow, reason := b.Decide(context.Background(), Request{Tool: "Task", WorkDir: "/ws"})
+	if allow || !strings.Contains(reason, "no human available") {
+		t.Errorf("got allow %v, reason %q, want an immediate denial", allow, reason)
+	}
+	if len(b.Pending()) != 0 {
+		t.Error("expected no escalation while unattended")
+	}
+}
+
+func TestBroker_HandlerDeniesWithoutEscalating(t *testing.T) {
+	b, _ := NewBroker(DefaultPolicy())
+
+	result, err := b.Handler("echo-001", "/ws")(context.Background(), "WebSearch", map[string]any{"query": "x"}, claude.CanUseToolOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Behavior != "deny" {
+		t.Errorf("got behavior %q, want deny", result.Behavior)
+	}
+	if len(b.Pending()) != 0 {
+		t.Error("expected no escalation for a policy denial")
+	}
+}
+
+// waitForPending waits for the broker to record one escalation.
+func waitForPending(t *testing.T, b *Broker) Escalation {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if list := b.Pending(); len(list) == 1 {
+			return list[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("escalation never appeared")
+	return Escalation{}
+}
@@ -0,0 +1,424 @@
+// Package permission decides whether agents may use a tool, escalating
+// ambiguous requests to the human.
+package permission
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deligoez/axiom/internal/pathglob"
+)
+
+// DefaultEscalationTimeout is how long an escalated request waits for a human before it is denied.
+const DefaultEscalationTimeout = 5 * time.Minute
+
+// Decision is the outcome of evaluating a tool-use request.
+type Decision string
+
+const (
+	// Allow approves the request without asking.
+	Allow Decision = "allow"
+	// Deny rejects the request without asking.
+	Deny Decision = "deny"
+	// Ask escalates the request to the human.
+	Ask Decision = "ask"
+)
+
+// Rules is a pair of allow and deny patterns. Deny always wins.
+type Rules struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Policy is the permission policy, read from .axiom/permissions.json.
+type Policy struct {
+	// Paths are globs relative to the agent's workspace for file tools.
+	// Paths outside the workspace are always denied.
+	Paths Rules `json:"paths"`
+
+	// Bash are command patterns where "*" matches any text.
+	// Chained commands (&&, ||, ;, |) must allow every part. Commands with
+	// background jobs, substitutions, redirects or subshells are always escalated,
+	// and the paths read commands name are checked like file tool paths.
+	Bash Rules `json:"bash"`
+
+	// Tools lists other tools (e.g. "TodoWrite") to allow or deny outright.
+	Tools Rules `json:"tools"`
+
+	// Network lists tools that reach the network. They are always denied.
+	Network []string `json:"network"`
+
+	// EscalationTimeout is how long to wait for a human (e.g. "5m"). Unanswered requests are denied.
+	EscalationTimeout string `json:"escalationTimeout,omitempty"`
+}
+
+// fileTools are tools whose input names a path, with the input keys holding it.
+var fileTools = map[string][]string{
+	"Read":         {"file_path"},
+	"Write":        {"file_path"},
+	"Edit":         {"file_path"},
+	"MultiEdit":    {"file_path"},
+	"NotebookEdit": {"notebook_path"},
+	"Glob":         {"path"},
+	"Grep":         {"path"},
+	"LS":           {"path"},
+}
+
+// DefaultPolicy returns the policy used when no permissions.json exists.
+func DefaultPolicy() Policy {
+	return Policy{
+		Paths: Rules{
+			Allow: []string{"**"},
+			Deny:  []string{".git/**", ".env", ".env.*", "*.pem", "*.key", ".axiom/config.json"},
+		},
+		Bash: Rules{
+			Allow: []string{
+				"ls", "ls *", "cat *", "head *", "tail *", "wc *", "grep *", "find *", "pwd", "echo", "echo *",
+				"git status*", "git diff*", "git log*", "git show*", "git add *", "git commit *",
+				"go build*", "go test*", "go vet*", "go fmt*", "gofmt *", "golangci-lint *",
+				"make *", "npm test*", "npm run *", "pytest*",
+			},
+			Deny: []string{
+				"sudo *", "rm -rf /*", "rm -rf ~*", "git push*", "git reset --hard*",
+				"curl *", "wget *", "ssh *", "scp *", "nc *",
+			},
+		},
+		Tools: Rules{
+			Allow: []string{"TodoWrite"},
+		},
+		Network:           []string{"WebFetch", "WebSearch"},
+		EscalationTimeout: DefaultEscalationTimeout.String(),
+	}
+}
+
+// LoadPolicy reads a policy file. A missing file yields DefaultPolicy.
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultPolicy(), nil
+	}
+	if err != nil {
+		return Policy{}, fmt.Errorf("read permissions: %w", err)
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("parse permissions: %w", err)
+	}
+	if _, err := p.Timeout(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// WriteDefaultPolicy writes DefaultPolicy to path unless a file already exists there.
+func WriteDefaultPolicy(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(DefaultPolicy(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("write permissions: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write permissions: %w", err)
+	}
+	return nil
+}
+
+// Timeout returns the escalation timeout, defaulting to DefaultEscalationTimeout.
+func (p Policy) Timeout() (time.Duration, error) {
+	if p.EscalationTimeout == "" {
+		return DefaultEscalationTimeout, nil
+	}
+	d, err := time.ParseDuration(p.EscalationTimeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid escalationTimeout %q", p.EscalationTimeout)
+	}
+	return d, nil
+}
+
+// Request is a tool-use request from an agent.
+type Request struct {
+	AgentID string         `json:"agentId"`
+	Tool    string         `json:"tool"`
+	Input   map[string]any `json:"input,omitempty"`
+
+	// WorkDir is the agent's workspace; file access is confined to it.
+	WorkDir string `json:"workDir"`
+}
+
+// Verdict is a decision with the reason it was reached.
+type Verdict struct {
+	Decision Decision
+	Reason   string
+}
+
+// Evaluate decides a request against the policy.
+func (p Policy) Evaluate(req Request) Verdict {
+	for _, tool := range p.Network {
+		if req.Tool == tool {
+			return Verdict{Deny, fmt.Sprintf("%s reaches the network", req.Tool)}
+		}
+	}
+
+	if keys, ok := fileTools[req.Tool]; ok {
+		return p.evaluatePath(req, keys)
+	}
+	if req.Tool == "Bash" {
+		command, _ := req.Input["command"].(string)
+		return p.evaluateCommand(command, req.WorkDir)
+	}
+
+	switch {
+	case matchesName(p.Tools.Deny, req.Tool):
+		return Verdict{Deny, fmt.Sprintf("%s is denied by policy", req.Tool)}
+	case matchesName(p.Tools.Allow, req.Tool):
+		return Verdict{Allow, fmt.Sprintf("%s is allowed by policy", req.Tool)}
+	}
+	return Verdict{Ask, fmt.Sprintf("%s is not covered by policy", req.Tool)}
+}
+
+// evaluatePath checks the path named by a file tool's input. A Glob pattern
+// names a directory of its own, which is checked as well.
+func (p Policy) evaluatePath(req Request, keys []string) Verdict {
+	target := ""
+	for _, key := range keys {
+		if s, ok := req.Input[key].(string); ok && s != "" {
+			target = s
+			break
+		}
+	}
+	// Searches without a path run in the workspace itself.
+	if target == "" {
+		target = "."
+	}
+
+	verdict := p.checkPath(req.Tool, req.WorkDir, target)
+	if pattern, ok := req.Input["pattern"].(string); ok && req.Tool == "Glob" && verdict.Decision != Deny {
+		dir := globDir(pattern)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(target, dir)
+		}
+		if v := p.checkPath(req.Tool, req.WorkDir, dir); v.Decision != Allow {
+			return v
+		}
+	}
+	return verdict
+}
+
+// checkPath checks a single path against the workspace and the path rules.
+func (p Policy) checkPath(tool, workDir, target string) Verdict {
+	rel, ok := relativeTo(workDir, target)
+	if !ok {
+		return Verdict{Deny, fmt.Sprintf("%s is outside the workspace", target)}
+	}
+	switch {
+	case pathglob.MatchAny(p.Paths.Deny, rel):
+		return Verdict{Deny, fmt.Sprintf("%s is denied by policy", rel)}
+	case rel == "." || pathglob.MatchAny(p.Paths.Allow, rel):
+		return Verdict{Allow, fmt.Sprintf("%s %s is allowed by policy", tool, rel)}
+	}
+	return Verdict{Ask, fmt.Sprintf("%s %s is not covered by policy", tool, rel)}
+}
+
+// globDir returns the directory a glob pattern searches: the part before its
+// first wildcard, or the pattern itself when it has none.
+func globDir(pattern string) string {
+	i := strings.IndexAny(pattern, "*?[{")
+	if i < 0 {
+		return pattern
+	}
+	return filepath.Dir(pattern[:i])
+}
+
+// evaluateCommand checks every part of a shell command run in workDir.
+func (p Policy) evaluateCommand(command, workDir string) Verdict {
+	parts := splitCommand(command)
+	if len(parts) == 0 {
+		return Verdict{Ask, "empty command"}
+	}
+	for _, part := range parts {
+		if matchesCommand(p.Bash.Deny, part) {
+			return Verdict{Deny, fmt.Sprintf("%q is denied by policy", part)}
+		}
+	}
+	// What these constructs run or write is not visible in the command's parts.
+	if construct := shellConstruct(command); construct != "" {
+		return Verdict{Ask, fmt.Sprintf("%q uses %s", command, construct)}
+	}
+
+	verdict := Verdict{Allow, fmt.Sprintf("%q is allowed by policy", command)}
+	for _, part := range parts {
+		if !matchesCommand(p.Bash.Allow, part) {
+			verdict = Verdict{Ask, fmt.Sprintf("%q is not covered by policy", part)}
+			continue
+		}
+		switch v := p.checkArgs(part, workDir); v.Decision {
+		case Deny:
+			return v
+		case Ask:
+			verdict = v
+		}
+	}
+	return verdict
+}
+
+// shellConstructs are the shell syntax a command's parts can't be judged
+// without, with their names.
+var shellConstructs = []struct{ syntax, name string }{
+	{"&", "a background job"},
+	{"$(", "command substitution"},
+	{"`", "command substitution"},
+	{"<", "a redirect"},
+	{">", "a redirect"},
+	{"(", "a subshell"},
+	{")", "a subshell"},
+}
+
+// execFlags run another command from within an allowed one (find -exec,
+// go test -exec, go build -toolexec).
+var execFlags = map[string]bool{"exec": true, "execdir": true, "ok": true, "okdir": true, "toolexec": true}
+
+// shellConstruct names the first construct command uses that hides what it does,
+// or returns "" when it uses none. "&&" is a chain, not a background job.
+func shellConstruct(command string) string {
+	plain := strings.ReplaceAll(command, "&&", "")
+	for _, c := range shellConstructs {
+		if strings.Contains(plain, c.syntax) {
+			return c.name
+		}
+	}
+	for _, field := range strings.Fields(command) {
+		if !strings.HasPrefix(field, "-") {
+			continue
+		}
+		flag, _, _ := strings.Cut(strings.TrimLeft(field, "-"), "=")
+		if execFlags[flag] {
+			return field
+		}
+	}
+	return ""
+}
+
+// readCommands are allowed commands whose operands are paths to read.
+var readCommands = map[string]bool{"ls": true, "cat": true, "head": true, "tail": true, "wc": true, "grep": true, "find": true}
+
+// checkArgs checks the paths a read command names against the workspace and the
+// path rules. Other commands are allowed as they are.
+func (p Policy) checkArgs(part, workDir string) Verdict {
+	fields := strings.Fields(part)
+	if len(fields) == 0 || !readCommands[fields[0]] {
+		return Verdict{Allow, fmt.Sprintf("%q is allowed by policy", part)}
+	}
+	for _, arg := range fields[1:] {
+		arg = strings.Trim(arg, `"'`)
+		if strings.HasPrefix(arg, "-") || arg == "!" {
+			if fields[0] == "find" {
+				break // find's expression follows its paths
+			}
+			continue
+		}
+		switch {
+		case strings.Contains(arg, "$"):
+			return Verdict{Ask, fmt.Sprintf("%q reads a path from a variable", part)}
+		case strings.HasPrefix(arg, "~"):
+			return Verdict{Deny, fmt.Sprintf("%s is outside the workspace", arg)}
+		}
+		if v := p.checkPath(fields[0], workDir, arg); v.Decision != Allow {
+			return v
+		}
+	}
+	return Verdict{Allow, fmt.Sprintf("%q is allowed by policy", part)}
+}
+
+// relativeTo returns target relative to workDir, and false if it lies outside.
+// Symlinks are resolved on both sides, so a link can't lead out of the workspace.
+func relativeTo(workDir, target string) (string, bool) {
+	if workDir == "" {
+		return "", false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(workDir, target)
+	}
+	rel, err := filepath.Rel(resolve(workDir), resolve(target))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// resolve returns path with symlinks evaluated. The part of the path that does
+// not exist yet, e.g. a file about to be written, is kept as it is.
+func resolve(path string) string {
+	path = filepath.Clean(path)
+	var rest []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{real}, rest...)...)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return path
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+	}
+}
+
+// splitCommand splits a shell command on &&, ||, ;, | and & into trimmed parts.
+func splitCommand(command string) []string {
+	replacer := strings.NewReplacer("&&", "\n", "||", "\n", ";", "\n", "|", "\n", "&", "\n")
+	var parts []string
+	for _, part := range strings.Split(replacer.Replace(command), "\n") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// matchesCommand reports whether command matches any pattern, where "*" matches any text.
+func matchesCommand(patterns []string, command string) bool {
+	for _, pattern := range patterns {
+		if wildcard(pattern, command) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesName reports whether name is listed.
+func matchesName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcard matches s against pattern, where "*" matches any run of characters.
+func wildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
@@ -0,0 +1,270 @@
+// Package persona loads AXIOM agent personas and assembles their system prompts.
+package persona
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/deligoez/axiom/internal/agent"
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/skill"
+)
+
+// Persona names used by AXIOM agents.
+const (
+	Ava  = "ava"
+	Axel = "axel"
+	Echo = "echo"
+	Rex  = "rex"
+	Cleo = "cleo"
+	Dex  = "dex"
+	Max  = "max"
+	Ash  = "ash"
+)
+
+// Names lists all personas in documented order.
+var Names = []string{Ava, Axel, Echo, Rex, Cleo, Dex, Max, Ash}
+
+// ErrUnknownPersona is returned when a persona name is not one of Names.
+var ErrUnknownPersona = errors.New("unknown persona")
+
+// ErrNoPrompt is returned when a persona has neither a prompt.md nor an embedded default.
+var ErrNoPrompt = errors.New("no prompt for persona")
+
+// Valid reports whether name is a known persona.
+func Valid(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PromptData holds the task and case context rendered into prompt templates.
+type PromptData struct {
+	Persona string
+	AgentID string
+	TaskID  string
+	WorkDir string
+	Case    *casestore.Case
+
+	// Files are paths the task is expected to touch, used for skill matching.
+	Files []string
+}
+
+// Part is a single prompt source with its origin.
+type Part struct {
+	// Source is the file path, or "embedded:<name>" for built-in defaults.
+	Source string
+
+	// Content is the raw template text.
+	Content string
+}
+
+// Persona is a loaded persona with its prompt parts in assembly order.
+type Persona struct {
+	Name  string
+	Parts []Part
+
+	// Skills holds shared and persona skills; matching ones are rendered after Parts.
+	Skills *skill.Registry
+
+	// SkillBudget caps the tokens spent on skills (skill.DefaultBudget if zero).
+	SkillBudget int
+}
+
+// Loader reads persona files from an .axiom/ directory.
+type Loader struct {
+	axiomDir string
+
+	// SkillBudget caps the tokens spent on skills per prompt (skill.DefaultBudget if zero).
+	SkillBudget int
+}
+
+// NewLoader creates a Loader rooted at the given .axiom/ directory.
+func NewLoader(axiomDir string) *Loader {
+	return &Loader{axiomDir: axiomDir}
+}
+
+// Load reads the persona's prompt sources in the documented order:
+//  1. .axiom/rules/*.md              (shared rules)
+//  2. .axiom/agents/{name}/rules.md  (or the embedded default)
+//  3. .axiom/agents/{name}/prompt.md (or the embedded default)
+//  4. .axiom/skills/*.md and .axiom/agents/{name}/skills/*.md (matched at render time)
+func (l *Loader) Load(name string) (*Persona, error) {
+	if !Valid(name) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPersona, name)
+	}
+
+	agentDir := filepath.Join(l.axiomDir, "agents", name)
+	p := &Persona{Name: name, SkillBudget: l.SkillBudget}
+
+	shared, err := readDir(filepath.Join(l.axiomDir, "rules"))
+	if err != nil {
+		return nil, fmt.Errorf("read shared rules: %w", err)
+	}
+	p.Parts = append(p.Parts, shared...)
+
+	rules, err := readOptional(filepath.Join(agentDir, "rules.md"))
+	if err != nil {
+		return nil, fmt.Errorf("read %s rules: %w", name, err)
+	}
+	if rules == nil {
+		if def, ok := agent.DefaultRules(name); ok {
+			rules = &Part{Source: "embedded:" + name + "/rules", Content: def}
+		}
+	}
+	if rules != nil {
+		p.Parts = append(p.Parts, *rules)
+	}
+
+	prompt, err := readOptional(filepath.Join(agentDir, "prompt.md"))
+	if err != nil {
+		return nil, fmt.Errorf("read %s prompt: %w", name, err)
+	}
+	if prompt == nil {
+		def, ok := agent.DefaultPrompt(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNoPrompt, name)
+		}
+		prompt = &Part{Source: "embedded:" + name, Content: def}
+	}
+	p.Parts = append(p.Parts, *prompt)
+
+	skills, err := skill.Load(l.axiomDir, name)
+	if err != nil {
+		return nil, fmt.Errorf("read %s skills: %w", name, err)
+	}
+	p.Skills = skills
+
+	return p, nil
+}
+
+// Render executes each part and each matched skill as a Go template with data
+// and joins the results. Case context is appended verbatim after the templated parts.
+func (p *Persona) Render(data PromptData) (string, error) {
+	if data.Persona == "" {
+		data.Persona = p.Name
+	}
+
+	parts := append([]Part(nil), p.Parts...)
+	for _, s := range p.MatchSkills(data) {
+		parts = append(parts, Part{Source: s.Path, Content: s.Content})
+	}
+
+	sections := make([]string, 0, len(parts)+1)
+	for _, part := range parts {
+		tmpl, err := template.New(part.Source).Option("missingkey=zero").Parse(part.Content)
+		if err != nil {
+			return "", fmt.Errorf("parse %s: %w", part.Source, err)
+		}
+
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("render %s: %w", part.Source, err)
+		}
+		if s := strings.TrimSpace(b.String()); s != "" {
+			sections = append(sections, s)
+		}
+	}
+
+	if data.Case != nil {
+		sections = append(sections, caseContext(data.Case))
+	}
+
+	return strings.Join(sections, "\n\n---\n\n"), nil
+}
+
+// MatchSkills selects the skills relevant to data within the persona's skill budget.
+func (p *Persona) MatchSkills(data PromptData) []*skill.Skill {
+	if p.Skills == nil {
+		return nil
+	}
+
+	q := skill.Query{Files: data.Files}
+	if data.Case != nil {
+		q.Labels = data.Case.Labels
+		q.Content = data.Case.Content
+	}
+	return p.Skills.Select(q, p.SkillBudget)
+}
+
+// SystemPrompt loads and renders the named persona.
+func (l *Loader) SystemPrompt(name string, data PromptData) (string, error) {
+	p, err := l.Load(name)
+	if err != nil {
+		return "", err
+	}
+	return p.Render(data)
+}
+
+// Configure sets config.SystemPrompt and the persona's tool policy on config.
+// TaskID, AgentID and WorkDir default to the values already on config.
+func (l *Loader) Configure(config *agent.AgentConfig, name string, data PromptData) error {
+	if data.TaskID == "" {
+		data.TaskID = config.TaskID
+	}
+	if data.AgentID == "" {
+		data.AgentID = config.AgentID
+	}
+	if data.WorkDir == "" {
+		data.WorkDir = config.WorkDir
+	}
+
+	prompt, err := l.SystemPrompt(name, data)
+	if err != nil {
+		return err
+	}
+	config.SystemPrompt = prompt
+	return agent.ApplyToolPolicy(config, name)
+}
+
+// caseContext formats a case as the prompt's case context section.
+func caseContext(c *casestore.Case) string {
+	var b strings.Builder
+	b.WriteString("# Case Context\n\n")
+	fmt.Fprintf(&b, "- ID: %s\n", c.ID)
+	fmt.Fprintf(&b, "- Type: %s\n", c.Type)
+	fmt.Fprintf(&b, "- Status: %s\n", c.Status)
+	b.WriteString("\n")
+	b.WriteString(strings.TrimSpace(c.Content))
+	return b.String()
+}
+
+// readOptional reads a file, returning nil if it does not exist.
+func readOptional(path string) (*Part, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Part{Source: path, Content: string(data)}, nil
+}
+
+// readDir reads all *.md files in dir sorted by name.
+// A missing directory yields no parts.
+func readDir(dir string) ([]Part, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	parts := make([]Part, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, Part{Source: path, Content: string(data)})
+	}
+	return parts, nil
+}
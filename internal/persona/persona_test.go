@@ -0,0 +1,173 @@
+package persona
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/agent"
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+}
+
+func TestLoad_UnknownPersona(t *testing.T) {
+	loader := NewLoader(t.TempDir())
+
+	_, err := loader.Load("bob")
+	if !errors.Is(err, ErrUnknownPersona) {
+		t.Errorf("expected ErrUnknownPersona, got %v", err)
+	}
+}
+
+func TestLoad_FallsBackToEmbeddedDefaults(t *testing.T) {
+	loader := NewLoader(t.TempDir())
+
+	for _, name := range Names {
+		p, err := loader.Load(name)
+		if err != nil {
+			t.Fatalf("load %s: unexpected error: %v", name, err)
+		}
+		if len(p.Parts) != 2 {
+			t.Fatalf("load %s: expected rules and prompt parts, got %d", name, len(p.Parts))
+		}
+		if p.Parts[1].Source != "embedded:"+name {
+			t.Errorf("load %s: expected embedded prompt source, got %s", name, p.Parts[1].Source)
+		}
+	}
+}
+
+func TestLoad_AvaDefaultIsEmbeddedTemplate(t *testing.T) {
+	p, err := NewLoader(t.TempDir()).Load(Ava)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Parts[1].Content != agent.AvaPromptTemplate {
+		t.Error("expected embedded Ava template content")
+	}
+}
+
+func TestLoad_OrderAndOverride(t *testing.T) {
+	axiomDir := t.TempDir()
+	writeFile(t, filepath.Join(axiomDir, "rules", "b-commit.md"), "shared B")
+	writeFile(t, filepath.Join(axiomDir, "rules", "a-signals.md"), "shared A")
+	writeFile(t, filepath.Join(axiomDir, "agents", "echo", "rules.md"), "echo rules")
+	writeFile(t, filepath.Join(axiomDir, "agents", "echo", "prompt.md"), "echo prompt")
+	writeFile(t, filepath.Join(axiomDir, "agents", "echo", "skills", "tdd.md"), "tdd skill")
+
+	p, err := NewLoader(axiomDir).Load(Echo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, part := range p.Parts {
+		got = append(got, part.Content)
+	}
+	want := []string{"shared A", "shared B", "echo rules", "echo prompt"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got parts %v, want %v", got, want)
+	}
+
+	prompt, err := p.Render(PromptData{})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if !strings.HasSuffix(prompt, "tdd skill") {
+		t.Errorf("expected skill after prompt, got %q", prompt)
+	}
+}
+
+func TestRender_OnlyMatchedSkills(t *testing.T) {
+	axiomDir := t.TempDir()
+	writeFile(t, filepath.Join(axiomDir, "agents", "echo", "prompt.md"), "echo prompt")
+	writeFile(t, filepath.Join(axiomDir, "skills", "security.md"),
+		"# Security Review\n\n## When to Use\n\n- Labels: security\n")
+	writeFile(t, filepath.Join(axiomDir, "agents", "echo", "skills", "sql.md"),
+		"# SQL Migrations\n\n## When to Use\n\n- Files: migrations/*.sql\n")
+
+	c := &casestore.Case{ID: "task-009", Content: "Harden login", Labels: []string{"security"}}
+	prompt, err := NewLoader(axiomDir).SystemPrompt(Echo, PromptData{Case: c})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(prompt, "Security Review") {
+		t.Error("expected label-matched skill in prompt")
+	}
+	if strings.Contains(prompt, "SQL Migrations") {
+		t.Error("expected unmatched skill to be excluded")
+	}
+}
+
+func TestRender_TemplateAndCaseContext(t *testing.T) {
+	axiomDir := t.TempDir()
+	writeFile(t, filepath.Join(axiomDir, "agents", "echo", "prompt.md"),
+		"You are {{.AgentID}} working on {{.TaskID}} in {{.WorkDir}}.")
+
+	c := &casestore.Case{
+		ID:      "task-001",
+		Type:    casestore.CaseTypeTask,
+		Status:  casestore.StatusActive,
+		Content: "Add login {{validation}}",
+	}
+	prompt, err := NewLoader(axiomDir).SystemPrompt(Echo, PromptData{
+		AgentID: "echo-001",
+		TaskID:  "task-001",
+		WorkDir: "/work/task-001",
+		Case:    c,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(prompt, "You are echo-001 working on task-001 in /work/task-001.") {
+		t.Errorf("template not rendered: %s", prompt)
+	}
+	// Case content is appended verbatim, never executed as a template.
+	if !strings.Contains(prompt, "Add login {{validation}}") {
+		t.Errorf("case context missing: %s", prompt)
+	}
+}
+
+func TestRender_InvalidTemplateNamesSource(t *testing.T) {
+	axiomDir := t.TempDir()
+	promptPath := filepath.Join(axiomDir, "agents", "rex", "prompt.md")
+	writeFile(t, promptPath, "broken {{.TaskID")
+
+	_, err := NewLoader(axiomDir).SystemPrompt(Rex, PromptData{})
+	if err == nil {
+		t.Fatal("expected parse error")
+	}
+	if !strings.Contains(err.Error(), promptPath) {
+		t.Errorf("expected error to name %s, got %v", promptPath, err)
+	}
+}
+
+func TestConfigure_SetsSystemPrompt(t *testing.T) {
+	axiomDir := t.TempDir()
+	writeFile(t, filepath.Join(axiomDir, "agents", "echo", "prompt.md"), "Task {{.TaskID}} by {{.Persona}}")
+
+	config := &agent.AgentConfig{TaskID: "task-042", AgentID: "echo-007"}
+	if err := NewLoader(axiomDir).Configure(config, Echo, PromptData{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasSuffix(config.SystemPrompt, "Task task-042 by echo") {
+		t.Errorf("got SystemPrompt %q", config.SystemPrompt)
+	}
+	if !slices.Contains(config.AllowedTools, "Bash") {
+		t.Errorf("expected echo tool policy applied, got AllowedTools %v", config.AllowedTools)
+	}
+}
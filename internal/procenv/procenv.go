@@ -0,0 +1,23 @@
+// Package procenv builds the environment of the commands AXIOM runs: hooks and
+// verification commands.
+package procenv
+
+import (
+	"os"
+	"sort"
+)
+
+// With returns AXIOM's environment with env added as KEY=value pairs in key order.
+func With(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := os.Environ()
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+env[k])
+	}
+	return pairs
+}
@@ -0,0 +1,157 @@
+// Package registry assigns agent IDs and tracks live AXIOM agents.
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/persona"
+)
+
+// lockTimeout bounds how long Next waits for another process's lock,
+// and how old a lock file must be before it is considered stale.
+const lockTimeout = 10 * time.Second
+
+// Counters persists per-persona spawn counters (e.g. .axiom/metrics/counters.json).
+// Counters only ever increase, so IDs are never reused across restarts.
+type Counters struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewCounters creates a Counters backed by the file at path.
+func NewCounters(path string) *Counters {
+	return &Counters{path: path}
+}
+
+// Load returns the current counters. A missing file yields all personas at 0;
+// invalid JSON is logged and treated the same way.
+func (c *Counters) Load() (map[string]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.read()
+}
+
+// Init creates the counters file with every persona at 0 if it does not exist.
+func (c *Counters) Init() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := os.Stat(c.path); err == nil {
+		return nil
+	}
+	counts, err := c.read()
+	if err != nil {
+		return err
+	}
+	return c.write(counts)
+}
+
+// Next increments the persona's counter and returns its new agent ID (e.g. "echo-047").
+func (c *Counters) Next(personaName string) (string, error) {
+	if !persona.Valid(personaName) {
+		return "", fmt.Errorf("%w: %q", persona.ErrUnknownPersona, personaName)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	unlock, err := c.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	counts, err := c.read()
+	if err != nil {
+		return "", err
+	}
+	counts[personaName]++
+	if err := c.write(counts); err != nil {
+		return "", err
+	}
+
+	return agent.FormatAgentID(personaName, counts[personaName]), nil
+}
+
+// read loads the counters file, filling in missing personas with 0.
+func (c *Counters) read() (map[string]int, error) {
+	counts := make(map[string]int, len(persona.Names))
+	for _, name := range persona.Names {
+		counts[name] = 0
+	}
+
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return counts, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read counters: %w", err)
+	}
+
+	var stored map[string]int
+	if err := json.Unmarshal(data, &stored); err != nil {
+		log.Printf("[WARN] invalid counters file %s, starting fresh: %v", c.path, err)
+		return counts, nil
+	}
+	for name, n := range stored {
+		counts[name] = n
+	}
+	return counts, nil
+}
+
+// write atomically replaces the counters file.
+func (c *Counters) write(counts map[string]int) error {
+	data, err := json.MarshalIndent(counts, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("create metrics dir: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write counters: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("write counters: %w", err)
+	}
+	return nil
+}
+
+// lock takes an exclusive lock file so concurrent AXIOM processes never hand out the same ID.
+func (c *Counters) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return nil, fmt.Errorf("create metrics dir: %w", err)
+	}
+
+	lockPath := c.path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("lock counters: %w", err)
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockTimeout {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("lock counters: timed out waiting for %s", lockPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
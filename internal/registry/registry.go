@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+)
+
+var (
+	// ErrNotFound is returned when no live agent has the requested ID.
+	ErrNotFound = errors.New("agent not found")
+	// ErrExists is returned when registering an ID that is already listed.
+	ErrExists = errors.New("agent already registered")
+)
+
+// StopFunc stops a running agent (typically cancels its context and closes the client).
+type StopFunc func() error
+
+// Info is a snapshot of a live agent.
+type Info struct {
+	ID        string      `json:"id"`
+	Persona   string      `json:"persona"`
+	TaskID    string      `json:"taskId,omitempty"`
+	WorkDir   string      `json:"workDir,omitempty"`
+	StartedAt time.Time   `json:"startedAt"`
+	State     agent.State `json:"state"`
+
+	// PID is the process ID of the agent's CLI child, or 0 if unknown.
+	PID int `json:"pid,omitempty"`
+}
+
+// entry is a tracked agent with its stop hook.
+type entry struct {
+	info Info
+	stop StopFunc
+}
+
+// Registry is the single source of truth about running agents,
+// shared by the web UI and the orchestrator.
+type Registry struct {
+	counters *Counters
+
+	mu     sync.Mutex
+	agents map[string]*entry
+	now    func() time.Time
+}
+
+// New creates a Registry that assigns IDs from counters.
+func New(counters *Counters) *Registry {
+	return &Registry{
+		counters: counters,
+		agents:   make(map[string]*entry),
+		now:      time.Now,
+	}
+}
+
+// Spawn assigns a new ID for persona and registers the agent in the idle state.
+// The returned Info carries the assigned ID for the caller's AgentConfig.
+func (r *Registry) Spawn(persona, taskID, workDir string, stop StopFunc) (Info, error) {
+	id, err := r.counters.Next(persona)
+	if err != nil {
+		return Info{}, fmt.Errorf("assign agent ID: %w", err)
+	}
+	return r.Register(id, persona, taskID, workDir, stop)
+}
+
+// Register lists an agent under an ID Spawn assigned earlier, in the idle state,
+// e.g. a conversational agent starting another run after it was removed. It
+// returns ErrExists while the ID is listed.
+func (r *Registry) Register(id, persona, taskID, workDir string, stop StopFunc) (Info, error) {
+	info := Info{
+		ID:        id,
+		Persona:   persona,
+		TaskID:    taskID,
+		WorkDir:   workDir,
+		StartedAt: r.now(),
+		State:     agent.StateIdle,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.agents[id]; ok {
+		return Info{}, fmt.Errorf("%w: %s", ErrExists, id)
+	}
+	r.agents[id] = &entry{info: info, stop: stop}
+	return info, nil
+}
+
+// SetStop replaces the stop hook for an agent, e.g. once its client exists.
+func (r *Registry) SetStop(id string, stop StopFunc) error {
+	return r.update(id, func(e *entry) { e.stop = stop })
+}
+
+// SetState records an agent's lifecycle state.
+func (r *Registry) SetState(id string, state agent.State) error {
+	return r.update(id, func(e *entry) { e.info.State = state })
+}
+
+// Track mirrors a state machine's transitions into the agent's Info until the
+// machine reaches a terminal state.
+func (r *Registry) Track(id string, m *agent.Machine) error {
+	events, cancel := m.Subscribe()
+	if err := r.SetState(id, m.State()); err != nil {
+		cancel()
+		return err
+	}
+
+	go func() {
+		for change := range events {
+			_ = r.SetState(id, change.To)
+		}
+	}()
+	return nil
+}
+
+// SetPID records the process ID of an agent's CLI child.
+func (r *Registry) SetPID(id string, pid int) error {
+	return r.update(id, func(e *entry) { e.info.PID = pid })
+}
+
+// Get returns a snapshot of one agent.
+func (r *Registry) Get(id string) (Info, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.agents[id]
+	if !ok {
+		return Info{}, false
+	}
+	return e.info, true
+}
+
+// List returns snapshots of all live agents ordered by start time, then ID.
+func (r *Registry) List() []Info {
+	r.mu.Lock()
+	list := make([]Info, 0, len(r.agents))
+	for _, e := range r.agents {
+		list = append(list, e.info)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].StartedAt.Equal(list[j].StartedAt) {
+			return list[i].StartedAt.Before(list[j].StartedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Stop calls the agent's stop hook and removes it from the registry.
+func (r *Registry) Stop(id string) error {
+	r.mu.Lock()
+	e, ok := r.agents[id]
+	if ok {
+		delete(r.agents, id)
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if e.stop != nil {
+		if err := e.stop(); err != nil {
+			return fmt.Errorf("stop %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// Remove forgets an agent that has already exited, without calling its stop hook.
+func (r *Registry) Remove(id string) {
+	r.mu.Lock()
+	delete(r.agents, id)
+	r.mu.Unlock()
+}
+
+// update applies fn to a tracked agent under the lock.
+func (r *Registry) update(id string, fn func(*entry)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.agents[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	fn(e)
+	return nil
+}
@@ -0,0 +1,231 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+)
+
+func TestCounters_NextIncrementsAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics", "counters.json")
+	counters := NewCounters(path)
+
+	for _, want := range []string{"echo-001", "echo-002"} {
+		id, err := counters.Next("echo")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if id != want {
+			t.Errorf("got ID %q, want %q", id, want)
+		}
+	}
+
+	// A fresh Counters on the same file continues where the last one stopped.
+	id, err := NewCounters(path).Next("echo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "echo-003" {
+		t.Errorf("got ID %q after restart, want echo-003", id)
+	}
+
+	data, _ := os.ReadFile(path)
+	var stored map[string]int
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("counters file is not valid JSON: %v", err)
+	}
+	if stored["echo"] != 3 || stored["ava"] != 0 || len(stored) != 8 {
+		t.Errorf("unexpected stored counters: %v", stored)
+	}
+}
+
+func TestCounters_UnknownPersona(t *testing.T) {
+	counters := NewCounters(filepath.Join(t.TempDir(), "counters.json"))
+
+	if _, err := counters.Next("bob"); err == nil {
+		t.Error("expected error for unknown persona")
+	}
+}
+
+func TestCounters_InvalidJSONStartsFresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+	if err := os.WriteFile(path, []byte("{broken"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	id, err := NewCounters(path).Next("rex")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "rex-001" {
+		t.Errorf("got ID %q, want rex-001", id)
+	}
+}
+
+func TestCounters_ConcurrentNextIsUnique(t *testing.T) {
+	counters := NewCounters(filepath.Join(t.TempDir(), "counters.json"))
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := counters.Next("echo")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			mu.Lock()
+			seen[id] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 20 {
+		t.Errorf("expected 20 unique IDs, got %d", len(seen))
+	}
+}
+
+func TestCounters_Init(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics", "counters.json")
+
+	if err := NewCounters(path).Init(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counts, err := NewCounters(path).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(counts) != 8 || counts["ash"] != 0 {
+		t.Errorf("unexpected initial counters: %v", counts)
+	}
+}
+
+func TestRegistry_SpawnListGet(t *testing.T) {
+	r := New(NewCounters(filepath.Join(t.TempDir(), "counters.json")))
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	tick := 0
+	r.now = func() time.Time {
+		tick++
+		return base.Add(time.Duration(tick) * time.Second)
+	}
+
+	first, err := r.Spawn("echo", "task-001", "/ws/task-001", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.Spawn("rex", "task-002", "/ws/merge", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first.ID != "echo-001" || first.State != agent.StateIdle {
+		t.Errorf("unexpected spawn info: %+v", first)
+	}
+
+	if err := r.SetState(first.ID, agent.StateRunning); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.SetPID(first.ID, 4242); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, ok := r.Get(first.ID)
+	if !ok || got.State != agent.StateRunning || got.PID != 4242 || got.TaskID != "task-001" {
+		t.Errorf("unexpected Get result: %+v (ok=%v)", got, ok)
+	}
+
+	list := r.List()
+	if len(list) != 2 || list[0].ID != "echo-001" || list[1].ID != "rex-001" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+}
+
+func TestRegistry_StopCallsHookAndRemoves(t *testing.T) {
+	r := New(NewCounters(filepath.Join(t.TempDir(), "counters.json")))
+
+	stopped := false
+	info, err := r.Spawn("echo", "task-001", "", func() error {
+		stopped = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.Stop(info.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !stopped {
+		t.Error("expected stop hook to be called")
+	}
+	if _, ok := r.Get(info.ID); ok {
+		t.Error("expected agent to be removed after stop")
+	}
+	if err := r.Stop(info.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound on second stop, got %v", err)
+	}
+}
+
+func TestRegistry_RegisterReusesID(t *testing.T) {
+	r := New(NewCounters(filepath.Join(t.TempDir(), "counters.json")))
+	info, err := r.Spawn("ava", "", "/project", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := r.Register(info.ID, "ava", "", "/project", nil); !errors.Is(err, ErrExists) {
+		t.Errorf("expected ErrExists while listed, got %v", err)
+	}
+	r.Remove(info.ID)
+	again, err := r.Register(info.ID, "ava", "", "/project", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if again.ID != "ava-001" || again.WorkDir != "/project" || again.State != agent.StateIdle {
+		t.Errorf("unexpected register info: %+v", again)
+	}
+	if next, err := r.Spawn("ava", "", "", nil); err != nil || next.ID != "ava-002" {
+		t.Errorf("got %+v (%v), want Spawn to keep counting", next, err)
+	}
+}
+
+func TestRegistry_TrackMirrorsMachine(t *testing.T) {
+	r := New(NewCounters(filepath.Join(t.TempDir(), "counters.json")))
+	info, err := r.Spawn("echo", "task-001", "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := agent.NewMachine(info.ID)
+	if err := r.Track(info.ID, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = m.Transition(agent.StateStarting, "")
+	_ = m.Transition(agent.StateRunning, "")
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, _ := r.Get(info.ID)
+		if got.State == agent.StateRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("registry state %s never reached running", got.State)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := r.Track("echo-999", m); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for unknown agent, got %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+// Package resolver resolves merge conflicts the integration queue cannot with a Rex agent.
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/agent"
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/integration"
+	"github.com/deligoez/axiom/internal/persona"
+	"github.com/deligoez/axiom/internal/registry"
+	"github.com/deligoez/axiom/internal/signal"
+)
+
+// ErrNotResolved is returned when Rex finishes without emitting RESOLVED.
+var ErrNotResolved = errors.New("rex finished without a RESOLVED signal")
+
+// Config configures the Rex resolver.
+type Config struct {
+	// Personas renders Rex's system prompt; without it Rex only gets its tool policy.
+	Personas *persona.Loader
+
+	// ProjectDir is the project root conflict worktrees must lie within.
+	ProjectDir string
+
+	// Model and Timeout apply to each Rex run.
+	Model   string
+	Timeout string
+
+	// Registry, when set, assigns Rex's agent ID and lists it while it works.
+	Registry *registry.Registry
+
+	// Cases looks up the case behind a task ID for the prompt; it may return nil.
+	Cases func(taskID string) *casestore.Case
+}
+
+// executeFunc runs one prompt on a new agent and returns the signals it emitted.
+type executeFunc func(ctx context.Context, config *agent.AgentConfig, prompt string) ([]signal.Signal, error)
+
+// Rex implements integration.Resolver by spawning a Rex agent in the conflict worktree.
+type Rex struct {
+	cfg     Config
+	execute executeFunc
+}
+
+var _ integration.Resolver = (*Rex)(nil)
+
+// New returns a Rex resolver.
+func New(cfg Config) *Rex {
+	return &Rex{cfg: cfg, execute: execute}
+}
+
+// Resolve runs Rex on the conflict. It returns nil once Rex emits RESOLVED,
+// an error wrapping integration.ErrEscalate when Rex emits PENDING, and
+// ErrNotResolved when it emits neither.
+func (r *Rex) Resolve(ctx context.Context, req integration.ResolveRequest) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	agentID := agent.FormatAgentID(persona.Rex, 1)
+	if r.cfg.Registry != nil {
+		info, err := r.cfg.Registry.Spawn(persona.Rex, req.TaskID, req.Dir, func() error {
+			cancel()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		agentID = info.ID
+		defer r.cfg.Registry.Remove(agentID)
+	}
+
+	config := &agent.AgentConfig{
+		Model:      r.cfg.Model,
+		AgentID:    agentID,
+		TaskID:     req.TaskID,
+		WorkDir:    req.Dir,
+		ProjectDir: r.cfg.ProjectDir,
+		Timeout:    r.cfg.Timeout,
+	}
+	if r.cfg.Personas != nil {
+		if err := r.cfg.Personas.Configure(config, persona.Rex, persona.PromptData{Case: r.lookup(req.TaskID)}); err != nil {
+			return fmt.Errorf("configure rex: %w", err)
+		}
+	} else if err := agent.ApplyToolPolicy(config, persona.Rex); err != nil {
+		return err
+	}
+
+	log.Printf("[resolver] %s resolving %s conflict of %s (attempt %d)", agentID, req.Report.Level, req.TaskID, req.Attempt)
+	signals, err := r.execute(ctx, config, r.prompt(req))
+	if err != nil {
+		return fmt.Errorf("%s: %w", agentID, err)
+	}
+
+	// The last verdict counts: Rex may report PENDING, then recover and resolve.
+	for i := len(signals) - 1; i >= 0; i-- {
+		switch signals[i].Type {
+		case signal.Resolved:
+			return nil
+		case signal.Pending:
+			return fmt.Errorf("%w: %s", integration.ErrEscalate, signals[i].Payload)
+		}
+	}
+	return ErrNotResolved
+}
+
+// lookup returns the case for taskID, or nil.
+func (r *Rex) lookup(taskID string) *casestore.Case {
+	if r.cfg.Cases == nil {
+		return nil
+	}
+	return r.cfg.Cases(taskID)
+}
+
+// describe formats a task as "id: content", or just the ID without a case.
+func (r *Rex) describe(taskID string) string {
+	if c := r.lookup(taskID); c != nil && c.Content != "" {
+		return fmt.Sprintf("%s: %s", taskID, c.Content)
+	}
+	return taskID
+}
+
+// prompt builds the conflict context Rex works from (docs/06-integration.md, "Rex Prompt Context").
+func (r *Rex) prompt(req integration.ResolveRequest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Merge conflict: %s\n\n", req.TaskID)
+	fmt.Fprintf(&b, "Branch %s is being merged into %s. The merge is in progress in the current directory.\n", req.Branch, req.Target)
+	if req.Attempt > 1 {
+		fmt.Fprintf(&b, "This is attempt %d; earlier attempts did not produce a verified resolution.\n", req.Attempt)
+	}
+
+	fmt.Fprintf(&b, "\n## Task being merged\n\n%s\n", r.describe(req.TaskID))
+	if len(req.TargetTasks) > 0 {
+		fmt.Fprintf(&b, "\n## Tasks merged into %s since the branch was created\n\n", req.Target)
+		for _, id := range req.TargetTasks {
+			fmt.Fprintf(&b, "- %s\n", r.describe(id))
+		}
+	}
+	writeCommits(&b, "Recent commits on "+req.Target, req.TargetCommits)
+	writeCommits(&b, "Recent commits on "+req.Branch, req.BranchCommits)
+
+	b.WriteString("\n## Conflicts\n")
+	paths := make([]string, 0, len(req.Regions))
+	for path := range req.Regions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, f := range req.Report.Files {
+		if f.Resolved {
+			fmt.Fprintf(&b, "\n- %s was resolved automatically (%s)\n", f.Path, f.Kind)
+		}
+	}
+	for _, f := range req.Report.Files {
+		if f.Resolved {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s (%s, %s)\n", f.Path, f.Kind, f.Level)
+		if f.Detail != "" {
+			fmt.Fprintf(&b, "\n%s\n", f.Detail)
+		}
+		if regions := req.Regions[f.Path]; regions != "" {
+			fmt.Fprintf(&b, "\n```\n%s```\n", regions)
+		}
+	}
+
+	b.WriteString("\n## What to do\n\n")
+	b.WriteString("1. Resolve every conflict so both tasks' intent is preserved, and remove all conflict markers.\n")
+	b.WriteString("2. Stage the files with `git add` and run the affected tests.\n")
+	b.WriteString("3. Commit with `git commit --no-edit`.\n")
+	b.WriteString("4. Emit <axiom>RESOLVED</axiom> only when the tests pass. If the conflict needs a human decision, emit <axiom>PENDING:reason</axiom> instead.\n")
+	return b.String()
+}
+
+// writeCommits writes a commit list section when there are commits.
+func writeCommits(b *strings.Builder, title string, commits []string) {
+	if len(commits) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	for _, c := range commits {
+		fmt.Fprintf(b, "- %s\n", c)
+	}
+}
+
+// execute runs prompt on a new agent client and collects its signals.
+func execute(ctx context.Context, config *agent.AgentConfig, prompt string) ([]signal.Signal, error) {
+	client, err := agent.NewAgentClient(config)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = client.Close() }()
+
+	var signals []signal.Signal
+	var runErr error
+	msgs, errs := client.Execute(ctx, prompt)
+	for msgs != nil || errs != nil {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				msgs = nil
+				continue
+			}
+			signals = append(signals, msg.Signals...)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if err != nil && runErr == nil {
+				runErr = err
+			}
+		}
+	}
+	return signals, runErr
+}
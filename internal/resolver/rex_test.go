@@ -0,0 +1,107 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/agent"
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/integration"
+	"github.com/deligoez/axiom/internal/signal"
+)
+
+func request(t *testing.T) integration.ResolveRequest {
+	t.Helper()
+	return integration.ResolveRequest{
+		TaskID: "task-004",
+		Branch: "axiom/task-004",
+		Target: "main",
+		Dir:    t.TempDir(),
+		Report: &integration.ConflictReport{
+			Level: integration.LevelMedium,
+			Files: []integration.FileConflict{
+				{Path: "go.sum", Kind: integration.KindRegenerable, Level: integration.LevelSimple, Resolved: true},
+				{Path: "src/auth/login.go", Kind: integration.KindSemantic, Level: integration.LevelMedium},
+			},
+		},
+		Regions: map[string]string{
+			"src/auth/login.go": "<<<<<<< ours\n\trateLimit(user)\n=======\n\tcheck2FA(user)\n>>>>>>> theirs\n",
+		},
+		TargetTasks:   []string{"task-003"},
+		TargetCommits: []string{"def456 Add rate limiting"},
+		BranchCommits: []string{"ghi789 Add 2FA check"},
+		Attempt:       1,
+	}
+}
+
+func newRex(signals []signal.Signal, err error) (*Rex, *string, *agent.AgentConfig) {
+	cases := map[string]*casestore.Case{
+		"task-003": {ID: "task-003", Content: "Add rate limiting to login"},
+		"task-004": {ID: "task-004", Content: "Add two-factor authentication to login flow"},
+	}
+	rex := New(Config{Model: "opus", Cases: func(id string) *casestore.Case { return cases[id] }})
+	var prompt string
+	var config agent.AgentConfig
+	rex.execute = func(_ context.Context, c *agent.AgentConfig, p string) ([]signal.Signal, error) {
+		prompt, config = p, *c
+		return signals, err
+	}
+	return rex, &prompt, &config
+}
+
+func TestRex_ResolvedSignalAccepts(t *testing.T) {
+	rex, prompt, config := newRex([]signal.Signal{{Type: signal.Pending, Payload: "unsure"}, {Type: signal.Resolved}}, nil)
+	req := request(t)
+
+	if err := rex.Resolve(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.WorkDir != req.Dir || config.AgentID != "rex-001" || config.TaskID != "task-004" || config.Model != "opus" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+	if !slices.Contains(config.AllowedTools, "Bash") {
+		t.Errorf("expected rex's tool policy, got %v", config.AllowedTools)
+	}
+	for _, want := range []string{
+		"task-004: Add two-factor authentication to login flow",
+		"task-003: Add rate limiting to login",
+		"def456 Add rate limiting",
+		"ghi789 Add 2FA check",
+		"### src/auth/login.go (semantic, medium)",
+		"check2FA(user)",
+		"go.sum was resolved automatically (regenerable)",
+		"<axiom>RESOLVED</axiom>",
+	} {
+		if !strings.Contains(*prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, *prompt)
+		}
+	}
+}
+
+func TestRex_Verdicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		signals []signal.Signal
+		err     error
+		want    error
+	}{
+		{"pending escalates", []signal.Signal{{Type: signal.Pending, Payload: "needs product decision"}}, nil, integration.ErrEscalate},
+		{"no verdict", []signal.Signal{{Type: "PROGRESS", Payload: "50"}}, nil, ErrNotResolved},
+		{"agent error", nil, agent.ErrTimeout, agent.ErrTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rex, _, _ := newRex(tt.signals, tt.err)
+
+			err := rex.Resolve(context.Background(), request(t))
+
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Package runlog appends agent run events to .axiom/agents/{persona}/logs/{taskId}.jsonl.
+package runlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/deligoez/axiom/internal/usage"
+)
+
+// Event names.
+const (
+	EventStart     = "start"
+	EventIteration = "iteration"
+	EventSignal    = "signal"
+	EventUsage     = "usage"
+	EventComplete  = "complete"
+	EventError     = "error"
+)
+
+// Event is one line of a run log.
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	Event     string    `json:"event"`
+	TaskID    string    `json:"taskId,omitempty"`
+	AgentID   string    `json:"agentId,omitempty"`
+	Model     string    `json:"model,omitempty"`
+
+	// Reason explains the model choice on start events.
+	Reason string `json:"reason,omitempty"`
+
+	Number  int    `json:"number,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Payload string `json:"payload,omitempty"`
+
+	DurationMs int64        `json:"durationMs,omitempty"`
+	Iterations int          `json:"iterations,omitempty"`
+	Usage      *usage.Usage `json:"usage,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// Path returns the log file for a persona's task.
+func Path(axiomDir, persona, taskID string) string {
+	return filepath.Join(axiomDir, "agents", persona, "logs", taskID+".jsonl")
+}
+
+// Log appends events to one run log file.
+type Log struct {
+	path    string
+	taskID  string
+	agentID string
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// New creates a Log for an agent working on a task. The file is created on first Append.
+func New(axiomDir, persona, taskID, agentID string) *Log {
+	return &Log{
+		path:    Path(axiomDir, persona, taskID),
+		taskID:  taskID,
+		agentID: agentID,
+		now:     time.Now,
+	}
+}
+
+// Path returns the log file path.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes e, filling in the timestamp, task and agent when unset.
+func (l *Log) Append(e Event) error {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = l.now().UTC()
+	}
+	if e.TaskID == "" {
+		e.TaskID = l.taskID
+	}
+	if e.AgentID == "" {
+		e.AgentID = l.agentID
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("create log dir: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open run log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write run log: %w", err)
+	}
+	return nil
+}
+
+// Read returns every event in a run log file.
+func Read(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open run log: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("parse run log %s: %w", path, err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read run log: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,45 @@
+package runlog
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/usage"
+)
+
+func TestLog_AppendAndRead(t *testing.T) {
+	axiomDir := t.TempDir()
+	log := New(axiomDir, "echo", "task-001", "echo-001")
+
+	if log.Path() != filepath.Join(axiomDir, "agents", "echo", "logs", "task-001.jsonl") {
+		t.Errorf("unexpected path %s", log.Path())
+	}
+
+	if err := log.Append(Event{Event: EventStart, Model: "sonnet"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u := usage.Usage{InputTokens: 100, OutputTokens: 20, CostUSD: 0.01, Turns: 2}
+	if err := log.Append(Event{Event: EventUsage, Usage: &u}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, err := Read(log.Path())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].TaskID != "task-001" || events[0].AgentID != "echo-001" || events[0].Timestamp.IsZero() {
+		t.Errorf("expected defaults filled in, got %+v", events[0])
+	}
+	if events[1].Usage == nil || events[1].Usage.InputTokens != 100 {
+		t.Errorf("expected usage round-trip, got %+v", events[1].Usage)
+	}
+}
+
+func TestRead_MissingFile(t *testing.T) {
+	if _, err := Read(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
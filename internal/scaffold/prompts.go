@@ -0,0 +1,217 @@
+package scaffold
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/persona"
+)
+
+// defaultsFile records the checksum of each default written into a persona directory,
+// so upgrades can tell untouched defaults apart from user edits.
+const defaultsFile = "defaults.json"
+
+// PromptStatus describes how a local prompt file relates to the embedded default.
+type PromptStatus string
+
+const (
+	// PromptMissing means the local file does not exist.
+	PromptMissing PromptStatus = "missing"
+	// PromptCurrent means the local file matches the embedded default.
+	PromptCurrent PromptStatus = "current"
+	// PromptOutdated means the local file is an older, unedited default.
+	PromptOutdated PromptStatus = "outdated"
+	// PromptModified means the local file was edited by the user.
+	PromptModified PromptStatus = "modified"
+)
+
+// PromptDrift reports the state of one persona file against its embedded default.
+type PromptDrift struct {
+	Persona string
+	File    string // "prompt.md" or "rules.md"
+	Path    string
+	Status  PromptStatus
+
+	// Diff is a unified diff from the local file to the embedded default.
+	// Empty when the file is current.
+	Diff string
+}
+
+// WriteAgentPrompts writes the embedded prompt.md and rules.md for every persona
+// into .axiom/agents/{name}/. Existing files are never overwritten.
+func WriteAgentPrompts(axiomDir string) error {
+	for _, name := range persona.Names {
+		sums, err := readDefaults(axiomDir, name)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range defaultFiles(name) {
+			path := filepath.Join(axiomDir, "agents", name, f.file)
+			if _, err := os.Stat(path); err == nil {
+				continue
+			}
+			if err := writePromptFile(path, f.content); err != nil {
+				return fmt.Errorf("write %s %s: %w", name, f.file, err)
+			}
+			sums[f.file] = checksum(f.content)
+		}
+
+		if err := writeDefaults(axiomDir, name, sums); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckPrompts compares every persona's local prompt.md and rules.md with the embedded defaults.
+func CheckPrompts(axiomDir string) ([]PromptDrift, error) {
+	var drifts []PromptDrift
+	for _, name := range persona.Names {
+		sums, err := readDefaults(axiomDir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range defaultFiles(name) {
+			d := PromptDrift{
+				Persona: name,
+				File:    f.file,
+				Path:    filepath.Join(axiomDir, "agents", name, f.file),
+			}
+
+			local, err := os.ReadFile(d.Path)
+			switch {
+			case os.IsNotExist(err):
+				d.Status = PromptMissing
+				d.Diff = unifiedDiff(d.Path, "embedded", "", f.content)
+			case err != nil:
+				return nil, fmt.Errorf("read %s: %w", d.Path, err)
+			case string(local) == f.content:
+				d.Status = PromptCurrent
+			default:
+				d.Status = PromptModified
+				if sums[f.file] == checksum(string(local)) {
+					d.Status = PromptOutdated
+				}
+				d.Diff = unifiedDiff(d.Path, "embedded", string(local), f.content)
+			}
+			drifts = append(drifts, d)
+		}
+	}
+	return drifts, nil
+}
+
+// UpgradePrompts replaces missing and outdated persona files with the embedded defaults.
+// Files the user modified are only replaced when force is true.
+// It returns the files that were written.
+func UpgradePrompts(axiomDir string, force bool) ([]PromptDrift, error) {
+	drifts, err := CheckPrompts(axiomDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var upgraded []PromptDrift
+	for _, d := range drifts {
+		switch d.Status {
+		case PromptCurrent:
+			continue
+		case PromptModified:
+			if !force {
+				continue
+			}
+		}
+
+		content := defaultContent(d.Persona, d.File)
+		if err := writePromptFile(d.Path, content); err != nil {
+			return upgraded, fmt.Errorf("write %s: %w", d.Path, err)
+		}
+
+		sums, err := readDefaults(axiomDir, d.Persona)
+		if err != nil {
+			return upgraded, err
+		}
+		sums[d.File] = checksum(content)
+		if err := writeDefaults(axiomDir, d.Persona, sums); err != nil {
+			return upgraded, err
+		}
+		upgraded = append(upgraded, d)
+	}
+	return upgraded, nil
+}
+
+// defaultFile pairs a persona file name with its embedded content.
+type defaultFile struct {
+	file    string
+	content string
+}
+
+// defaultFiles returns the embedded files available for a persona.
+func defaultFiles(name string) []defaultFile {
+	var files []defaultFile
+	if content, ok := agent.DefaultPrompt(name); ok {
+		files = append(files, defaultFile{file: "prompt.md", content: content})
+	}
+	if content, ok := agent.DefaultRules(name); ok {
+		files = append(files, defaultFile{file: "rules.md", content: content})
+	}
+	return files
+}
+
+// defaultContent returns the embedded content for a persona file.
+func defaultContent(name, file string) string {
+	for _, f := range defaultFiles(name) {
+		if f.file == file {
+			return f.content
+		}
+	}
+	return ""
+}
+
+// writePromptFile writes content, creating the persona directory if needed.
+func writePromptFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+// readDefaults reads a persona's recorded default checksums.
+func readDefaults(axiomDir, name string) (map[string]string, error) {
+	sums := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(axiomDir, "agents", name, defaultsFile))
+	if os.IsNotExist(err) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s defaults: %w", name, err)
+	}
+	if err := json.Unmarshal(data, &sums); err != nil {
+		return nil, fmt.Errorf("parse %s defaults: %w", name, err)
+	}
+	return sums, nil
+}
+
+// writeDefaults records a persona's default checksums.
+func writeDefaults(axiomDir, name string, sums map[string]string) error {
+	data, err := json.MarshalIndent(sums, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(axiomDir, "agents", name, defaultsFile)
+	if err := writePromptFile(path, string(data)+"\n"); err != nil {
+		return fmt.Errorf("write %s defaults: %w", name, err)
+	}
+	return nil
+}
+
+// checksum returns the hex SHA-256 of content.
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,150 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/agent"
+)
+
+func TestWriteAgentPrompts_WritesAllPersonas(t *testing.T) {
+	axiomDir := filepath.Join(t.TempDir(), ".axiom")
+
+	if err := WriteAgentPrompts(axiomDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"ava", "axel", "echo", "rex", "cleo", "dex", "max", "ash"} {
+		for _, file := range []string{"prompt.md", "rules.md"} {
+			path := filepath.Join(axiomDir, "agents", name, file)
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("%s was not created: %v", path, err)
+			}
+		}
+	}
+}
+
+func TestWriteAgentPrompts_KeepsUserEdits(t *testing.T) {
+	axiomDir := filepath.Join(t.TempDir(), ".axiom")
+	promptPath := filepath.Join(axiomDir, "agents", "echo", "prompt.md")
+	if err := os.MkdirAll(filepath.Dir(promptPath), 0o755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.WriteFile(promptPath, []byte("my echo"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	if err := WriteAgentPrompts(axiomDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, _ := os.ReadFile(promptPath)
+	if string(content) != "my echo" {
+		t.Errorf("user prompt was overwritten: %q", content)
+	}
+}
+
+func TestCheckPrompts_Statuses(t *testing.T) {
+	axiomDir := filepath.Join(t.TempDir(), ".axiom")
+	if err := WriteAgentPrompts(axiomDir); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	// User edit
+	echoPrompt := filepath.Join(axiomDir, "agents", "echo", "prompt.md")
+	if err := os.WriteFile(echoPrompt, []byte("custom echo\n"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	// Older default, never edited: content and recorded checksum agree
+	rexRules := filepath.Join(axiomDir, "agents", "rex", "rules.md")
+	if err := os.WriteFile(rexRules, []byte("old rex rules\n"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := writeDefaults(axiomDir, "rex", map[string]string{"rules.md": checksum("old rex rules\n")}); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	// Deleted file
+	if err := os.Remove(filepath.Join(axiomDir, "agents", "max", "prompt.md")); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	drifts, err := CheckPrompts(axiomDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make(map[string]PromptDrift)
+	for _, d := range drifts {
+		got[d.Persona+"/"+d.File] = d
+	}
+
+	tests := map[string]PromptStatus{
+		"echo/prompt.md": PromptModified,
+		"rex/rules.md":   PromptOutdated,
+		"max/prompt.md":  PromptMissing,
+		"ava/prompt.md":  PromptCurrent,
+	}
+	for key, want := range tests {
+		if got[key].Status != want {
+			t.Errorf("%s: got status %s, want %s", key, got[key].Status, want)
+		}
+	}
+	if !strings.Contains(got["echo/prompt.md"].Diff, "-custom echo") {
+		t.Errorf("expected diff to show local line removed, got:\n%s", got["echo/prompt.md"].Diff)
+	}
+	if got["ava/prompt.md"].Diff != "" {
+		t.Error("expected no diff for current prompt")
+	}
+}
+
+func TestUpgradePrompts_SkipsModifiedUnlessForced(t *testing.T) {
+	axiomDir := filepath.Join(t.TempDir(), ".axiom")
+	if err := WriteAgentPrompts(axiomDir); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	echoPrompt := filepath.Join(axiomDir, "agents", "echo", "prompt.md")
+	if err := os.WriteFile(echoPrompt, []byte("custom echo\n"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := os.Remove(filepath.Join(axiomDir, "agents", "dex", "rules.md")); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	upgraded, err := UpgradePrompts(axiomDir, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(upgraded) != 1 || upgraded[0].Persona != "dex" {
+		t.Fatalf("expected only dex rules upgraded, got %+v", upgraded)
+	}
+	content, _ := os.ReadFile(echoPrompt)
+	if string(content) != "custom echo\n" {
+		t.Error("modified prompt was overwritten without force")
+	}
+
+	if _, err := UpgradePrompts(axiomDir, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(echoPrompt)
+	want, _ := agent.DefaultPrompt("echo")
+	if string(content) != want {
+		t.Error("expected forced upgrade to restore embedded default")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\n"
+	b := "one\n2\nthree\nfour\nfive\n"
+
+	got := unifiedDiff("a", "b", a, b)
+	want := "--- a\n+++ b\n@@ -1,4 +1,5 @@\n one\n-two\n+2\n three\n four\n+five\n"
+	if got != want {
+		t.Errorf("got diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	if unifiedDiff("a", "b", a, a) != "" {
+		t.Error("expected empty diff for equal input")
+	}
+}
@@ -8,20 +8,22 @@ import (
 	"strings"
 
 	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/persona"
 )
 
 // Scaffold creates the .axiom/ directory structure.
 // It creates:
 //   - .axiom/
-//   - .axiom/agents/ava/
-//   - .axiom/agents/ava/logs/
+//   - .axiom/agents/{persona}/ for every persona
+//   - .axiom/agents/{persona}/logs/
 func Scaffold(projectDir string) error {
 	axiomDir := filepath.Join(projectDir, ".axiom")
-	avaDir := filepath.Join(axiomDir, "agents", "ava")
-	logsDir := filepath.Join(avaDir, "logs")
 
-	if err := os.MkdirAll(logsDir, 0o755); err != nil {
-		return fmt.Errorf("create directories: %w", err)
+	for _, name := range persona.Names {
+		logsDir := filepath.Join(axiomDir, "agents", name, "logs")
+		if err := os.MkdirAll(logsDir, 0o755); err != nil {
+			return fmt.Errorf("create directories: %w", err)
+		}
 	}
 
 	return nil
@@ -8,6 +8,18 @@ import (
 // Signal types used by AXIOM agents.
 const (
 	AvaComplete = "AVA_COMPLETE"
+
+	// Complete is emitted by Echo once a task is implemented and verified.
+	Complete = "COMPLETE"
+	// Blocked is emitted when a task cannot proceed; the payload says why.
+	Blocked = "BLOCKED"
+	// Progress reports a task's completion percentage in its payload.
+	Progress = "PROGRESS"
+
+	// Resolved is emitted by Rex once a merge conflict is resolved and tested.
+	Resolved = "RESOLVED"
+	// Pending is emitted when a task needs a human; the payload says why.
+	Pending = "PENDING"
 )
 
 // Signal represents an AXIOM signal extracted from agent output.
@@ -0,0 +1,173 @@
+package skill
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/pathglob"
+)
+
+// DefaultBudget is the default token budget for skills injected into one prompt.
+const DefaultBudget = 6000
+
+// Match scores for each kind of trigger hit.
+const (
+	labelScore   = 3
+	fileScore    = 2
+	keywordScore = 1
+)
+
+// Query describes the task a skill is matched against.
+type Query struct {
+	Labels  []string
+	Files   []string
+	Content string
+}
+
+// Match is a skill that applies to a query.
+type Match struct {
+	Skill *Skill
+
+	// Score ranks matches; untriggered skills score 0.
+	Score int
+
+	// Reasons lists the triggers that fired (e.g. "label:security").
+	Reasons []string
+}
+
+// Registry holds skills keyed by name.
+type Registry struct {
+	skills map[string]*Skill
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{skills: make(map[string]*Skill)}
+}
+
+// Load builds a registry from .axiom/skills/ and .axiom/agents/{persona}/skills/.
+// Persona skills override shared skills with the same name.
+func Load(axiomDir, persona string) (*Registry, error) {
+	r := NewRegistry()
+	if err := r.LoadDir(filepath.Join(axiomDir, "skills")); err != nil {
+		return nil, err
+	}
+	if persona != "" {
+		if err := r.LoadDir(filepath.Join(axiomDir, "agents", persona, "skills")); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// LoadDir parses every *.md file in dir. A missing directory is not an error.
+func (r *Registry) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return fmt.Errorf("list skills: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read skill: %w", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		r.Add(Parse(name, path, string(data)))
+	}
+	return nil
+}
+
+// Add registers a skill, replacing any skill with the same name.
+func (r *Registry) Add(s *Skill) {
+	r.skills[s.Name] = s
+}
+
+// Skills returns all skills sorted by name.
+func (r *Registry) Skills() []*Skill {
+	out := make([]*Skill, 0, len(r.skills))
+	for _, s := range r.skills {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Match returns the skills that apply to q, highest score first.
+// Triggered skills apply only if at least one trigger fires; untriggered skills always apply.
+func (r *Registry) Match(q Query) []Match {
+	content := strings.ToLower(q.Content)
+
+	var matches []Match
+	for _, s := range r.Skills() {
+		if !s.HasTriggers() {
+			matches = append(matches, Match{Skill: s})
+			continue
+		}
+
+		m := Match{Skill: s}
+		for _, label := range s.Labels {
+			if containsFold(q.Labels, label) {
+				m.Score += labelScore
+				m.Reasons = append(m.Reasons, "label:"+label)
+			}
+		}
+		for _, glob := range s.Files {
+			for _, f := range q.Files {
+				if pathglob.Match(glob, f) {
+					m.Score += fileScore
+					m.Reasons = append(m.Reasons, "file:"+glob)
+					break
+				}
+			}
+		}
+		for _, kw := range s.Keywords {
+			if content != "" && strings.Contains(content, strings.ToLower(kw)) {
+				m.Score += keywordScore
+				m.Reasons = append(m.Reasons, "keyword:"+kw)
+			}
+		}
+		if m.Score > 0 {
+			matches = append(matches, m)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// Select returns the matching skills that fit within budget tokens, in match order.
+// A skill that does not fit is skipped so smaller, lower-ranked skills can still fit.
+// A budget <= 0 uses DefaultBudget.
+func (r *Registry) Select(q Query, budget int) []*Skill {
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+
+	var out []*Skill
+	used := 0
+	for _, m := range r.Match(q) {
+		tokens := m.Skill.Tokens()
+		if used+tokens > budget {
+			continue
+		}
+		used += tokens
+		out = append(out, m.Skill)
+	}
+	return out
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+// Package skill parses AXIOM skill files and matches them to tasks.
+package skill
+
+import (
+	"bufio"
+	"strings"
+)
+
+// Skill is a parsed skill markdown file.
+//
+// Triggers are declared as bullets in the "When to Use" section:
+//
+//	## When to Use
+//
+//	- Implementing new features
+//	- Labels: security, auth
+//	- Files: internal/**/*.go, *.sql
+//	- Keywords: login, session token
+//
+// Bullets without a trigger prefix are descriptive only.
+type Skill struct {
+	// Name is the file name without the .md extension.
+	Name string
+
+	// Path is the file the skill was loaded from.
+	Path string
+
+	// Title is the first "# " heading.
+	Title string
+
+	// Description is the first paragraph after the title.
+	Description string
+
+	// WhenToUse holds the descriptive "When to Use" bullets.
+	WhenToUse []string
+
+	// Labels match case labels exactly (case-insensitive).
+	Labels []string
+
+	// Files are path globs matched against the task's files.
+	Files []string
+
+	// Keywords match case content (case-insensitive substring).
+	Keywords []string
+
+	// Content is the full file content.
+	Content string
+}
+
+// HasTriggers reports whether the skill declares any label, file or keyword trigger.
+// Skills without triggers apply to every task.
+func (s *Skill) HasTriggers() bool {
+	return len(s.Labels) > 0 || len(s.Files) > 0 || len(s.Keywords) > 0
+}
+
+// Tokens estimates the skill's prompt size in tokens (about 4 bytes per token).
+func (s *Skill) Tokens() int {
+	return EstimateTokens(s.Content)
+}
+
+// EstimateTokens approximates the token count of text.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// Parse parses skill markdown content.
+func Parse(name, path, content string) *Skill {
+	s := &Skill{Name: name, Path: path, Content: content}
+
+	var section string
+	var para []string
+	inFence := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "# ") && s.Title == "":
+			s.Title = strings.TrimSpace(line[2:])
+			section = "title"
+			continue
+		case strings.HasPrefix(line, "## "):
+			section = strings.ToLower(strings.TrimSpace(line[3:]))
+			continue
+		case strings.HasPrefix(line, "#"):
+			section = ""
+			continue
+		}
+
+		switch section {
+		case "title":
+			if trimmed == "" {
+				if len(para) > 0 {
+					s.Description = strings.Join(para, " ")
+					section = ""
+				}
+				continue
+			}
+			para = append(para, trimmed)
+		case "when to use":
+			if item, ok := bullet(trimmed); ok {
+				s.addCondition(item)
+			}
+		}
+	}
+	if s.Description == "" && len(para) > 0 {
+		s.Description = strings.Join(para, " ")
+	}
+
+	return s
+}
+
+// addCondition records a "When to Use" bullet as a trigger or description.
+func (s *Skill) addCondition(item string) {
+	key, value, found := strings.Cut(item, ":")
+	if found {
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "labels", "label":
+			s.Labels = append(s.Labels, splitList(value)...)
+			return
+		case "files", "file":
+			s.Files = append(s.Files, splitList(value)...)
+			return
+		case "keywords", "keyword":
+			s.Keywords = append(s.Keywords, splitList(value)...)
+			return
+		}
+	}
+	s.WhenToUse = append(s.WhenToUse, item)
+}
+
+// bullet returns the text of a markdown list item.
+func bullet(line string) (string, bool) {
+	for _, prefix := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(line[len(prefix):]), true
+		}
+	}
+	return "", false
+}
+
+// splitList splits a comma-separated list, trimming whitespace and backticks.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.Trim(strings.TrimSpace(item), "`")
+		if item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
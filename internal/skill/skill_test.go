@@ -0,0 +1,134 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const tddSkill = "# Test-Driven Development\n" +
+	"\n" +
+	"Write tests before implementation code.\n" +
+	"\n" +
+	"## When to Use\n" +
+	"\n" +
+	"- Implementing new features\n" +
+	"- Labels: tdd, feature\n" +
+	"- Files: `*_test.go`, internal/**/*.go\n" +
+	"- Keywords: test, coverage\n" +
+	"\n" +
+	"## Instructions\n" +
+	"\n" +
+	"```markdown\n" +
+	"## When to Use\n" +
+	"- Labels: ignored\n" +
+	"```\n"
+
+func TestParse_ExtractsSections(t *testing.T) {
+	s := Parse("tdd", "skills/tdd.md", tddSkill)
+
+	if s.Title != "Test-Driven Development" {
+		t.Errorf("got Title %q", s.Title)
+	}
+	if s.Description != "Write tests before implementation code." {
+		t.Errorf("got Description %q", s.Description)
+	}
+	if len(s.WhenToUse) != 1 || s.WhenToUse[0] != "Implementing new features" {
+		t.Errorf("got WhenToUse %v", s.WhenToUse)
+	}
+	if strings.Join(s.Labels, ",") != "tdd,feature" {
+		t.Errorf("got Labels %v", s.Labels)
+	}
+	if strings.Join(s.Files, ",") != "*_test.go,internal/**/*.go" {
+		t.Errorf("got Files %v", s.Files)
+	}
+	if strings.Join(s.Keywords, ",") != "test,coverage" {
+		t.Errorf("got Keywords %v", s.Keywords)
+	}
+}
+
+func TestParse_NoTriggers(t *testing.T) {
+	s := Parse("api", "api.md", "# API\n\nCall things.\n\n## When to Use\n\n- Connecting to services\n")
+
+	if s.HasTriggers() {
+		t.Error("expected no triggers")
+	}
+}
+
+func TestRegistry_MatchRanksByScore(t *testing.T) {
+	r := NewRegistry()
+	r.Add(Parse("general", "general.md", "# General\n"))
+	r.Add(Parse("security", "security.md", "# Security\n\n## When to Use\n\n- Labels: security\n"))
+	r.Add(Parse("sql", "sql.md", "# SQL\n\n## When to Use\n\n- Files: *.sql\n- Keywords: migration\n"))
+	r.Add(Parse("css", "css.md", "# CSS\n\n## When to Use\n\n- Files: web/\n"))
+
+	matches := r.Match(Query{
+		Labels:  []string{"Security"},
+		Files:   []string{"db/001_users.sql"},
+		Content: "Add a Migration for users",
+	})
+
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.Skill.Name)
+	}
+	if strings.Join(names, ",") != "security,sql,general" {
+		t.Errorf("got matches %v", names)
+	}
+	if matches[1].Score != fileScore+keywordScore {
+		t.Errorf("got sql score %d, want %d", matches[1].Score, fileScore+keywordScore)
+	}
+}
+
+func TestRegistry_SelectRespectsBudget(t *testing.T) {
+	r := NewRegistry()
+	r.Add(Parse("big", "big.md", "# Big\n\n## When to Use\n\n- Keywords: auth\n\n"+strings.Repeat("x", 400)))
+	r.Add(Parse("small", "small.md", "# Small\n"))
+
+	selected := r.Select(Query{Content: "auth flow"}, 50)
+
+	if len(selected) != 1 || selected[0].Name != "small" {
+		t.Errorf("expected only small skill within budget, got %v", selected)
+	}
+}
+
+func TestLoad_PersonaOverridesShared(t *testing.T) {
+	axiomDir := t.TempDir()
+	writeSkill(t, filepath.Join(axiomDir, "skills", "tdd.md"), "# Shared TDD\n")
+	writeSkill(t, filepath.Join(axiomDir, "skills", "docs.md"), "# Docs\n")
+	writeSkill(t, filepath.Join(axiomDir, "agents", "echo", "skills", "tdd.md"), "# Echo TDD\n")
+
+	r, err := Load(axiomDir, "echo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	skills := r.Skills()
+	if len(skills) != 2 {
+		t.Fatalf("expected 2 skills, got %d", len(skills))
+	}
+	if skills[1].Name != "tdd" || skills[1].Title != "Echo TDD" {
+		t.Errorf("expected persona tdd skill to override shared, got %q", skills[1].Title)
+	}
+}
+
+func TestLoad_MissingDirs(t *testing.T) {
+	r, err := Load(t.TempDir(), "echo")
+	if err != nil {
+		t.Fatalf("unexpected error for missing dirs: %v", err)
+	}
+	if len(r.Skills()) != 0 {
+		t.Errorf("expected empty registry, got %d skills", len(r.Skills()))
+	}
+}
+
+func writeSkill(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+}
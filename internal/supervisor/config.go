@@ -0,0 +1,120 @@
+// Package supervisor watches an agent's Execute stream for signs that it is stuck
+// and nudges, restarts or fails it.
+package supervisor
+
+import (
+	"fmt"
+	"time"
+)
+
+// Defaults applied to zero-valued Config fields.
+const (
+	DefaultNoOutputTimeout = 5 * time.Minute
+	DefaultRepeatLimit     = 3
+	DefaultFailureLimit    = 3
+	DefaultCheckpointGrace = 2 * time.Minute
+	DefaultNudgePrompt     = "You appear to be stuck repeating the same steps. " +
+		"Stop, summarize what you have tried and why it did not work, then take a different approach. " +
+		"If you cannot make progress, emit a BLOCKED signal explaining what you need."
+	DefaultCheckpointPrompt = "Time is almost up for this task. Do not start new changes. " +
+		"Commit your work in progress with a message starting with \"WIP:\", " +
+		"then reply with a short summary of what is done and what remains."
+)
+
+// Action is what the supervisor does when an agent is stuck.
+type Action string
+
+const (
+	// ActionNudge sends the nudge prompt into the agent's conversation.
+	ActionNudge Action = "nudge"
+	// ActionRestart starts a fresh conversation with the original prompt and recovery context.
+	ActionRestart Action = "restart"
+	// ActionFail marks the agent failed.
+	ActionFail Action = "fail"
+)
+
+// DefaultActions is the escalation ladder used when Config.Actions is unset:
+// two nudges, then one restart, then failure.
+var DefaultActions = []Action{ActionNudge, ActionNudge, ActionRestart}
+
+// Config configures stuck detection (the "stuck" section of .axiom/config.json).
+type Config struct {
+	// NoOutputTimeout is how long an agent may go without a message, e.g. "5m".
+	NoOutputTimeout string `json:"noOutputTimeout,omitempty"`
+
+	// RepeatLimit is how many identical tool calls in a row count as stuck.
+	RepeatLimit int `json:"repeatLimit,omitempty"`
+
+	// FailureLimit is how many times the same command may fail before the agent is stuck.
+	FailureLimit int `json:"failureLimit,omitempty"`
+
+	// MaxIterations caps Execute calls per supervisor, including nudges and restarts. 0 means no limit.
+	MaxIterations int `json:"maxIterations,omitempty"`
+
+	// Actions is the escalation ladder taken on successive stuck detections.
+	// Once it is exhausted the agent fails; an empty list fails on the first detection.
+	Actions []Action `json:"actions,omitempty"`
+
+	// NudgePrompt is sent on ActionNudge.
+	NudgePrompt string `json:"nudgePrompt,omitempty"`
+
+	// CheckpointGrace is how long before a context deadline the agent is stopped
+	// and asked to checkpoint, e.g. "2m". At most half the remaining time is used.
+	CheckpointGrace string `json:"checkpointGrace,omitempty"`
+
+	// CheckpointPrompt is sent when the deadline is near.
+	CheckpointPrompt string `json:"checkpointPrompt,omitempty"`
+}
+
+// durations are the parsed Config durations.
+type durations struct {
+	noOutput   time.Duration
+	checkpoint time.Duration
+}
+
+// withDefaults fills in zero-valued fields and validates the result.
+func (c Config) withDefaults() (Config, durations, error) {
+	var d durations
+	var err error
+	if d.noOutput, err = parseDuration("noOutputTimeout", c.NoOutputTimeout, DefaultNoOutputTimeout); err != nil {
+		return c, d, err
+	}
+	if d.checkpoint, err = parseDuration("checkpointGrace", c.CheckpointGrace, DefaultCheckpointGrace); err != nil {
+		return c, d, err
+	}
+	if c.RepeatLimit <= 0 {
+		c.RepeatLimit = DefaultRepeatLimit
+	}
+	if c.FailureLimit <= 0 {
+		c.FailureLimit = DefaultFailureLimit
+	}
+	if c.Actions == nil {
+		c.Actions = DefaultActions
+	}
+	for _, a := range c.Actions {
+		switch a {
+		case ActionNudge, ActionRestart, ActionFail:
+		default:
+			return c, d, fmt.Errorf("unknown stuck action %q", a)
+		}
+	}
+	if c.NudgePrompt == "" {
+		c.NudgePrompt = DefaultNudgePrompt
+	}
+	if c.CheckpointPrompt == "" {
+		c.CheckpointPrompt = DefaultCheckpointPrompt
+	}
+	return c, d, nil
+}
+
+// parseDuration parses a positive duration, returning def for an empty string.
+func parseDuration(name, s string, def time.Duration) (time.Duration, error) {
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return d, nil
+}
@@ -0,0 +1,60 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/deligoez/axiom/internal/agent"
+)
+
+// monitor detects stuck patterns in the messages of all Execute calls for one task.
+type monitor struct {
+	repeatLimit  int
+	failureLimit int
+
+	lastCall string
+	repeats  int
+	failures map[string]int
+}
+
+// newMonitor creates a monitor with the limits from cfg.
+func newMonitor(cfg Config) *monitor {
+	return &monitor{
+		repeatLimit:  cfg.RepeatLimit,
+		failureLimit: cfg.FailureLimit,
+		failures:     make(map[string]int),
+	}
+}
+
+// observe returns why the agent is stuck, or "" if msg shows no stuck pattern.
+func (m *monitor) observe(msg agent.AgentMessage) string {
+	for _, call := range msg.ToolCalls {
+		if !call.Done {
+			key := callKey(call)
+			if key == m.lastCall {
+				m.repeats++
+			} else {
+				m.lastCall, m.repeats = key, 1
+			}
+			if m.repeats >= m.repeatLimit {
+				return fmt.Sprintf("repeated the same tool call %d times (%s)", m.repeats, call.Summary)
+			}
+			continue
+		}
+
+		if call.Name == "Bash" && call.Error != "" {
+			command, _ := call.Input["command"].(string)
+			m.failures[command]++
+			if n := m.failures[command]; n >= m.failureLimit {
+				return fmt.Sprintf("ran the same failing command %d times (%s)", n, call.Summary)
+			}
+		}
+	}
+	return ""
+}
+
+// callKey identifies a tool call by name and input.
+func callKey(call agent.ToolCall) string {
+	input, _ := json.Marshal(call.Input)
+	return call.Name + " " + string(input)
+}
@@ -0,0 +1,33 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/deligoez/axiom/internal/agent"
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+// CaseStatus returns the task status a failed Run implies: timeout for deadlines
+// and iteration limits, failed for anything else. It returns false when err is nil
+// or the run was cancelled, since those leave the case status to the caller.
+func CaseStatus(err error) (casestore.Status, bool) {
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return "", false
+	case errors.Is(err, agent.ErrTimeout), errors.Is(err, ErrIterationLimit):
+		return casestore.StatusTimeout, true
+	default:
+		return casestore.StatusFailed, true
+	}
+}
+
+// RecordOutcome sets the status of case caseID in the cases file at path when err
+// ends the task. See CaseStatus.
+func RecordOutcome(store *casestore.CaseStore, path, caseID string, err error) error {
+	status, ok := CaseStatus(err)
+	if !ok {
+		return nil
+	}
+	return store.SetStatus(path, caseID, status)
+}
@@ -0,0 +1,51 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/agent"
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+func TestCaseStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		want   casestore.Status
+		wantOK bool
+	}{
+		{nil, "", false},
+		{context.Canceled, "", false},
+		{fmt.Errorf("%w: task exceeded 30m0s", agent.ErrTimeout), casestore.StatusTimeout, true},
+		{fmt.Errorf("%w: 50", ErrIterationLimit), casestore.StatusTimeout, true},
+		{fmt.Errorf("%w: produced no output", ErrStuck), casestore.StatusFailed, true},
+		{errors.New("exit status 1"), casestore.StatusFailed, true},
+	}
+	for _, tt := range tests {
+		got, ok := CaseStatus(tt.err)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("CaseStatus(%v) = %q, %v; want %q, %v", tt.err, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRecordOutcome_SetsTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cases.jsonl")
+	if err := os.WriteFile(path, []byte(`{"id":"task-001","type":"task","status":"active"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	store := casestore.NewCaseStore()
+
+	if err := RecordOutcome(store, path, "task-001", fmt.Errorf("%w: deadline", agent.ErrTimeout)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cases, _ := store.Load(path)
+	if cases[0].Status != casestore.StatusTimeout {
+		t.Errorf("got status %s, want %s", cases[0].Status, casestore.StatusTimeout)
+	}
+}
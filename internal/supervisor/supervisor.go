@@ -0,0 +1,328 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+)
+
+// interruptGrace is how long an interrupted turn may take to finish before it is cancelled.
+const interruptGrace = 30 * time.Second
+
+// recentCalls is how many tool calls are listed in a restart's recovery context.
+const recentCalls = 10
+
+var (
+	// ErrStuck is returned when the escalation ladder is exhausted.
+	ErrStuck = errors.New("agent stuck")
+	// ErrIterationLimit is returned when MaxIterations Execute calls have been made.
+	ErrIterationLimit = errors.New("iteration limit reached")
+
+	// errCheckpointDue ends an iteration when the context deadline is near.
+	errCheckpointDue = errors.New("checkpoint due")
+)
+
+// Executor runs prompts on an agent. *agent.AgentClient implements it.
+type Executor interface {
+	Execute(ctx context.Context, prompt string) (<-chan agent.AgentMessage, <-chan error)
+	Interrupt(ctx context.Context) error
+	Reset() error
+	Machine() *agent.Machine
+	ToolCalls() []agent.ToolCall
+}
+
+// Event reports a stuck detection and the action taken.
+type Event struct {
+	AgentID string    `json:"agentId"`
+	Reason  string    `json:"reason"`
+	Action  Action    `json:"action"`
+	At      time.Time `json:"at"`
+}
+
+// Supervisor runs prompts on one agent, detecting when it is stuck.
+// Nudges continue the agent's conversation, so they are most useful with
+// streaming agents (those with a permission handler).
+type Supervisor struct {
+	exec       Executor
+	cfg        Config
+	noOutput   time.Duration
+	checkpoint time.Duration
+	grace      time.Duration
+	mon        *monitor
+
+	mu         sync.Mutex
+	iterations int
+	escalation int
+	notify     func(Event)
+}
+
+// New creates a Supervisor for exec. Zero-valued cfg fields take their defaults.
+func New(exec Executor, cfg Config) (*Supervisor, error) {
+	cfg, d, err := cfg.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	return &Supervisor{
+		exec:       exec,
+		cfg:        cfg,
+		noOutput:   d.noOutput,
+		checkpoint: d.checkpoint,
+		grace:      interruptGrace,
+		mon:        newMonitor(cfg),
+	}, nil
+}
+
+// Notify registers fn to be called on every stuck detection, e.g. to warn in the web UI.
+func (s *Supervisor) Notify(fn func(Event)) {
+	s.mu.Lock()
+	s.notify = fn
+	s.mu.Unlock()
+}
+
+// Iterations returns the number of Execute calls made so far.
+func (s *Supervisor) Iterations() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.iterations
+}
+
+// Run executes prompt, passing every message to handle, until the agent finishes its turn.
+// When the agent is stuck, the next action on the escalation ladder is taken and the
+// run continues. When ctx has a deadline, the agent is stopped shortly before it and
+// asked to checkpoint its work, and Run returns an error wrapping agent.ErrTimeout.
+// It returns ErrStuck or ErrIterationLimit when the agent is failed, or the Execute error.
+func (s *Supervisor) Run(ctx context.Context, prompt string, handle func(agent.AgentMessage)) error {
+	original := prompt
+	machine := s.exec.Machine()
+
+	timeUp, stopTimer := s.checkpointTimer(ctx)
+	defer stopTimer()
+
+	for {
+		s.mu.Lock()
+		if s.cfg.MaxIterations > 0 && s.iterations >= s.cfg.MaxIterations {
+			s.mu.Unlock()
+			s.fail(fmt.Sprintf("reached %d iterations", s.cfg.MaxIterations))
+			return fmt.Errorf("%w: %d", ErrIterationLimit, s.cfg.MaxIterations)
+		}
+		s.iterations++
+		s.mu.Unlock()
+
+		reason, err := s.iterate(ctx, prompt, handle, timeUp)
+		if errors.Is(err, errCheckpointDue) {
+			return s.checkpointRun(ctx, handle)
+		}
+		if err != nil {
+			return err
+		}
+		if reason == "" {
+			return nil
+		}
+		if ctx.Err() != nil {
+			s.fail(context.Cause(ctx).Error())
+			return context.Cause(ctx)
+		}
+
+		action := s.nextAction()
+		s.emit(Event{AgentID: machine.AgentID(), Reason: reason, Action: action, At: time.Now()})
+
+		switch action {
+		case ActionNudge:
+			_ = machine.Transition(agent.StateRunning, "nudged: "+reason)
+			prompt = s.cfg.NudgePrompt
+		case ActionRestart:
+			if err := s.exec.Reset(); err != nil {
+				log.Printf("[WARN] reset %s: %v", machine.AgentID(), err)
+			}
+			_ = machine.Transition(agent.StateStarting, "restarted: "+reason)
+			prompt = recoveryPrompt(original, reason, s.exec.ToolCalls())
+		default:
+			s.fail(reason)
+			return fmt.Errorf("%w: %s", ErrStuck, reason)
+		}
+	}
+}
+
+// iterate runs one Execute call. It returns a non-empty reason when the agent was
+// stopped for being stuck, and errCheckpointDue when timeUp fired.
+func (s *Supervisor) iterate(ctx context.Context, prompt string, handle func(agent.AgentMessage), timeUp <-chan time.Time) (string, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs, errs := s.exec.Execute(runCtx, prompt)
+	timer := time.NewTimer(s.noOutput)
+	defer timer.Stop()
+
+	for msgs != nil || errs != nil {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				msgs = nil
+				continue
+			}
+			handle(msg)
+			timer.Reset(s.noOutput)
+			if reason := s.mon.observe(msg); reason != "" {
+				s.stop(ctx, cancel, msgs, handle, reason)
+				return reason, nil
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+		case <-timer.C:
+			reason := fmt.Sprintf("produced no output for %s", s.noOutput)
+			s.stop(ctx, cancel, msgs, handle, reason)
+			return reason, nil
+		case <-timeUp:
+			// Holding the agent in the stuck state keeps the cancelled turn from failing it.
+			s.stop(ctx, cancel, msgs, handle, "deadline near")
+			return "", errCheckpointDue
+		}
+	}
+	return "", nil
+}
+
+// stop marks the agent stuck and ends the current turn.
+func (s *Supervisor) stop(ctx context.Context, cancel context.CancelFunc, msgs <-chan agent.AgentMessage, handle func(agent.AgentMessage), reason string) {
+	_ = s.exec.Machine().Transition(agent.StateStuck, reason)
+	s.interrupt(ctx, cancel, msgs, handle)
+}
+
+// interrupt ends the current turn, asking the agent to interrupt first and
+// cancelling it if that fails or takes too long.
+func (s *Supervisor) interrupt(ctx context.Context, cancel context.CancelFunc, msgs <-chan agent.AgentMessage, handle func(agent.AgentMessage)) {
+	if err := s.exec.Interrupt(ctx); err != nil {
+		cancel()
+	}
+	grace := time.NewTimer(s.grace)
+	defer grace.Stop()
+
+	for msgs != nil {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				msgs = nil
+				continue
+			}
+			handle(msg)
+		case <-grace.C:
+			cancel()
+		}
+	}
+}
+
+// checkpointTimer fires shortly before ctx's deadline: CheckpointGrace before it,
+// or halfway there when less than twice the grace remains. Without a deadline it never fires.
+func (s *Supervisor) checkpointTimer(ctx context.Context) (<-chan time.Time, func()) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil, func() {}
+	}
+	remaining := time.Until(deadline)
+	timer := time.NewTimer(remaining - min(s.checkpoint, remaining/2))
+	return timer.C, func() { timer.Stop() }
+}
+
+// checkpointRun asks the agent to checkpoint its work in the time left before
+// ctx's deadline, then fails it with a timeout.
+func (s *Supervisor) checkpointRun(ctx context.Context, handle func(agent.AgentMessage)) error {
+	machine := s.exec.Machine()
+	deadline, _ := ctx.Deadline()
+	log.Printf("[WARN] %s: deadline %s is near, asking for a checkpoint", machine.AgentID(), deadline.Format(time.RFC3339))
+
+	if machine.State() == agent.StateStuck {
+		_ = machine.Transition(agent.StateRunning, "checkpoint")
+	}
+	if _, err := s.iterate(ctx, s.cfg.CheckpointPrompt, handle, nil); err != nil {
+		log.Printf("[WARN] %s: checkpoint: %v", machine.AgentID(), err)
+	}
+
+	err := fmt.Errorf("%w: stopped to checkpoint before the %s deadline", agent.ErrTimeout, deadline.Format(time.RFC3339))
+	s.fail(err.Error())
+	return err
+}
+
+// nextAction returns the next step on the escalation ladder.
+func (s *Supervisor) nextAction() Action {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.escalation >= len(s.cfg.Actions) {
+		return ActionFail
+	}
+	action := s.cfg.Actions[s.escalation]
+	s.escalation++
+	return action
+}
+
+// fail marks the agent failed unless it already finished.
+func (s *Supervisor) fail(reason string) {
+	machine := s.exec.Machine()
+	if !machine.State().Terminal() {
+		_ = machine.Transition(agent.StateFailed, reason)
+	}
+}
+
+// emit calls the registered notify function, if any.
+func (s *Supervisor) emit(e Event) {
+	s.mu.Lock()
+	fn := s.notify
+	s.mu.Unlock()
+
+	log.Printf("[WARN] %s stuck: %s (%s)", e.AgentID, e.Reason, e.Action)
+	if fn != nil {
+		fn(e)
+	}
+}
+
+// recoveryPrompt restates the original prompt with what the previous attempt did.
+func recoveryPrompt(original, reason string, calls []agent.ToolCall) string {
+	var b strings.Builder
+	b.WriteString(original)
+	b.WriteString("\n\n## Recovery\n\n")
+	fmt.Fprintf(&b, "A previous attempt at this task was stopped because it %s.\n", reason)
+	b.WriteString("Check the current state of the work before continuing, and do not repeat the same approach.\n")
+
+	files := make(map[string]bool)
+	var touched []string
+	for _, call := range calls {
+		for _, f := range call.Files {
+			if !files[f] {
+				files[f] = true
+				touched = append(touched, f)
+			}
+		}
+	}
+	if len(touched) > 0 {
+		b.WriteString("\nFiles modified so far:\n")
+		for _, f := range touched {
+			fmt.Fprintf(&b, "- %s\n", f)
+		}
+	}
+
+	if len(calls) > recentCalls {
+		calls = calls[len(calls)-recentCalls:]
+	}
+	if len(calls) > 0 {
+		b.WriteString("\nLast steps of the previous attempt:\n")
+		for _, call := range calls {
+			line := "- " + call.Summary
+			if call.Error != "" {
+				line += " (failed)"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,268 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+)
+
+var _ Executor = (*agent.AgentClient)(nil)
+
+// script is what the fake executor does for one Execute call.
+type script struct {
+	messages []agent.AgentMessage
+	hang     bool // block until cancelled after sending messages
+}
+
+// fakeExecutor replays scripts, one per Execute call.
+type fakeExecutor struct {
+	machine *agent.Machine
+	scripts []script
+	calls   []agent.ToolCall
+
+	mu      sync.Mutex
+	prompts []string
+	resets  int
+}
+
+func newFakeExecutor(scripts ...script) *fakeExecutor {
+	return &fakeExecutor{machine: agent.NewMachine("echo-001"), scripts: scripts}
+}
+
+func (f *fakeExecutor) Execute(ctx context.Context, prompt string) (<-chan agent.AgentMessage, <-chan error) {
+	f.mu.Lock()
+	n := len(f.prompts)
+	f.prompts = append(f.prompts, prompt)
+	f.mu.Unlock()
+
+	msgs := make(chan agent.AgentMessage)
+	errs := make(chan error, 1)
+	if f.machine.State() == agent.StateIdle {
+		_ = f.machine.Transition(agent.StateStarting, "query sent")
+	}
+
+	go func() {
+		defer close(msgs)
+		defer close(errs)
+
+		var sc script
+		if n < len(f.scripts) {
+			sc = f.scripts[n]
+		}
+		for _, msg := range sc.messages {
+			if f.machine.State() == agent.StateStarting {
+				_ = f.machine.Transition(agent.StateRunning, "first message")
+			}
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+		if sc.hang {
+			<-ctx.Done()
+			errs <- ctx.Err()
+		}
+	}()
+	return msgs, errs
+}
+
+func (f *fakeExecutor) Interrupt(context.Context) error { return errors.New("no session") }
+
+func (f *fakeExecutor) Reset() error {
+	f.mu.Lock()
+	f.resets++
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeExecutor) Machine() *agent.Machine { return f.machine }
+
+func (f *fakeExecutor) ToolCalls() []agent.ToolCall { return f.calls }
+
+func started(name, command string) agent.AgentMessage {
+	call := agent.ToolCall{Name: name, Input: map[string]any{"command": command}, Summary: "ran " + command}
+	return agent.AgentMessage{ToolCalls: []agent.ToolCall{call}}
+}
+
+func failed(command string) agent.AgentMessage {
+	call := agent.ToolCall{Name: "Bash", Input: map[string]any{"command": command}, Summary: "ran " + command, Error: "exit status 1", Done: true}
+	return agent.AgentMessage{ToolCalls: []agent.ToolCall{call}}
+}
+
+func TestSupervisor_FinishesNormally(t *testing.T) {
+	exec := newFakeExecutor(script{messages: []agent.AgentMessage{{Text: "done"}}})
+	s, err := New(exec, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	err = s.Run(context.Background(), "do it", func(msg agent.AgentMessage) { got = append(got, msg.Text) })
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "done" || s.Iterations() != 1 {
+		t.Errorf("got messages %v after %d iterations", got, s.Iterations())
+	}
+}
+
+func TestSupervisor_RepeatedToolCallNudges(t *testing.T) {
+	loop := script{messages: []agent.AgentMessage{started("Bash", "ls"), started("Bash", "ls"), started("Bash", "ls")}, hang: true}
+	exec := newFakeExecutor(loop, script{messages: []agent.AgentMessage{{Text: "ok"}}})
+	s, err := New(exec, Config{NudgePrompt: "try something else"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var events []Event
+	s.Notify(func(e Event) { events = append(events, e) })
+
+	err = s.Run(context.Background(), "do it", func(agent.AgentMessage) {})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(exec.prompts) != 2 || exec.prompts[1] != "try something else" {
+		t.Errorf("expected nudge prompt second, got %q", exec.prompts)
+	}
+	if len(events) != 1 || events[0].Action != ActionNudge || !strings.Contains(events[0].Reason, "repeated") {
+		t.Errorf("unexpected events: %+v", events)
+	}
+	if exec.machine.State() != agent.StateRunning {
+		t.Errorf("got state %s, want %s", exec.machine.State(), agent.StateRunning)
+	}
+}
+
+func TestSupervisor_FailingCommandRestartsWithRecovery(t *testing.T) {
+	flaky := script{messages: []agent.AgentMessage{failed("go test ./..."), failed("go test ./...")}}
+	exec := newFakeExecutor(flaky, script{messages: []agent.AgentMessage{{Text: "fixed"}}})
+	exec.calls = []agent.ToolCall{
+		{Name: "Edit", Summary: "edited foo.go", Files: []string{"foo.go"}},
+		{Name: "Bash", Summary: "ran go test ./...", Error: "exit status 1"},
+	}
+	s, err := New(exec, Config{FailureLimit: 2, Actions: []Action{ActionRestart}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = s.Run(context.Background(), "implement foo", func(agent.AgentMessage) {})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exec.resets != 1 {
+		t.Errorf("got %d resets, want 1", exec.resets)
+	}
+	recovery := exec.prompts[1]
+	for _, want := range []string{"implement foo", "ran the same failing command 2 times", "- foo.go", "- ran go test ./... (failed)"} {
+		if !strings.Contains(recovery, want) {
+			t.Errorf("recovery prompt missing %q:\n%s", want, recovery)
+		}
+	}
+}
+
+func TestSupervisor_StuckCountsSpanIterations(t *testing.T) {
+	first := script{messages: []agent.AgentMessage{failed("go test ./..."), started("Bash", "ls"), started("Bash", "ls")}, hang: true}
+	second := script{messages: []agent.AgentMessage{failed("go test ./...")}, hang: true}
+	exec := newFakeExecutor(first, second, script{messages: []agent.AgentMessage{{Text: "ok"}}})
+	s, err := New(exec, Config{RepeatLimit: 2, FailureLimit: 2, NoOutputTimeout: "1s", Actions: []Action{ActionNudge, ActionNudge}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var events []Event
+	s.Notify(func(e Event) { events = append(events, e) })
+
+	err = s.Run(context.Background(), "do it", func(agent.AgentMessage) {})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 || !strings.Contains(events[1].Reason, "ran the same failing command 2 times") {
+		t.Errorf("expected the second failure to cross the limit after a nudge, got %+v", events)
+	}
+}
+
+func TestSupervisor_NoOutputFailsWhenLadderExhausted(t *testing.T) {
+	exec := newFakeExecutor(script{hang: true})
+	s, err := New(exec, Config{NoOutputTimeout: "20ms", Actions: []Action{}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	err = s.Run(context.Background(), "do it", func(agent.AgentMessage) {})
+
+	if !errors.Is(err, ErrStuck) || !strings.Contains(err.Error(), "no output") {
+		t.Fatalf("got %v, want ErrStuck for no output", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("expected hung execute to be cancelled promptly")
+	}
+	if exec.machine.State() != agent.StateFailed {
+		t.Errorf("got state %s, want %s", exec.machine.State(), agent.StateFailed)
+	}
+}
+
+func TestSupervisor_IterationLimit(t *testing.T) {
+	loop := script{messages: []agent.AgentMessage{started("Bash", "ls"), started("Bash", "ls")}}
+	exec := newFakeExecutor(loop, loop, loop)
+	s, err := New(exec, Config{RepeatLimit: 2, MaxIterations: 2, Actions: []Action{ActionNudge, ActionNudge, ActionNudge}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = s.Run(context.Background(), "do it", func(agent.AgentMessage) {})
+
+	if !errors.Is(err, ErrIterationLimit) {
+		t.Fatalf("got %v, want ErrIterationLimit", err)
+	}
+	if s.Iterations() != 2 || exec.machine.State() != agent.StateFailed {
+		t.Errorf("got %d iterations in state %s", s.Iterations(), exec.machine.State())
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	exec := newFakeExecutor()
+	if _, err := New(exec, Config{NoOutputTimeout: "soon"}); err == nil {
+		t.Error("expected error for invalid timeout")
+	}
+	if _, err := New(exec, Config{Actions: []Action{"pray"}}); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
+
+func TestSupervisor_CheckpointsBeforeDeadline(t *testing.T) {
+	exec := newFakeExecutor(script{hang: true}, script{messages: []agent.AgentMessage{{Text: "WIP committed"}}})
+	s, err := New(exec, Config{CheckpointGrace: "100ms", CheckpointPrompt: "checkpoint now"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	var got []string
+	err = s.Run(ctx, "do it", func(msg agent.AgentMessage) { got = append(got, msg.Text) })
+
+	if !errors.Is(err, agent.ErrTimeout) {
+		t.Fatalf("got %v, want ErrTimeout", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("expected checkpoint to finish before the hard deadline")
+	}
+	if len(exec.prompts) != 2 || exec.prompts[1] != "checkpoint now" {
+		t.Errorf("expected checkpoint prompt second, got %q", exec.prompts)
+	}
+	if len(got) != 1 || got[0] != "WIP committed" {
+		t.Errorf("expected checkpoint output forwarded, got %v", got)
+	}
+	if exec.machine.State() != agent.StateFailed {
+		t.Errorf("got state %s, want %s", exec.machine.State(), agent.StateFailed)
+	}
+}
@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExceeded is the cancellation cause when a run goes over budget.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget caps resource use. Zero fields are unlimited.
+type Budget struct {
+	CostUSD float64 `json:"costUSD,omitempty"`
+	Tokens  int64   `json:"tokens,omitempty"`
+	Turns   int     `json:"turns,omitempty"`
+	Minutes float64 `json:"minutes,omitempty"`
+}
+
+// Budgets are the limits for a single run, all runs of a task, and the whole session.
+type Budgets struct {
+	Run     Budget `json:"run"`
+	Task    Budget `json:"task"`
+	Session Budget `json:"session"`
+}
+
+// Check returns an ErrBudgetExceeded error naming the first limit u is over, or nil.
+// scope names the budget in the message (e.g. "run").
+func (b Budget) Check(scope string, u Usage) error {
+	switch {
+	case b.CostUSD > 0 && u.CostUSD > b.CostUSD:
+		return fmt.Errorf("%w: %s cost $%.2f over $%.2f", ErrBudgetExceeded, scope, u.CostUSD, b.CostUSD)
+	case b.Tokens > 0 && u.Tokens() > b.Tokens:
+		return fmt.Errorf("%w: %s used %d tokens, limit %d", ErrBudgetExceeded, scope, u.Tokens(), b.Tokens)
+	case b.Turns > 0 && u.Turns > b.Turns:
+		return fmt.Errorf("%w: %s took %d turns, limit %d", ErrBudgetExceeded, scope, u.Turns, b.Turns)
+	case b.Minutes > 0 && u.Duration() >= b.wallTime():
+		return fmt.Errorf("%w: %s ran %s, limit %s", ErrBudgetExceeded, scope, u.Duration().Round(time.Second), b.wallTime())
+	}
+	return nil
+}
+
+// wallTime returns the Minutes limit as a duration.
+func (b Budget) wallTime() time.Duration {
+	return time.Duration(b.Minutes * float64(time.Minute))
+}
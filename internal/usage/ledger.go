@@ -0,0 +1,272 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
+)
+
+// Snapshot is a point-in-time copy of a Ledger's rollups.
+type Snapshot struct {
+	StartedAt time.Time        `json:"startedAt"`
+	Session   Usage            `json:"session"`
+	Tasks     map[string]Usage `json:"tasks"`
+	Personas  map[string]Usage `json:"personas"`
+}
+
+// Ledger rolls up finished runs per task, persona and session, and hands out
+// Meters that enforce budgets against those rollups.
+type Ledger struct {
+	prices  PriceTable
+	budgets Budgets
+
+	mu       sync.Mutex
+	started  time.Time
+	session  Usage
+	tasks    map[string]Usage
+	personas map[string]Usage
+	now      func() time.Time
+}
+
+// NewLedger creates a Ledger for a new session.
+func NewLedger(prices PriceTable, budgets Budgets) *Ledger {
+	return &Ledger{
+		prices:   prices,
+		budgets:  budgets,
+		started:  time.Now(),
+		tasks:    make(map[string]Usage),
+		personas: make(map[string]Usage),
+		now:      time.Now,
+	}
+}
+
+// Record adds a finished run to the rollups.
+func (l *Ledger) Record(persona, taskID string, u Usage) {
+	u.Runs = 1
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.session = l.session.Add(u)
+	if taskID != "" {
+		l.tasks[taskID] = l.tasks[taskID].Add(u)
+	}
+	l.personas[persona] = l.personas[persona].Add(u)
+}
+
+// Task returns the rolled-up usage of a task.
+func (l *Ledger) Task(taskID string) Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tasks[taskID]
+}
+
+// Persona returns the rolled-up usage of a persona.
+func (l *Ledger) Persona(name string) Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.personas[name]
+}
+
+// Session returns the rolled-up usage of the whole session.
+func (l *Ledger) Session() Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.session
+}
+
+// Snapshot copies the current rollups.
+func (l *Ledger) Snapshot() Snapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := Snapshot{
+		StartedAt: l.started,
+		Session:   l.session,
+		Tasks:     make(map[string]Usage, len(l.tasks)),
+		Personas:  make(map[string]Usage, len(l.personas)),
+	}
+	for k, v := range l.tasks {
+		s.Tasks[k] = v
+	}
+	for k, v := range l.personas {
+		s.Personas[k] = v
+	}
+	return s
+}
+
+// Save writes the snapshot to path (e.g. .axiom/metrics/session.json). The file
+// is replaced via a temporary file, so readers and concurrent saves never see it
+// half written.
+func (l *Ledger) Save(path string) error {
+	data, err := json.MarshalIndent(l.Snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("write session usage: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write session usage: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write session usage: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write session usage: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write session usage: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write session usage: %w", err)
+	}
+	return nil
+}
+
+// Meter starts accounting for one run. cancel is called with an ErrBudgetExceeded
+// cause as soon as the run, its task or the session goes over budget.
+func (l *Ledger) Meter(persona, taskID, model string, cancel context.CancelCauseFunc) *Meter {
+	m := &Meter{
+		ledger:  l,
+		persona: persona,
+		taskID:  taskID,
+		model:   model,
+		cancel:  cancel,
+		started: l.now(),
+	}
+	if d := l.budgets.Run.wallTime(); d > 0 {
+		m.timer = time.AfterFunc(d, func() { m.check() })
+	}
+	return m
+}
+
+// Meter accounts for a single agent run.
+type Meter struct {
+	ledger  *Ledger
+	persona string
+	taskID  string
+	model   string
+	cancel  context.CancelCauseFunc
+	started time.Time
+	timer   *time.Timer
+
+	mu       sync.Mutex
+	usage    Usage
+	toolUses int
+	finished bool
+	err      error
+}
+
+// Observe accounts for one message from the run. Tool-use rounds count as turns
+// until the result message reports the exact turn count and token usage.
+func (m *Meter) Observe(msg claude.Message) {
+	m.mu.Lock()
+	switch msg := msg.(type) {
+	case *claude.AssistantMessage:
+		if msg.Model != "" {
+			m.model = msg.Model
+		}
+		for _, block := range msg.Content {
+			if _, ok := block.(*claude.ToolUseBlock); ok {
+				m.toolUses++
+				m.usage.Turns = m.toolUses
+				break
+			}
+		}
+	case *claude.ResultMessage:
+		result := FromResult(msg, m.ledger.prices, m.model)
+		m.usage.InputTokens += result.InputTokens
+		m.usage.OutputTokens += result.OutputTokens
+		m.usage.CacheReadTokens += result.CacheReadTokens
+		m.usage.CacheWriteTokens += result.CacheWriteTokens
+		m.usage.CostUSD += result.CostUSD
+		m.usage.Turns = max(m.usage.Turns, result.Turns)
+	}
+	m.mu.Unlock()
+
+	m.check()
+}
+
+// Usage returns the run's usage so far, including wall time.
+func (m *Meter) Usage() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current()
+}
+
+// Err returns the budget error that cancelled the run, if any.
+func (m *Meter) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+// Finish stops the meter, records the run in the ledger and returns its usage.
+// Calling Finish more than once records the run only once.
+func (m *Meter) Finish() Usage {
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+
+	m.mu.Lock()
+	u := m.current()
+	already := m.finished
+	m.finished = true
+	m.mu.Unlock()
+
+	if !already {
+		m.ledger.Record(m.persona, m.taskID, u)
+	}
+	return u
+}
+
+// current returns usage with wall time filled in. Callers hold m.mu.
+func (m *Meter) current() Usage {
+	u := m.usage
+	u.DurationMs = m.ledger.now().Sub(m.started).Milliseconds()
+	u.Runs = 1
+	return u
+}
+
+// check cancels the run the first time any budget is exceeded.
+func (m *Meter) check() {
+	m.mu.Lock()
+	if m.err != nil || m.finished {
+		m.mu.Unlock()
+		return
+	}
+	run := m.current()
+	m.mu.Unlock()
+
+	budgets := m.ledger.budgets
+	err := budgets.Run.Check("run", run)
+	if err == nil && m.taskID != "" {
+		err = budgets.Task.Check("task "+m.taskID, m.ledger.Task(m.taskID).Add(run))
+	}
+	if err == nil {
+		err = budgets.Session.Check("session", m.ledger.Session().Add(run))
+	}
+	if err == nil {
+		return
+	}
+
+	m.mu.Lock()
+	if m.err == nil {
+		m.err = err
+	}
+	m.mu.Unlock()
+	if m.cancel != nil {
+		m.cancel(err)
+	}
+}
@@ -0,0 +1,160 @@
+// Package usage accounts for the tokens, cost, turns and time agent runs consume,
+// rolls them up per task, persona and session, and enforces budgets.
+package usage
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
+)
+
+// Usage is the resources consumed by one or more agent runs.
+type Usage struct {
+	InputTokens      int64   `json:"inputTokens"`
+	OutputTokens     int64   `json:"outputTokens"`
+	CacheReadTokens  int64   `json:"cacheReadTokens"`
+	CacheWriteTokens int64   `json:"cacheWriteTokens"`
+	CostUSD          float64 `json:"costUSD"`
+	Turns            int     `json:"turns"`
+	DurationMs       int64   `json:"durationMs"`
+
+	// Runs is the number of runs rolled up into this Usage.
+	Runs int `json:"runs,omitempty"`
+}
+
+// Add returns the sum of u and other.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:      u.InputTokens + other.InputTokens,
+		OutputTokens:     u.OutputTokens + other.OutputTokens,
+		CacheReadTokens:  u.CacheReadTokens + other.CacheReadTokens,
+		CacheWriteTokens: u.CacheWriteTokens + other.CacheWriteTokens,
+		CostUSD:          u.CostUSD + other.CostUSD,
+		Turns:            u.Turns + other.Turns,
+		DurationMs:       u.DurationMs + other.DurationMs,
+		Runs:             u.Runs + other.Runs,
+	}
+}
+
+// Tokens returns all tokens, including cache reads and writes.
+func (u Usage) Tokens() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// Duration returns the wall time as a time.Duration.
+func (u Usage) Duration() time.Duration {
+	return time.Duration(u.DurationMs) * time.Millisecond
+}
+
+// Price is a model's price in USD per million tokens.
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cacheRead"`
+	CacheWrite float64 `json:"cacheWrite"`
+}
+
+// Cost returns the estimated USD cost of u's tokens.
+func (p Price) Cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheReadTokens)*p.CacheRead +
+		float64(u.CacheWriteTokens)*p.CacheWrite) / 1_000_000
+}
+
+// PriceTable maps model names or name prefixes to prices.
+type PriceTable map[string]Price
+
+// DefaultPrices returns list prices for current Claude models.
+func DefaultPrices() PriceTable {
+	sonnet := Price{Input: 3, Output: 15, CacheRead: 0.30, CacheWrite: 3.75}
+	opus := Price{Input: 15, Output: 75, CacheRead: 1.50, CacheWrite: 18.75}
+	haiku := Price{Input: 1, Output: 5, CacheRead: 0.10, CacheWrite: 1.25}
+	return PriceTable{
+		"sonnet":            sonnet,
+		"opus":              opus,
+		"haiku":             haiku,
+		"claude-sonnet-4":   sonnet,
+		"claude-opus-4":     opus,
+		"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.50, CacheWrite: 6.25},
+		"claude-haiku-4-5":  haiku,
+		"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+		"claude-3-7-sonnet": sonnet,
+	}
+}
+
+// Lookup returns the price for model, matching the longest name prefix.
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	for _, k := range keys {
+		if strings.HasPrefix(model, k) {
+			return t[k], true
+		}
+	}
+	return Price{}, false
+}
+
+// FromResult extracts usage from a result message, pricing tokens with prices.
+// Models missing from the table keep the cost the CLI reported.
+// defaultModel is used when the result does not break usage down by model.
+func FromResult(msg *claude.ResultMessage, prices PriceTable, defaultModel string) Usage {
+	u := Usage{Turns: msg.NumTurns, DurationMs: int64(msg.DurationMs)}
+
+	if len(msg.ModelUsage) > 0 {
+		for model, mu := range msg.ModelUsage {
+			m := Usage{
+				InputTokens:      int64(mu.InputTokens),
+				OutputTokens:     int64(mu.OutputTokens),
+				CacheReadTokens:  int64(mu.CacheReadInputTokens),
+				CacheWriteTokens: int64(mu.CacheCreationInputTokens),
+				CostUSD:          mu.CostUSD,
+			}
+			if p, ok := prices.Lookup(model); ok {
+				m.CostUSD = p.Cost(m)
+			}
+			u.InputTokens += m.InputTokens
+			u.OutputTokens += m.OutputTokens
+			u.CacheReadTokens += m.CacheReadTokens
+			u.CacheWriteTokens += m.CacheWriteTokens
+			u.CostUSD += m.CostUSD
+		}
+		return u
+	}
+
+	if msg.Usage != nil {
+		raw := *msg.Usage
+		u.InputTokens = intField(raw, "input_tokens")
+		u.OutputTokens = intField(raw, "output_tokens")
+		u.CacheReadTokens = intField(raw, "cache_read_input_tokens")
+		u.CacheWriteTokens = intField(raw, "cache_creation_input_tokens")
+	}
+	if p, ok := prices.Lookup(defaultModel); ok {
+		u.CostUSD = p.Cost(u)
+	} else if msg.TotalCostUSD != nil {
+		u.CostUSD = *msg.TotalCostUSD
+	}
+	return u
+}
+
+// intField reads a JSON number from a decoded usage map.
+func intField(m map[string]any, key string) int64 {
+	switch v := m[key].(type) {
+	case float64:
+		return int64(v)
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return 0
+}
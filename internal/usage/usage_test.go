@@ -0,0 +1,191 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
+)
+
+func TestPriceTable_LookupLongestPrefix(t *testing.T) {
+	prices := DefaultPrices()
+
+	p, ok := prices.Lookup("claude-opus-4-5-20251101")
+	if !ok || p.Input != 5 {
+		t.Errorf("expected opus 4.5 price, got %+v (ok=%v)", p, ok)
+	}
+	p, ok = prices.Lookup("claude-opus-4-20250514")
+	if !ok || p.Input != 15 {
+		t.Errorf("expected opus 4 price, got %+v (ok=%v)", p, ok)
+	}
+	if _, ok := prices.Lookup("gpt-4"); ok {
+		t.Error("expected no price for unknown model")
+	}
+}
+
+func TestPrice_Cost(t *testing.T) {
+	p := Price{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+	u := Usage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 1_000_000}
+
+	if got := p.Cost(u); math.Abs(got-4.8) > 1e-9 {
+		t.Errorf("got cost %f, want 4.8", got)
+	}
+}
+
+func TestFromResult_PricesModelUsage(t *testing.T) {
+	cliCost := 9.99
+	msg := &claude.ResultMessage{
+		NumTurns:     4,
+		DurationMs:   1500,
+		TotalCostUSD: &cliCost,
+		ModelUsage: map[string]claude.ModelUsage{
+			"claude-sonnet-4-20250514": {InputTokens: 1_000_000, OutputTokens: 0},
+			"unknown-model":            {InputTokens: 10, CostUSD: 0.5},
+		},
+	}
+
+	u := FromResult(msg, DefaultPrices(), "")
+	if u.Turns != 4 || u.InputTokens != 1_000_010 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+	// $3 from the table for sonnet plus the CLI-reported $0.50 for the unknown model
+	if math.Abs(u.CostUSD-3.5) > 1e-9 {
+		t.Errorf("got cost %f, want 3.5", u.CostUSD)
+	}
+}
+
+func TestFromResult_FallsBackToUsageMap(t *testing.T) {
+	raw := map[string]any{"input_tokens": float64(1000), "output_tokens": float64(500)}
+	cliCost := 0.42
+	msg := &claude.ResultMessage{NumTurns: 1, Usage: &raw, TotalCostUSD: &cliCost}
+
+	u := FromResult(msg, DefaultPrices(), "my-model")
+	if u.InputTokens != 1000 || u.OutputTokens != 500 {
+		t.Errorf("unexpected tokens: %+v", u)
+	}
+	if u.CostUSD != 0.42 {
+		t.Errorf("expected CLI cost for unpriced model, got %f", u.CostUSD)
+	}
+}
+
+func TestBudget_Check(t *testing.T) {
+	u := Usage{CostUSD: 3, InputTokens: 900, OutputTokens: 200, Turns: 12, DurationMs: 120_000}
+
+	tests := []struct {
+		name   string
+		budget Budget
+		over   bool
+	}{
+		{"unlimited", Budget{}, false},
+		{"cost", Budget{CostUSD: 2}, true},
+		{"tokens", Budget{Tokens: 1000}, true},
+		{"turns", Budget{Turns: 10}, true},
+		{"minutes", Budget{Minutes: 1}, true},
+		{"within limits", Budget{CostUSD: 5, Tokens: 5000, Turns: 20, Minutes: 5}, false},
+	}
+	for _, tt := range tests {
+		err := tt.budget.Check("run", u)
+		if tt.over != errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("%s: got %v, want over=%v", tt.name, err, tt.over)
+		}
+	}
+}
+
+func TestLedger_RollsUpRuns(t *testing.T) {
+	l := NewLedger(DefaultPrices(), Budgets{})
+
+	l.Record("echo", "task-001", Usage{InputTokens: 10, CostUSD: 1})
+	l.Record("echo", "task-002", Usage{InputTokens: 20, CostUSD: 2})
+	l.Record("rex", "task-001", Usage{InputTokens: 30, CostUSD: 3})
+
+	if got := l.Task("task-001"); got.InputTokens != 40 || got.Runs != 2 {
+		t.Errorf("unexpected task rollup: %+v", got)
+	}
+	if got := l.Persona("echo"); got.CostUSD != 3 || got.Runs != 2 {
+		t.Errorf("unexpected persona rollup: %+v", got)
+	}
+	if got := l.Session(); got.InputTokens != 60 || got.Runs != 3 {
+		t.Errorf("unexpected session rollup: %+v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "metrics", "session.json")
+	if err := l.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var saved Snapshot
+	if data, err := os.ReadFile(path); err != nil || json.Unmarshal(data, &saved) != nil {
+		t.Fatalf("unreadable snapshot: %v", err)
+	}
+	if saved.Session.Runs != 3 {
+		t.Errorf("unexpected saved session: %+v", saved.Session)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("expected only session.json, got %v", entries)
+	}
+}
+
+func TestMeter_CancelsWhenRunBudgetExceeded(t *testing.T) {
+	l := NewLedger(DefaultPrices(), Budgets{Run: Budget{CostUSD: 1}})
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	m := l.Meter("echo", "task-001", "claude-sonnet-4-20250514", cancel)
+	m.Observe(&claude.ResultMessage{
+		NumTurns: 2,
+		ModelUsage: map[string]claude.ModelUsage{
+			"claude-sonnet-4-20250514": {OutputTokens: 100_000}, // $1.50
+		},
+	})
+
+	if !errors.Is(context.Cause(ctx), ErrBudgetExceeded) {
+		t.Fatalf("expected context cancelled for budget, got cause %v", context.Cause(ctx))
+	}
+	if !errors.Is(m.Err(), ErrBudgetExceeded) {
+		t.Errorf("expected meter error, got %v", m.Err())
+	}
+
+	u := m.Finish()
+	m.Finish()
+	if u.Turns != 2 || l.Task("task-001").Runs != 1 {
+		t.Errorf("expected one recorded run, got usage %+v and task %+v", u, l.Task("task-001"))
+	}
+}
+
+func TestMeter_TaskBudgetIncludesEarlierRuns(t *testing.T) {
+	l := NewLedger(DefaultPrices(), Budgets{Task: Budget{Turns: 5}})
+	l.Record("echo", "task-001", Usage{Turns: 4})
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	m := l.Meter("echo", "task-001", "", cancel)
+	m.Observe(&claude.AssistantMessage{Content: []claude.ContentBlock{&claude.ToolUseBlock{Name: "Bash"}}})
+	if ctx.Err() != nil {
+		t.Fatal("should not cancel at the limit")
+	}
+	m.Observe(&claude.AssistantMessage{Content: []claude.ContentBlock{&claude.ToolUseBlock{Name: "Edit"}}})
+
+	if !errors.Is(context.Cause(ctx), ErrBudgetExceeded) {
+		t.Errorf("expected task budget to cancel, got %v", context.Cause(ctx))
+	}
+}
+
+func TestMeter_WallTimeBudget(t *testing.T) {
+	l := NewLedger(DefaultPrices(), Budgets{Run: Budget{Minutes: 0.0005}}) // 30ms
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	m := l.Meter("echo", "", "", cancel)
+	defer m.Finish()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected wall time budget to cancel the run")
+	}
+	if !errors.Is(context.Cause(ctx), ErrBudgetExceeded) {
+		t.Errorf("unexpected cause %v", context.Cause(ctx))
+	}
+}
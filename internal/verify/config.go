@@ -0,0 +1,126 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is the per-command timeout in seconds when none is configured.
+const DefaultTimeout = 300
+
+// Config is the "verification" section of .axiom/config.json. It is either an
+// array of commands or an object with defaultTimeout and commands.
+type Config struct {
+	// DefaultTimeout applies to commands without their own timeout, in seconds. Default 300.
+	DefaultTimeout int `json:"defaultTimeout,omitempty"`
+
+	Commands []Command `json:"commands"`
+
+	// ContinueOnFailure runs the remaining commands after a required one fails,
+	// so the agent sees every failure at once. By default the gate stops at the first.
+	ContinueOnFailure bool `json:"continueOnFailure,omitempty"`
+}
+
+// Command is one verification command. It is either a command string or an object.
+type Command struct {
+	Command string `json:"command"`
+
+	// Name is shown in the UI and in feedback. Default the command itself.
+	Name string `json:"name,omitempty"`
+
+	// Timeout overrides Config.DefaultTimeout, in seconds.
+	Timeout int `json:"timeout,omitempty"`
+
+	// Required commands fail the gate; optional ones only log a warning. Default true.
+	Required *bool `json:"required,omitempty"`
+
+	// Paths limits the command to tasks that changed a matching file, e.g. ["web/"]
+	// or ["*.go"]. Scoping applies to task verification; the full suite before
+	// integration runs every command.
+	Paths []string `json:"paths,omitempty"`
+
+	// Scoped is the command line run instead of Command when verifying a task's
+	// changes, with {files} and {dirs} standing for the matching changed files and
+	// their directories, e.g. "go test {dirs}".
+	Scoped string `json:"scoped,omitempty"`
+
+	// Format names the command's test output, "go-test-json", "junit" or "tap",
+	// so failures are reported per test instead of as the raw log.
+	Format string `json:"format,omitempty"`
+
+	// Report is the file the command writes its results to, relative to the
+	// worktree, e.g. a JUnit XML report. Default the command's stdout.
+	Report string `json:"report,omitempty"`
+}
+
+// UnmarshalJSON accepts the array and object forms.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		*c = Config{}
+		return json.Unmarshal(data, &c.Commands)
+	}
+	type plain Config
+	return json.Unmarshal(data, (*plain)(c))
+}
+
+// UnmarshalJSON accepts a command string or an object.
+func (c *Command) UnmarshalJSON(data []byte) error {
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '"' {
+		*c = Command{}
+		return json.Unmarshal(data, &c.Command)
+	}
+	type plain Command
+	return json.Unmarshal(data, (*plain)(c))
+}
+
+// Validate reports commands without a command line, negative timeouts and unknown formats.
+func (c Config) Validate() error {
+	if c.DefaultTimeout < 0 {
+		return fmt.Errorf("verification defaultTimeout %d is negative", c.DefaultTimeout)
+	}
+	for i, cmd := range c.Commands {
+		if strings.TrimSpace(cmd.Command) == "" {
+			return fmt.Errorf("verification command %d is empty", i+1)
+		}
+		if cmd.Timeout < 0 {
+			return fmt.Errorf("verification command %q has a negative timeout", cmd.Command)
+		}
+		switch cmd.Format {
+		case "", FormatGoTest, FormatJUnit, FormatTAP:
+		default:
+			return fmt.Errorf("verification command %q has unknown format %q (want %s, %s or %s)", cmd.Command, cmd.Format, FormatGoTest, FormatJUnit, FormatTAP)
+		}
+		if cmd.Report != "" && cmd.Format == "" {
+			return fmt.Errorf("verification command %q has a report but no format", cmd.Command)
+		}
+	}
+	return nil
+}
+
+// name returns the display name.
+func (c Command) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Command
+}
+
+// required reports whether a failure fails the gate.
+func (c Command) required() bool {
+	return c.Required == nil || *c.Required
+}
+
+// timeout returns the command's timeout, falling back to def and then DefaultTimeout.
+func (c Command) timeout(def int) time.Duration {
+	secs := c.Timeout
+	if secs <= 0 {
+		secs = def
+	}
+	if secs <= 0 {
+		secs = DefaultTimeout
+	}
+	return time.Duration(secs) * time.Second
+}
@@ -0,0 +1,355 @@
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Output formats Command.Format can name; the command's test results are parsed from it.
+const (
+	FormatGoTest = "go-test-json"
+	FormatJUnit  = "junit"
+	FormatTAP    = "tap"
+)
+
+// TestStatus is one test's outcome.
+type TestStatus string
+
+const (
+	TestPassed  TestStatus = "pass"
+	TestFailed  TestStatus = "fail"
+	TestSkipped TestStatus = "skip"
+)
+
+// Test is one test case parsed from a command's output.
+type Test struct {
+	Name string `json:"name"`
+	// Suite is the Go package, JUnit class or suite the test belongs to.
+	Suite  string     `json:"suite,omitempty"`
+	Status TestStatus `json:"status"`
+
+	// Message is the failure or skip output; passing tests have none.
+	Message string `json:"message,omitempty"`
+	// File and Line locate the failure, when the output reports one.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+
+	DurationMs int64 `json:"durationMs,omitempty"`
+}
+
+// title names the test for feedback.
+func (t Test) title() string {
+	switch {
+	case t.Suite == "":
+		return t.Name
+	case t.Name == "":
+		return t.Suite
+	}
+	return t.Suite + " " + t.Name
+}
+
+// location returns "file:line", or "" when the output reports none.
+func (t Test) location() string {
+	if t.File == "" {
+		return ""
+	}
+	if t.Line == 0 {
+		return t.File
+	}
+	return fmt.Sprintf("%s:%d", t.File, t.Line)
+}
+
+// fileLine matches the first source location in output such as "foo_test.go:12:"
+// or "at /src/app.test.js:3:7".
+var fileLine = regexp.MustCompile(`((?:[\w.@-]+/)*[\w.@-]+\.[A-Za-z]\w*):(\d+)`)
+
+// locate sets t's File and Line from the first source location in its message.
+func (t *Test) locate() {
+	if t.File != "" {
+		return
+	}
+	if m := fileLine.FindStringSubmatch(t.Message); m != nil {
+		t.File = m[1]
+		t.Line, _ = strconv.Atoi(m[2])
+	}
+}
+
+// Parse extracts the tests from output in format.
+func Parse(format string, output []byte) ([]Test, error) {
+	switch format {
+	case FormatGoTest:
+		return parseGoTest(output)
+	case FormatJUnit:
+		return parseJUnit(output)
+	case FormatTAP:
+		return parseTAP(output)
+	}
+	return nil, fmt.Errorf("unknown test output format %q", format)
+}
+
+// goTestEvent is one line of `go test -json` (go doc test2json).
+type goTestEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Elapsed     float64
+	Output      string
+	ImportPath  string
+	FailedBuild string
+}
+
+// parseGoTest reads `go test -json` output. Lines that are not JSON events, such
+// as build errors older Go versions print, are ignored. Packages that fail
+// without a failing test, e.g. because they do not build, are reported as a test
+// without a name.
+func parseGoTest(output []byte) ([]Test, error) {
+	type key struct{ pkg, test string }
+	var (
+		tests   []Test
+		outputs = make(map[key]*strings.Builder)
+		builds  = make(map[string]*strings.Builder)
+		failed  = make(map[string]bool)
+		events  int
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var ev goTestEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			continue
+		}
+		events++
+		k := key{ev.Package, ev.Test}
+
+		switch ev.Action {
+		case "output":
+			if outputs[k] == nil {
+				outputs[k] = &strings.Builder{}
+			}
+			outputs[k].WriteString(ev.Output)
+		case "build-output":
+			if builds[ev.ImportPath] == nil {
+				builds[ev.ImportPath] = &strings.Builder{}
+			}
+			builds[ev.ImportPath].WriteString(ev.Output)
+		case "pass", "fail", "skip":
+			if ev.Test == "" && (ev.Action != "fail" || failed[ev.Package]) {
+				continue
+			}
+			t := Test{Name: ev.Test, Suite: ev.Package, Status: TestStatus(ev.Action), DurationMs: int64(ev.Elapsed * 1000)}
+			if t.Status != TestPassed {
+				out := ""
+				if outputs[k] != nil {
+					out = outputs[k].String()
+				}
+				if ev.FailedBuild != "" && builds[ev.FailedBuild] != nil {
+					out = builds[ev.FailedBuild].String() + out
+				}
+				t.Message = tail(cleanGoTestOutput(out))
+			}
+			if t.Status == TestFailed {
+				t.locate()
+				failed[ev.Package] = true
+			}
+			tests = append(tests, t)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("parse go test output: %w", err)
+	}
+	if events == 0 && len(bytes.TrimSpace(output)) > 0 {
+		return nil, fmt.Errorf("parse go test output: no test events; is the command run with -json?")
+	}
+	return dropFailedParents(tests), nil
+}
+
+// cleanGoTestOutput drops go test's own progress lines, keeping what the test logged.
+func cleanGoTestOutput(out string) string {
+	var kept []string
+	for _, line := range strings.Split(out, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", trimmed == "PASS", trimmed == "FAIL",
+			strings.HasPrefix(trimmed, "=== "),
+			strings.HasPrefix(trimmed, "--- PASS"), strings.HasPrefix(trimmed, "--- FAIL"), strings.HasPrefix(trimmed, "--- SKIP"),
+			strings.HasPrefix(trimmed, "ok  \t"), strings.HasPrefix(trimmed, "FAIL\t"):
+			continue
+		}
+		kept = append(kept, trimmed)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// dropFailedParents removes failed Go tests that logged nothing themselves and
+// only failed because a subtest did, so each failure is reported once.
+func dropFailedParents(tests []Test) []Test {
+	failedSub := make(map[string]bool)
+	for _, t := range tests {
+		if t.Status != TestFailed {
+			continue
+		}
+		for i := strings.LastIndex(t.Name, "/"); i > 0; i = strings.LastIndex(t.Name[:i], "/") {
+			failedSub[t.Suite+" "+t.Name[:i]] = true
+		}
+	}
+	kept := tests[:0]
+	for _, t := range tests {
+		if t.Status == TestFailed && t.Message == "" && failedSub[t.Suite+" "+t.Name] {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept
+}
+
+// junitSuite is a <testsuites> or <testsuite> element; suites may nest.
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Line      string        `xml:"line,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// text joins the message attribute and the element's body.
+func (m junitMessage) text() string {
+	msg, body := strings.TrimSpace(m.Message), strings.TrimSpace(m.Text)
+	if msg == "" || strings.HasPrefix(body, msg) {
+		return body
+	}
+	if body == "" {
+		return msg
+	}
+	return msg + "\n" + body
+}
+
+// parseJUnit reads a JUnit XML report rooted at <testsuites> or <testsuite>.
+// Errors count as failures.
+func parseJUnit(output []byte) ([]Test, error) {
+	var root junitSuite
+	if err := xml.Unmarshal(output, &root); err != nil {
+		return nil, fmt.Errorf("parse JUnit report: %w", err)
+	}
+	var tests []Test
+	var walk func(s junitSuite)
+	walk = func(s junitSuite) {
+		for _, c := range s.Cases {
+			t := Test{Name: c.Name, Suite: c.Classname, Status: TestPassed, File: c.File}
+			if t.Suite == "" {
+				t.Suite = s.Name
+			}
+			t.Line, _ = strconv.Atoi(c.Line)
+			if secs, err := strconv.ParseFloat(strings.ReplaceAll(c.Time, ",", ""), 64); err == nil {
+				t.DurationMs = int64(secs * 1000)
+			}
+			switch {
+			case c.Failure != nil:
+				t.Status, t.Message = TestFailed, tail(c.Failure.text())
+			case c.Error != nil:
+				t.Status, t.Message = TestFailed, tail(c.Error.text())
+			case c.Skipped != nil:
+				t.Status, t.Message = TestSkipped, tail(c.Skipped.text())
+			}
+			if t.Status == TestFailed {
+				t.locate()
+			}
+			tests = append(tests, t)
+		}
+		for _, sub := range s.Suites {
+			walk(sub)
+		}
+	}
+	walk(root)
+	return tests, nil
+}
+
+// tapResult matches a TAP test line: "ok 1 - name # SKIP reason".
+var tapResult = regexp.MustCompile(`^(not ok|ok)\b\s*(\d*)\s*(?:-\s*)?(.*)$`)
+
+// parseTAP reads TAP output, including indented subtests. A failed test's YAML
+// block and the "#" diagnostics after it become its message; SKIP and TODO
+// directives count as skipped.
+func parseTAP(output []byte) ([]Test, error) {
+	var tests []Test
+	var diag []string
+	inYAML := false
+	flush := func() {
+		if len(tests) > 0 && len(diag) > 0 {
+			t := &tests[len(tests)-1]
+			if t.Status != TestPassed {
+				t.Message = tail(strings.TrimSpace(strings.Join(diag, "\n")))
+				if t.Status == TestFailed {
+					t.locate()
+				}
+			}
+		}
+		diag = nil
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inYAML {
+			if trimmed == "..." {
+				inYAML = false
+			} else {
+				diag = append(diag, strings.TrimRight(line, " \r"))
+			}
+			continue
+		}
+		if trimmed == "---" && len(tests) > 0 {
+			inYAML = true
+			continue
+		}
+		m := tapResult.FindStringSubmatch(trimmed)
+		if m == nil {
+			if strings.HasPrefix(trimmed, "#") && len(tests) > 0 && !strings.HasPrefix(trimmed, "# Subtest") {
+				diag = append(diag, strings.TrimSpace(strings.TrimPrefix(trimmed, "#")))
+			}
+			continue
+		}
+		flush()
+
+		t := Test{Name: m[3], Status: TestPassed}
+		if m[1] == "not ok" {
+			t.Status = TestFailed
+		}
+		if i := strings.Index(t.Name, "#"); i >= 0 {
+			directive := strings.TrimSpace(t.Name[i+1:])
+			t.Name = strings.TrimSpace(t.Name[:i])
+			if upper := strings.ToUpper(directive); strings.HasPrefix(upper, "SKIP") || strings.HasPrefix(upper, "TODO") {
+				t.Status = TestSkipped
+				t.Message = directive
+			}
+		}
+		if t.Name == "" {
+			t.Name = "test " + m[2]
+		}
+		tests = append(tests, t)
+	}
+	flush()
+	return tests, nil
+}
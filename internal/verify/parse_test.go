@@ -0,0 +1,202 @@
+package verify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const goTestJSON = `{"Action":"start","Package":"example.com/app"}
+{"Action":"run","Package":"example.com/app","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd","Output":"    add_test.go:12: got 3, want 4\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd","Output":"--- FAIL: TestAdd (0.00s)\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestAdd","Elapsed":0.25}
+{"Action":"run","Package":"example.com/app","Test":"TestTable"}
+{"Action":"run","Package":"example.com/app","Test":"TestTable/empty"}
+{"Action":"output","Package":"example.com/app","Test":"TestTable/empty","Output":"    table_test.go:30: unexpected error\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestTable/empty","Elapsed":0}
+{"Action":"output","Package":"example.com/app","Test":"TestTable","Output":"--- FAIL: TestTable (0.00s)\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestTable","Elapsed":0}
+{"Action":"output","Package":"example.com/app","Test":"TestSlow","Output":"    slow_test.go:8: short mode\n"}
+{"Action":"skip","Package":"example.com/app","Test":"TestSlow","Elapsed":0}
+{"Action":"pass","Package":"example.com/app","Test":"TestSub","Elapsed":0.01}
+{"Action":"output","Package":"example.com/app","Output":"FAIL\texample.com/app\t0.3s\n"}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.3}
+{"ImportPath":"example.com/broken","Action":"build-output","Output":"# example.com/broken\n"}
+{"ImportPath":"example.com/broken","Action":"build-output","Output":"broken/broken.go:5:2: undefined: missing\n"}
+{"Action":"start","Package":"example.com/broken"}
+{"Action":"output","Package":"example.com/broken","Output":"FAIL\texample.com/broken [build failed]\n"}
+{"Action":"fail","Package":"example.com/broken","Elapsed":0,"FailedBuild":"example.com/broken"}
+{"Action":"pass","Package":"example.com/ok","Elapsed":0.1}
+`
+
+const junitXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="cart" tests="4">
+    <testcase name="adds items" classname="CartTest" time="0.012" />
+    <testcase name="applies discount" classname="CartTest" file="tests/CartTest.php" line="41" time="1,002.5">
+      <failure message="Failed asserting that 90 matches expected 80." type="AssertionFailedError">tests/CartTest.php:41</failure>
+    </testcase>
+    <testcase name="checks out" classname="CheckoutTest" time="0.3">
+      <error message="TypeError: total is undefined">at Checkout.total (src/checkout.js:17:9)</error>
+    </testcase>
+    <testcase name="ships abroad" classname="CheckoutTest">
+      <skipped message="not supported yet" />
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+
+const tapOutput = `TAP version 13
+1..4
+ok 1 - parses input
+not ok 2 - rejects bad input
+  ---
+  message: 'expected error'
+  at: test/parse.test.js:22:5
+  ...
+ok 3 - network # SKIP offline
+not ok 4
+# timeout after 5000ms
+`
+
+func TestParse_GoTest(t *testing.T) {
+	// Act
+	tests, err := Parse(FormatGoTest, []byte(goTestJSON))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Test{
+		{Name: "TestAdd", Suite: "example.com/app", Status: TestFailed, Message: "add_test.go:12: got 3, want 4", File: "add_test.go", Line: 12, DurationMs: 250},
+		{Name: "TestTable/empty", Suite: "example.com/app", Status: TestFailed, Message: "table_test.go:30: unexpected error", File: "table_test.go", Line: 30},
+		{Name: "TestSlow", Suite: "example.com/app", Status: TestSkipped, Message: "slow_test.go:8: short mode"},
+		{Name: "TestSub", Suite: "example.com/app", Status: TestPassed, DurationMs: 10},
+		{Suite: "example.com/broken", Status: TestFailed, Message: "# example.com/broken\nbroken/broken.go:5:2: undefined: missing", File: "broken/broken.go", Line: 5},
+	}
+	if len(tests) != len(want) {
+		t.Fatalf("got %d tests %+v, want %d", len(tests), tests, len(want))
+	}
+	for i := range want {
+		if tests[i] != want[i] {
+			t.Errorf("test %d = %+v, want %+v", i, tests[i], want[i])
+		}
+	}
+}
+
+func TestParse_GoTestWithoutJSON(t *testing.T) {
+	if _, err := Parse(FormatGoTest, []byte("--- FAIL: TestAdd\nFAIL\n")); err == nil {
+		t.Error("expected error for plain go test output")
+	}
+}
+
+func TestParse_JUnit(t *testing.T) {
+	// Act
+	tests, err := Parse(FormatJUnit, []byte(junitXML))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Test{
+		{Name: "adds items", Suite: "CartTest", Status: TestPassed, DurationMs: 12},
+		{Name: "applies discount", Suite: "CartTest", Status: TestFailed, Message: "Failed asserting that 90 matches expected 80.\ntests/CartTest.php:41", File: "tests/CartTest.php", Line: 41, DurationMs: 1002500},
+		{Name: "checks out", Suite: "CheckoutTest", Status: TestFailed, Message: "TypeError: total is undefined\nat Checkout.total (src/checkout.js:17:9)", File: "src/checkout.js", Line: 17, DurationMs: 300},
+		{Name: "ships abroad", Suite: "CheckoutTest", Status: TestSkipped, Message: "not supported yet"},
+	}
+	if len(tests) != len(want) {
+		t.Fatalf("got %d tests %+v, want %d", len(tests), tests, len(want))
+	}
+	for i := range want {
+		if tests[i] != want[i] {
+			t.Errorf("test %d = %+v, want %+v", i, tests[i], want[i])
+		}
+	}
+}
+
+func TestParse_JUnitInvalid(t *testing.T) {
+	if _, err := Parse(FormatJUnit, []byte("not xml")); err == nil {
+		t.Error("expected error for invalid XML")
+	}
+}
+
+func TestParse_TAP(t *testing.T) {
+	// Act
+	tests, err := Parse(FormatTAP, []byte(tapOutput))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Test{
+		{Name: "parses input", Status: TestPassed},
+		{Name: "rejects bad input", Status: TestFailed, Message: "message: 'expected error'\n  at: test/parse.test.js:22:5", File: "test/parse.test.js", Line: 22},
+		{Name: "network", Status: TestSkipped, Message: "SKIP offline"},
+		{Name: "test 4", Status: TestFailed, Message: "timeout after 5000ms"},
+	}
+	if len(tests) != len(want) {
+		t.Fatalf("got %d tests %+v, want %d", len(tests), tests, len(want))
+	}
+	for i := range want {
+		if tests[i] != want[i] {
+			t.Errorf("test %d = %+v, want %+v", i, tests[i], want[i])
+		}
+	}
+}
+
+func TestRun_ParsesReportIntoFeedback(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "fixture.xml"), []byte(junitXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Commands: []Command{{Name: "phpunit", Command: "cp fixture.xml junit.xml; exit 1", Format: FormatJUnit, Report: "junit.xml"}}}
+
+	// Act
+	report := Run(context.Background(), cfg, dir, nil)
+
+	// Assert
+	res := report.Results[0]
+	if report.Passed || len(res.Tests) != 4 || res.ParseError != "" {
+		t.Fatalf("got %+v, want a failed run with 4 parsed tests", res)
+	}
+	feedback := report.Feedback()
+	for _, want := range []string{"- FAIL CartTest applies discount (tests/CartTest.php:41)", "90 matches expected 80", "- FAIL CheckoutTest checks out (src/checkout.js:17)"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback missing %q:\n%s", want, feedback)
+		}
+	}
+	if strings.Contains(feedback, "adds items") || strings.Contains(feedback, "<testsuite") {
+		t.Errorf("feedback includes passing tests or the raw report:\n%s", feedback)
+	}
+}
+
+func TestRun_StaleReport(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "junit.xml"), []byte(junitXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, "junit.xml"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Commands: []Command{{Command: "echo build failed; exit 1", Format: FormatJUnit, Report: "junit.xml"}}}
+
+	// Act
+	report := Run(context.Background(), cfg, dir, nil)
+
+	// Assert
+	res := report.Results[0]
+	if len(res.Tests) != 0 || res.ParseError == "" {
+		t.Fatalf("got %+v, want the stale report ignored", res)
+	}
+	if !strings.Contains(report.Feedback(), "build failed") {
+		t.Errorf("feedback should fall back to the output:\n%s", report.Feedback())
+	}
+}
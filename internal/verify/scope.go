@@ -0,0 +1,120 @@
+package verify
+
+import (
+	"context"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+	"github.com/deligoez/axiom/internal/pathglob"
+)
+
+// Placeholders in Command.Scoped, replaced by shell-quoted lists of the matching changes.
+const (
+	// FilesPlaceholder is replaced by the changed files that still exist.
+	FilesPlaceholder = "{files}"
+	// DirsPlaceholder is replaced by their directories as "./dir", e.g. Go packages.
+	DirsPlaceholder = "{dirs}"
+)
+
+// Changed returns the files that differ between base and the worktree at dir:
+// committed and uncommitted changes, deletions and untracked files, slash-separated.
+func Changed(ctx context.Context, dir, base string) ([]string, error) {
+	diff, err := gitcmd.Run(ctx, dir, "diff", "--name-only", "--no-renames", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitcmd.Run(ctx, dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, f := range strings.Split(diff+"\n"+untracked, "\n") {
+		if f = strings.TrimSpace(f); f != "" && !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// RunChanged runs the gate scoped to changed, the files the task touched: commands
+// with Paths run only when a changed file matches them, and Scoped command lines
+// run in place of the full ones. Use Run for the full suite before integration.
+func RunChanged(ctx context.Context, cfg Config, dir string, changed []string, env map[string]string) Report {
+	if changed == nil {
+		changed = []string{}
+	}
+	return run(ctx, cfg, dir, changed, env)
+}
+
+// RunTask runs the gate scoped to the changes in the task worktree at dir since
+// base, the commit its branch was created from. When the diff cannot be computed
+// it falls back to the full suite.
+func RunTask(ctx context.Context, cfg Config, dir, base string, env map[string]string) Report {
+	changed, err := Changed(ctx, dir, base)
+	if err != nil {
+		log.Printf("[WARN] verification scope: %v; running the full suite", err)
+		return Run(ctx, cfg, dir, env)
+	}
+	return RunChanged(ctx, cfg, dir, changed, env)
+}
+
+// scoped returns the command line to run for the changes, or false when none of
+// them concern the command.
+func (c Command) scoped(dir string, changed []string) (string, bool) {
+	matched := changed
+	if len(c.Paths) > 0 {
+		matched = nil
+		for _, f := range changed {
+			if pathglob.MatchAny(c.Paths, f) {
+				matched = append(matched, f)
+			}
+		}
+		if len(matched) == 0 {
+			return "", false
+		}
+	}
+	if c.Scoped == "" {
+		return c.Command, true
+	}
+
+	var files, dirs []string
+	seen := make(map[string]bool)
+	for _, f := range matched {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f))); err == nil {
+			files = append(files, f)
+		}
+		d := "./" + path.Dir(f)
+		if d == "./." {
+			d = "."
+		}
+		if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(d))); err == nil && info.IsDir() && !seen[d] {
+			seen[d] = true
+			dirs = append(dirs, d)
+		}
+	}
+	if len(files) == 0 && len(dirs) == 0 {
+		// Everything matching was deleted; nothing is left to check in scope.
+		return "", false
+	}
+
+	line := strings.ReplaceAll(c.Scoped, FilesPlaceholder, quoteAll(files))
+	return strings.ReplaceAll(line, DirsPlaceholder, quoteAll(dirs)), true
+}
+
+// quoteAll single-quotes each word for sh and joins them with spaces.
+func quoteAll(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = "'" + strings.ReplaceAll(w, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
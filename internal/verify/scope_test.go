@@ -0,0 +1,151 @@
+package verify
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// initRepo creates a git repository with Go packages and a web directory, returning it and its first commit.
+func initRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":           "package main\n",
+		"internal/a/a.go":   "package a\n",
+		"internal/b/b.go":   "package b\n",
+		"web/app.js":        "app()\n",
+		"docs/it's.md":      "quote\n",
+		"internal/c/c.go":   "package c\n",
+		"internal/c/c.json": "{}\n",
+	} {
+		writeFile(t, dir, name, content)
+	}
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-q", "-m", "initial")
+	return dir, gitRun(t, dir, "rev-parse", "HEAD")
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := gitcmd.Run(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return out
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChanged(t *testing.T) {
+	dir, base := initRepo(t)
+	writeFile(t, dir, "internal/a/a.go", "package a\n\nvar X = 1\n")
+	gitRun(t, dir, "commit", "-q", "-am", "change a")
+	writeFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, dir, "internal/new/new.go", "package new\n")
+	gitRun(t, dir, "rm", "-q", "-r", "internal/b")
+
+	changed, err := Changed(context.Background(), dir, base)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"internal/a/a.go", "internal/b/b.go", "internal/new/new.go", "main.go"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("got %v, want %v", changed, want)
+	}
+}
+
+func TestRunChanged_ScopesCommands(t *testing.T) {
+	dir, _ := initRepo(t)
+	cfg := Config{Commands: []Command{
+		{Name: "tests", Command: "echo full", Scoped: "echo {dirs}", Paths: []string{"*.go"}},
+		{Name: "lint", Command: "echo lint", Paths: []string{"web/"}},
+		{Name: "docs", Command: "echo full-docs", Scoped: "cat {files}", Paths: []string{"docs/"}},
+		{Name: "build", Command: "echo build"},
+	}}
+	changed := []string{"internal/a/a.go", "internal/b/gone.go", "internal/gone/gone.go", "main.go", "docs/it's.md"}
+
+	report := RunChanged(context.Background(), cfg, dir, changed, nil)
+
+	if !report.Passed || len(report.Results) != 4 {
+		t.Fatalf("got %+v, want a passing run of 4 results", report)
+	}
+	tests, lint, docs, build := report.Results[0], report.Results[1], report.Results[2], report.Results[3]
+	if strings.TrimSpace(tests.Stdout) != "./internal/a ./internal/b ." {
+		t.Errorf("tests ran %q, output %q; want the existing changed packages", tests.Command, tests.Stdout)
+	}
+	if !lint.OutOfScope || lint.Passed {
+		t.Errorf("lint = %+v, want out of scope", lint)
+	}
+	if strings.TrimSpace(docs.Stdout) != "quote" {
+		t.Errorf("docs ran %q, output %q; want the quoted file", docs.Command, docs.Stdout)
+	}
+	if strings.TrimSpace(build.Stdout) != "build" {
+		t.Errorf("build output %q, want unscoped commands to run", build.Stdout)
+	}
+	if len(report.Failures()) != 0 || report.Feedback() != "" {
+		t.Errorf("out-of-scope commands counted as failures: %+v", report.Failures())
+	}
+}
+
+func TestRun_FullSuiteIgnoresScope(t *testing.T) {
+	dir, _ := initRepo(t)
+	cfg := Config{Commands: []Command{
+		{Command: "echo full", Scoped: "echo {dirs}", Paths: []string{"*.go"}},
+		{Command: "echo lint", Paths: []string{"web/"}},
+	}}
+
+	report := Run(context.Background(), cfg, dir, nil)
+
+	if report.Changed != nil || len(report.Results) != 2 {
+		t.Fatalf("got %+v, want an unscoped run", report)
+	}
+	for i, want := range []string{"full", "lint"} {
+		if res := report.Results[i]; res.OutOfScope || strings.TrimSpace(res.Stdout) != want {
+			t.Errorf("result %d = %+v, want the full command run", i, res)
+		}
+	}
+}
+
+func TestRunTask(t *testing.T) {
+	dir, base := initRepo(t)
+	writeFile(t, dir, "web/app.js", "app(1)\n")
+	cfg := Config{Commands: []Command{
+		{Command: "echo go", Paths: []string{"*.go"}},
+		{Command: "echo web", Paths: []string{"web/"}},
+	}}
+
+	report := RunTask(context.Background(), cfg, dir, base, nil)
+
+	if !reflect.DeepEqual(report.Changed, []string{"web/app.js"}) {
+		t.Errorf("changed = %v", report.Changed)
+	}
+	if !report.Results[0].OutOfScope || report.Results[1].OutOfScope {
+		t.Errorf("got %+v, want only the web command run", report.Results)
+	}
+}
@@ -0,0 +1,314 @@
+// Package verify is the verification gate: it runs the configured commands in a
+// task's worktree after the agent signals COMPLETE and reports what failed, so the
+// agent can fix it in its next iteration (docs/07-execution.md).
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/procenv"
+)
+
+// maxOutput is how much of each stream is kept per command; the tail is kept,
+// since that is where failures are reported.
+const maxOutput = 16 << 10
+
+// feedbackLines is how many trailing output lines of a failed command the agent is shown.
+const feedbackLines = 40
+
+// feedbackTests is how many failed tests of a command the agent is shown, and
+// testFeedbackLines how many lines of each one's message.
+const (
+	feedbackTests     = 10
+	testFeedbackLines = 20
+)
+
+// ErrFailed is returned by Check when a required command fails.
+var ErrFailed = errors.New("verification failed")
+
+// Result is one command's outcome.
+type Result struct {
+	Name     string `json:"name"`
+	Command  string `json:"command"`
+	Required bool   `json:"required"`
+
+	Passed   bool `json:"passed"`
+	ExitCode int  `json:"exitCode"`
+	TimedOut bool `json:"timedOut,omitempty"`
+	// Skipped commands were not run because an earlier required command failed.
+	Skipped bool `json:"skipped,omitempty"`
+	// OutOfScope commands were not run because the task changed none of their paths.
+	OutOfScope bool `json:"outOfScope,omitempty"`
+
+	Stdout     string `json:"stdout,omitempty"`
+	Stderr     string `json:"stderr,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+
+	// Tests are parsed from the output of commands with a Format.
+	Tests []Test `json:"tests,omitempty"`
+	// ParseError explains why a command with a Format has no tests.
+	ParseError string `json:"parseError,omitempty"`
+}
+
+// Report is the outcome of one verification run.
+type Report struct {
+	// Passed reports that every required command passed.
+	Passed bool `json:"passed"`
+	// Empty reports that no commands are configured; the gate passes without checking anything.
+	Empty bool `json:"empty,omitempty"`
+
+	// Changed lists the files a scoped run was limited to; it is nil for a full run.
+	Changed []string `json:"changed,omitempty"`
+
+	Results    []Result  `json:"results"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// Run executes all of cfg's commands in dir via sh -c with env added to AXIOM's
+// environment, e.g. AXIOM_TASK_ID. A required failure stops the run unless
+// cfg.ContinueOnFailure is set; optional failures are logged and ignored.
+func Run(ctx context.Context, cfg Config, dir string, env map[string]string) Report {
+	return run(ctx, cfg, dir, nil, env)
+}
+
+// run executes the gate, scoped to changed unless it is nil.
+func run(ctx context.Context, cfg Config, dir string, changed []string, env map[string]string) Report {
+	start := time.Now()
+	report := Report{Passed: true, Changed: changed, Results: []Result{}, StartedAt: start.UTC()}
+	if len(cfg.Commands) == 0 {
+		log.Printf("[WARN] VERIFICATION_EMPTY: no verification commands configured, passing without checks")
+		report.Empty = true
+		return report
+	}
+
+	stopped := false
+	for _, cmd := range cfg.Commands {
+		if stopped || ctx.Err() != nil {
+			report.Results = append(report.Results, Result{Name: cmd.name(), Command: cmd.Command, Required: cmd.required(), Skipped: true})
+			continue
+		}
+		line := cmd.Command
+		if changed != nil {
+			var ok bool
+			if line, ok = cmd.scoped(dir, changed); !ok {
+				report.Results = append(report.Results, Result{Name: cmd.name(), Command: cmd.Command, Required: cmd.required(), OutOfScope: true})
+				continue
+			}
+		}
+		res := runCommand(ctx, cmd, line, cfg.DefaultTimeout, dir, env)
+		report.Results = append(report.Results, res)
+		if res.Passed {
+			continue
+		}
+		if !res.Required {
+			log.Printf("[WARN] optional verification %q failed: %s", res.Name, res.Error)
+			continue
+		}
+		report.Passed = false
+		stopped = !cfg.ContinueOnFailure
+	}
+	if ctx.Err() != nil {
+		report.Passed = false
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report
+}
+
+// Check runs the full suite, as required before integration, and returns an error
+// wrapping ErrFailed with the failures when it does not pass.
+func Check(ctx context.Context, cfg Config, dir string, env map[string]string) error {
+	report := Run(ctx, cfg, dir, env)
+	if report.Passed {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var names []string
+	for _, r := range report.Failures() {
+		names = append(names, fmt.Sprintf("%s (%s)", r.Name, r.Error))
+	}
+	return fmt.Errorf("%w: %s", ErrFailed, strings.Join(names, "; "))
+}
+
+// runCommand executes line, cmd's full or scoped command line.
+func runCommand(ctx context.Context, cmd Command, line string, defaultTimeout int, dir string, env map[string]string) Result {
+	res := Result{Name: cmd.name(), Command: line, Required: cmd.required()}
+	timeout := cmd.timeout(defaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	proc := exec.CommandContext(ctx, "sh", "-c", line)
+	proc.Dir = dir
+	proc.Env = procenv.With(env)
+	// Grandchildren holding the output pipes open must not outlive the timeout.
+	proc.WaitDelay = time.Second
+	var stdout, stderr bytes.Buffer
+	proc.Stdout, proc.Stderr = &stdout, &stderr
+
+	start := time.Now()
+	err := proc.Run()
+	res.DurationMs = time.Since(start).Milliseconds()
+	res.Stdout, res.Stderr = tail(stdout.String()), tail(stderr.String())
+	res.ExitCode = proc.ProcessState.ExitCode()
+	if cmd.Format != "" {
+		var perr error
+		if res.Tests, perr = parseResults(cmd, dir, stdout.Bytes(), start); perr != nil {
+			res.ParseError = perr.Error()
+		}
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.TimedOut = true
+		res.Error = fmt.Sprintf("timed out after %s", timeout)
+	case err != nil && res.ExitCode > 0:
+		res.Error = fmt.Sprintf("exit code %d", res.ExitCode)
+	case err != nil:
+		res.Error = err.Error()
+	default:
+		res.Passed = true
+	}
+	return res
+}
+
+// parseResults parses the tests from cmd's report file, or from stdout when it has
+// none. A report left over from an earlier run is not parsed.
+func parseResults(cmd Command, dir string, stdout []byte, start time.Time) ([]Test, error) {
+	if cmd.Report == "" {
+		return Parse(cmd.Format, stdout)
+	}
+	path := filepath.Join(dir, filepath.FromSlash(cmd.Report))
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("read report: %w", err)
+	}
+	if info.ModTime().Before(start.Truncate(time.Second)) {
+		return nil, fmt.Errorf("report %s was not written by this run", cmd.Report)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read report: %w", err)
+	}
+	return Parse(cmd.Format, data)
+}
+
+// Failed returns the failed tests parsed from the command's output.
+func (r Result) Failed() []Test {
+	var failed []Test
+	for _, t := range r.Tests {
+		if t.Status == TestFailed {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
+
+// Failures returns the required commands that failed or timed out.
+func (r Report) Failures() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if res.Required && !res.Passed && !res.Skipped && !res.OutOfScope {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Feedback describes the failures for the agent's next prompt. It is empty when the gate passed.
+func (r Report) Feedback() string {
+	failed := r.Failures()
+	if r.Passed || len(failed) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Verification Failed\n\n")
+	b.WriteString("You signalled COMPLETE, but these verification commands failed. ")
+	b.WriteString("Fix the problems, run the commands yourself to confirm, then signal COMPLETE again.\n")
+	for _, res := range failed {
+		fmt.Fprintf(&b, "\n### %s\n\n`%s`: %s\n", res.Name, res.Command, res.Error)
+		if tests := res.Failed(); len(tests) > 0 {
+			writeFailedTests(&b, tests)
+			continue
+		}
+		output := strings.TrimSpace(strings.TrimSpace(res.Stdout) + "\n" + strings.TrimSpace(res.Stderr))
+		if output != "" {
+			fmt.Fprintf(&b, "\n```\n%s\n```\n", lastLines(output, feedbackLines))
+		}
+	}
+	var skipped []string
+	for _, res := range r.Results {
+		if res.Skipped {
+			skipped = append(skipped, res.Name)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "\nNot run yet: %s.\n", strings.Join(skipped, ", "))
+	}
+	return b.String()
+}
+
+// writeFailedTests lists the first feedbackTests failed tests with their messages.
+func writeFailedTests(b *strings.Builder, tests []Test) {
+	for i, t := range tests {
+		if i == feedbackTests {
+			fmt.Fprintf(b, "\n…and %d more failed test(s).\n", len(tests)-feedbackTests)
+			break
+		}
+		fmt.Fprintf(b, "\n- FAIL %s", t.title())
+		if loc := t.location(); loc != "" {
+			fmt.Fprintf(b, " (%s)", loc)
+		}
+		b.WriteString("\n")
+		if t.Message != "" {
+			fmt.Fprintf(b, "\n```\n%s\n```\n", lastLines(t.Message, testFeedbackLines))
+		}
+	}
+}
+
+// Prompt appends the report's feedback to the agent's prompt.
+func Prompt(original string, r Report) string {
+	feedback := r.Feedback()
+	if feedback == "" {
+		return original
+	}
+	return original + "\n\n" + feedback
+}
+
+// Record stores the report on the task's case under "verification".
+func Record(caseFile, taskID string, r Report) error {
+	if err := casestore.NewCaseStore().SetField(caseFile, taskID, "verification", r); err != nil {
+		return fmt.Errorf("record verification of %s: %w", taskID, err)
+	}
+	return nil
+}
+
+// tail keeps the last maxOutput bytes of s.
+func tail(s string) string {
+	if len(s) <= maxOutput {
+		return s
+	}
+	return "…" + s[len(s)-maxOutput:]
+}
+
+// lastLines returns the last n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) <= n {
+		return s
+	}
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
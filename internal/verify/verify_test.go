@@ -0,0 +1,215 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestConfig_UnmarshalForms(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{"array", `["go test ./...", "go vet ./..."]`, []string{"go test ./...", "go vet ./..."}},
+		{"object", `{"defaultTimeout": 60, "commands": [{"command": "go test ./...", "timeout": 600}, "go vet ./..."]}`, []string{"go test ./...", "go vet ./..."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var cfg Config
+
+			// Act
+			err := json.Unmarshal([]byte(tt.json), &cfg)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(cfg.Commands) != len(tt.want) {
+				t.Fatalf("got %+v, want %v", cfg.Commands, tt.want)
+			}
+			for i, want := range tt.want {
+				if cfg.Commands[i].Command != want || !cfg.Commands[i].required() {
+					t.Errorf("command %d = %+v, want required %q", i, cfg.Commands[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := (Config{Commands: []Command{{Command: " "}}}).Validate(); err == nil {
+		t.Error("expected error for an empty command")
+	}
+	if err := (Config{Commands: []Command{{Command: "true", Timeout: -1}}}).Validate(); err == nil {
+		t.Error("expected error for a negative timeout")
+	}
+	if err := (Config{Commands: []Command{{Command: "true", Format: "xunit"}}}).Validate(); err == nil {
+		t.Error("expected error for an unknown format")
+	}
+	if err := (Config{Commands: []Command{{Command: "true", Report: "junit.xml"}}}).Validate(); err == nil {
+		t.Error("expected error for a report without a format")
+	}
+	if err := (Config{Commands: []Command{{Command: "true"}}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantPassed  bool
+		wantResults []string // per command: passed, failed, skipped
+	}{
+		{
+			name:        "all pass",
+			cfg:         Config{Commands: []Command{{Command: "true"}, {Command: "echo ok"}}},
+			wantPassed:  true,
+			wantResults: []string{"passed", "passed"},
+		},
+		{
+			name:        "required failure stops",
+			cfg:         Config{Commands: []Command{{Command: "exit 2"}, {Command: "true"}}},
+			wantPassed:  false,
+			wantResults: []string{"failed", "skipped"},
+		},
+		{
+			name:        "continue on failure",
+			cfg:         Config{ContinueOnFailure: true, Commands: []Command{{Command: "exit 2"}, {Command: "true"}}},
+			wantPassed:  false,
+			wantResults: []string{"failed", "passed"},
+		},
+		{
+			name:        "optional failure is ignored",
+			cfg:         Config{Commands: []Command{{Command: "exit 1", Required: boolPtr(false)}, {Command: "true"}}},
+			wantPassed:  true,
+			wantResults: []string{"failed", "passed"},
+		},
+		{
+			name:        "empty passes",
+			cfg:         Config{},
+			wantPassed:  true,
+			wantResults: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			report := Run(context.Background(), tt.cfg, t.TempDir(), nil)
+
+			// Assert
+			if report.Passed != tt.wantPassed {
+				t.Errorf("Passed = %v, want %v", report.Passed, tt.wantPassed)
+			}
+			if len(report.Results) != len(tt.wantResults) {
+				t.Fatalf("got %d results, want %d", len(report.Results), len(tt.wantResults))
+			}
+			for i, want := range tt.wantResults {
+				res := report.Results[i]
+				got := "failed"
+				if res.Passed {
+					got = "passed"
+				} else if res.Skipped {
+					got = "skipped"
+				}
+				if got != want {
+					t.Errorf("result %d = %s (%+v), want %s", i, got, res, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_CapturesOutputAndEnv(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	cfg := Config{Commands: []Command{{Name: "tests", Command: `echo "$AXIOM_TASK_ID in $(basename "$PWD")"; echo "FAIL: TestLogin" >&2; exit 3`}}}
+
+	// Act
+	report := Run(context.Background(), cfg, dir, map[string]string{"AXIOM_TASK_ID": "task-042"})
+
+	// Assert
+	res := report.Results[0]
+	if res.ExitCode != 3 || res.Error != "exit code 3" {
+		t.Errorf("got exit %d, error %q", res.ExitCode, res.Error)
+	}
+	if want := "task-042 in " + filepath.Base(dir); strings.TrimSpace(res.Stdout) != want {
+		t.Errorf("stdout = %q, want %q", res.Stdout, want)
+	}
+	if strings.TrimSpace(res.Stderr) != "FAIL: TestLogin" {
+		t.Errorf("stderr = %q", res.Stderr)
+	}
+	feedback := report.Feedback()
+	for _, want := range []string{"## Verification Failed", "### tests", "exit code 3", "FAIL: TestLogin"} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback lacks %q:\n%s", want, feedback)
+		}
+	}
+	if got := Prompt("Implement login.", report); !strings.HasPrefix(got, "Implement login.\n\n## Verification Failed") {
+		t.Errorf("Prompt() = %q", got)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	// Arrange
+	cfg := Config{Commands: []Command{{Command: "sleep 10", Timeout: 1}}}
+
+	// Act
+	start := time.Now()
+	report := Run(context.Background(), cfg, t.TempDir(), nil)
+
+	// Assert
+	if res := report.Results[0]; !res.TimedOut || report.Passed {
+		t.Errorf("got %+v, want a failed timeout", res)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Run() took %s", elapsed)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check(context.Background(), Config{Commands: []Command{{Command: "true"}}}, t.TempDir(), nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := Check(context.Background(), Config{Commands: []Command{{Name: "lint", Command: "false"}}}, t.TempDir(), nil)
+	if !errors.Is(err, ErrFailed) || !strings.Contains(err.Error(), "lint") {
+		t.Errorf("got %v, want ErrFailed naming lint", err)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "cases.jsonl")
+	if err := os.WriteFile(path, []byte(`{"id":"task-001","status":"active"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := Run(context.Background(), Config{Commands: []Command{{Command: "true"}}}, t.TempDir(), nil)
+
+	// Act
+	err := Record(path, "task-001", report)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var stored struct {
+		Verification Report `json:"verification"`
+	}
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Verification.Passed || len(stored.Verification.Results) != 1 {
+		t.Errorf("stored %+v", stored.Verification)
+	}
+}
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+
+	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/runlog"
+	"github.com/deligoez/axiom/internal/usage"
+)
+
+// SetAccounting records every agent run's usage in ledger and in run logs under axiomDir.
+func (s *Server) SetAccounting(ledger *usage.Ledger, axiomDir string) {
+	s.ledger = ledger
+	s.axiomDir = axiomDir
+}
+
+// runRecorder meters one Execute call and writes its run log. A nil recorder does nothing.
+type runRecorder struct {
+	meter   *usage.Meter
+	log     *runlog.Log
+	ledger  *usage.Ledger
+	session string
+}
+
+// beginRun starts accounting for a run of client on taskID, logging the model and
+// why it was chosen. The returned context is cancelled with a usage.ErrBudgetExceeded
+// cause when a budget runs out.
+func (s *Server) beginRun(parent context.Context, client *agent.AgentClient, persona, taskID, modelReason string) (context.Context, context.CancelCauseFunc, *runRecorder) {
+	ctx, cancel := context.WithCancelCause(parent)
+	if s.ledger == nil {
+		return ctx, cancel, nil
+	}
+
+	config := client.Config()
+	r := &runRecorder{
+		meter:   s.ledger.Meter(persona, taskID, config.Model, cancel),
+		log:     runlog.New(s.axiomDir, persona, taskID, config.AgentID),
+		ledger:  s.ledger,
+		session: filepath.Join(s.axiomDir, "metrics", "session.json"),
+	}
+	r.append(runlog.Event{Event: runlog.EventStart, Model: config.Model, Reason: modelReason})
+	return ctx, cancel, r
+}
+
+// observe accounts for one agent message.
+func (r *runRecorder) observe(msg agent.AgentMessage) {
+	if r == nil {
+		return
+	}
+	r.meter.Observe(msg.Raw)
+	for _, sig := range msg.Signals {
+		r.append(runlog.Event{Event: runlog.EventSignal, Type: sig.Type, Payload: sig.Payload})
+	}
+}
+
+// finish records the run's usage and outcome. It returns the budget error when
+// the run was cancelled for going over budget, otherwise err.
+func (r *runRecorder) finish(err error) error {
+	if r == nil {
+		return err
+	}
+	if budgetErr := r.meter.Err(); budgetErr != nil {
+		err = budgetErr
+	}
+
+	u := r.meter.Finish()
+	r.append(runlog.Event{Event: runlog.EventUsage, Usage: &u})
+	if err != nil {
+		r.append(runlog.Event{Event: runlog.EventError, Error: err.Error(), DurationMs: u.DurationMs})
+	} else {
+		r.append(runlog.Event{Event: runlog.EventComplete, DurationMs: u.DurationMs, Iterations: u.Turns})
+	}
+
+	if saveErr := r.ledger.Save(r.session); saveErr != nil {
+		log.Printf("[WARN] %v", saveErr)
+	}
+	return err
+}
+
+// append writes a run log event, logging failures rather than interrupting the agent.
+func (r *runRecorder) append(e runlog.Event) {
+	if err := r.log.Append(e); err != nil {
+		log.Printf("[WARN] %v", err)
+	}
+}
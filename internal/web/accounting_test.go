@@ -0,0 +1,63 @@
+package web
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dotcommander/agent-sdk-go/claude"
+
+	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/runlog"
+	"github.com/deligoez/axiom/internal/usage"
+)
+
+func TestServer_RunAccounting(t *testing.T) {
+	// Arrange
+	axiomDir := t.TempDir()
+	ledger := usage.NewLedger(usage.DefaultPrices(), usage.Budgets{})
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetAccounting(ledger, axiomDir)
+	client, err := agent.NewAgentClient(&agent.AgentConfig{AgentID: "ava-001", Model: "sonnet"})
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	cost := 0.5
+
+	// Act
+	_, cancel, run := server.beginRun(context.Background(), client, "ava", "init", "default")
+	defer cancel(nil)
+	run.observe(agent.AgentMessage{Raw: &claude.ResultMessage{NumTurns: 2, TotalCostUSD: &cost}})
+	err = run.finish(nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	events, err := runlog.Read(runlog.Path(axiomDir, "ava", "init"))
+	if err != nil {
+		t.Fatalf("read run log: %v", err)
+	}
+	var kinds []string
+	for _, e := range events {
+		kinds = append(kinds, e.Event)
+	}
+	if len(kinds) != 3 || kinds[0] != runlog.EventStart || kinds[1] != runlog.EventUsage || kinds[2] != runlog.EventComplete {
+		t.Errorf("unexpected events: %v", kinds)
+	}
+	if got := ledger.Task("init").Runs; got != 1 {
+		t.Errorf("got %d runs recorded, want 1", got)
+	}
+	if _, err := os.Stat(filepath.Join(axiomDir, "metrics", "session.json")); err != nil {
+		t.Errorf("session rollup not saved: %v", err)
+	}
+}
+
+func TestRunRecorder_NilIsNoop(t *testing.T) {
+	var run *runRecorder
+	run.observe(agent.AgentMessage{})
+	if err := run.finish(context.Canceled); err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,134 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/models"
+	"github.com/deligoez/axiom/internal/registry"
+)
+
+// SetRegistry shares the live agent registry with the server.
+// Without one, agents still get IDs but are not listed by /api/agents.
+func (s *Server) SetRegistry(r *registry.Registry) {
+	s.registry = r
+}
+
+// SetModels sets the model selection policy and the config's default model.
+func (s *Server) SetModels(policy models.Policy, defaultModel string) {
+	s.models = policy
+	s.defaultModel = defaultModel
+}
+
+// selectModel chooses the model for a new agent run.
+func (s *Server) selectModel(req models.Request) models.Choice {
+	return s.models.Select(s.defaultModel, req)
+}
+
+// SetTimeouts applies per-query limits to new agents and starts the session deadline.
+func (s *Server) SetTimeouts(t agent.Timeouts) {
+	s.timeouts = t
+	s.sessionCtx, s.sessionCancel = t.SessionContext(context.Background())
+}
+
+// session returns the context all agent runs derive from.
+func (s *Server) session() context.Context {
+	if s.sessionCtx == nil {
+		return context.Background()
+	}
+	return s.sessionCtx
+}
+
+// spawnAgent assigns an agent ID for persona working in workDir, registering it when a registry is set.
+// It refuses with workspace.ErrLowDisk when free disk space is below the configured threshold
+// and with escalation.ErrPaused while an unanswered escalation keeps the session paused.
+func (s *Server) spawnAgent(persona, taskID, workDir string, stop registry.StopFunc) (string, error) {
+	if err := s.checkPaused(); err != nil {
+		return "", err
+	}
+	if err := s.checkDisk(); err != nil {
+		return "", err
+	}
+	if s.registry == nil {
+		return agent.FormatAgentID(persona, 1), nil
+	}
+	info, err := s.registry.Spawn(persona, taskID, workDir, stop)
+	if err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// trackAgent mirrors an agent's state machine into the registry when one is set.
+func (s *Server) trackAgent(id string, m *agent.Machine) {
+	if s.registry == nil {
+		return
+	}
+	_ = s.registry.Track(id, m)
+}
+
+// recordPID stores the agent's CLI process ID in the registry once it is known.
+func (s *Server) recordPID(client *agent.AgentClient) {
+	if s.registry == nil {
+		return
+	}
+	id := client.Config().AgentID
+	if info, ok := s.registry.Get(id); !ok || info.PID != 0 {
+		return
+	}
+	if pid := client.PID(); pid != 0 {
+		_ = s.registry.SetPID(id, pid)
+	}
+}
+
+// handleAgents handles GET /api/agents, listing live agents as JSON.
+func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	agents := []registry.Info{}
+	if s.registry != nil {
+		agents = s.registry.List()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(agents)
+}
+
+// handleAgentStop handles POST /api/agents/stop for the agent named by the "id" form value.
+func (s *Server) handleAgentStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "Agent ID required", http.StatusBadRequest)
+		return
+	}
+	if s.registry == nil {
+		http.Error(w, "Agent not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.registry.Stop(id); err != nil {
+		if errors.Is(err, registry.ErrNotFound) {
+			http.Error(w, "Agent not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"status":"ok"}`))
+}
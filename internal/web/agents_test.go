@@ -0,0 +1,130 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/persona"
+	"github.com/deligoez/axiom/internal/registry"
+	"github.com/deligoez/axiom/internal/scaffold"
+)
+
+func TestServer_Agents_ListsRegistry(t *testing.T) {
+	// Arrange
+	reg := registry.New(registry.NewCounters(filepath.Join(t.TempDir(), "counters.json")))
+	if _, err := reg.Spawn("echo", "task-001", "/ws/task-001", nil); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetRegistry(reg)
+	req := httptest.NewRequest(http.MethodGet, "/api/agents", http.NoBody)
+	rec := httptest.NewRecorder()
+
+	// Act
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	var agents []registry.Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &agents); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(agents) != 1 || agents[0].ID != "echo-001" || agents[0].TaskID != "task-001" {
+		t.Errorf("unexpected agents: %+v", agents)
+	}
+}
+
+func TestServer_AgentStop(t *testing.T) {
+	// Arrange
+	reg := registry.New(registry.NewCounters(filepath.Join(t.TempDir(), "counters.json")))
+	stopped := false
+	info, err := reg.Spawn("echo", "task-001", "", func() error {
+		stopped = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetRegistry(reg)
+
+	// Act
+	req := httptest.NewRequest(http.MethodPost, "/api/agents/stop", strings.NewReader("id="+info.ID))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusOK || !stopped {
+		t.Errorf("got status %d (stopped=%v), want 200 and stopped", rec.Code, stopped)
+	}
+
+	// Stopping again reports not found
+	req = httptest.NewRequest(http.MethodPost, "/api/agents/stop", strings.NewReader("id="+info.ID))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestServer_SSEInit_RemovesAgentWhenItFailsToStart(t *testing.T) {
+	// Arrange
+	axiomDir := t.TempDir()
+	// A directory where Ava's rules file belongs makes loading the persona fail.
+	if err := os.MkdirAll(filepath.Join(axiomDir, "agents", "ava", "rules.md"), 0o755); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	reg := registry.New(registry.NewCounters(filepath.Join(t.TempDir(), "counters.json")))
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetRegistry(reg)
+	server.SetPersonas(persona.NewLoader(axiomDir), t.TempDir())
+	server.EnableInitMode("", scaffold.ConfigNew)
+	req := httptest.NewRequest(http.MethodGet, "/sse/init", http.NoBody)
+	rec := httptest.NewRecorder()
+
+	// Act
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	if !strings.Contains(rec.Body.String(), "event: error") {
+		t.Errorf("expected an error event, got %q", rec.Body.String())
+	}
+	if agents := reg.List(); len(agents) != 0 {
+		t.Errorf("expected no registered agents, got %+v", agents)
+	}
+}
+
+func TestServer_SSEInit_RemovesAvaWhenHerRunEnds(t *testing.T) {
+	// Arrange
+	// Without the CLI on PATH, Ava's run ends as soon as it starts.
+	t.Setenv("PATH", t.TempDir())
+	reg := registry.New(registry.NewCounters(filepath.Join(t.TempDir(), "counters.json")))
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetRegistry(reg)
+	server.SetPersonas(persona.NewLoader(t.TempDir()), t.TempDir())
+	server.EnableInitMode("", scaffold.ConfigNew)
+	req := httptest.NewRequest(http.MethodGet, "/sse/init", http.NoBody)
+	rec := httptest.NewRecorder()
+
+	// Act
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	deadline := time.Now().Add(5 * time.Second)
+	for len(reg.List()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected Ava removed after her run, got %+v", reg.List())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,180 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/deligoez/axiom/internal/autopilot"
+	"github.com/deligoez/axiom/internal/escalation"
+)
+
+// SetAutopilot enables the autopilot controls. Runs started from the UI derive
+// from the session context, so the session deadline stops them.
+func (s *Server) SetAutopilot(ap *autopilot.Autopilot) {
+	s.autopilot = ap
+}
+
+// autopilotStatus returns the autopilot's status, or an idle one without an autopilot.
+func (s *Server) autopilotStatus() autopilot.Status {
+	if s.autopilot == nil {
+		return autopilot.Status{State: autopilot.StateIdle, Slots: []autopilot.Slot{}, Approvals: []autopilot.Approval{}}
+	}
+	return s.autopilot.Status()
+}
+
+// handleAutopilot handles GET /api/autopilot, reporting the loop's state, mode,
+// slots, approvals and stats as JSON.
+func (s *Server) handleAutopilot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.autopilotStatus())
+}
+
+// handleAutopilotPanel handles GET /autopilot, rendering the autopilot panel for htmx polling.
+func (s *Server) handleAutopilotPanel(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := s.templates.ExecuteTemplate(&buf, "autopilot-panel", s.autopilotStatus()); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// StartAutopilot runs the loop in the background under the session context, so
+// agents.sessionTimeout stops it. It returns autopilot.ErrRunning while a run is
+// in progress.
+func (s *Server) StartAutopilot() error {
+	if state := s.autopilot.Status().State; state == autopilot.StateRunning || state == autopilot.StatePaused {
+		return autopilot.ErrRunning
+	}
+	ctx := s.session()
+	go func() {
+		stats, err := s.autopilot.Run(ctx)
+		if err != nil {
+			log.Printf("[autopilot] stopped: %v", err)
+			return
+		}
+		log.Printf("[autopilot] finished: %d done, %d failed, %d blocked in %d iterations ($%.2f)",
+			stats.Completed, stats.Failed, stats.Blocked, stats.Iterations, stats.CostUSD)
+	}()
+	return nil
+}
+
+// handleAutopilotStart handles POST /api/autopilot/start, running the loop in the
+// background until no ready task remains.
+func (s *Server) handleAutopilotStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.autopilot == nil {
+		http.Error(w, "Autopilot not configured", http.StatusNotFound)
+		return
+	}
+	if err := s.StartAutopilot(); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write([]byte(`{"status":"started"}`))
+}
+
+// handleAutopilotPause handles POST /api/autopilot/pause with an optional "reason"
+// form value. Running agents stop at the end of their current iteration.
+func (s *Server) handleAutopilotPause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	reason := r.FormValue("reason")
+	if reason == "" {
+		reason = "paused by user"
+	}
+	s.autopilotControl(w, func(ap *autopilot.Autopilot) error { return ap.Pause(reason) })
+}
+
+// handleAutopilotResume handles POST /api/autopilot/resume, restarting the limits.
+func (s *Server) handleAutopilotResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.autopilotControl(w, (*autopilot.Autopilot).Resume)
+}
+
+// handleAutopilotMode handles POST /api/autopilot/mode with a "mode" form value,
+// semi-auto or autopilot. The switch applies to a running loop at once.
+func (s *Server) handleAutopilotMode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	mode := autopilot.Mode(r.FormValue("mode"))
+	s.autopilotControl(w, func(ap *autopilot.Autopilot) error { return ap.SetMode(mode) })
+}
+
+// handleAutopilotApprove handles POST /api/autopilot/approve, deciding a semi-auto
+// checkpoint with "id", "decision" and an optional "message" form value.
+func (s *Server) handleAutopilotApprove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+	decision := escalation.Decision(r.FormValue("decision"))
+	s.autopilotControl(w, func(ap *autopilot.Autopilot) error { return ap.Decide(id, decision, r.FormValue("message")) })
+}
+
+// autopilotControl applies fn and writes the resulting status.
+func (s *Server) autopilotControl(w http.ResponseWriter, fn func(*autopilot.Autopilot) error) {
+	if s.autopilot == nil {
+		http.Error(w, "Autopilot not configured", http.StatusNotFound)
+		return
+	}
+	if err := fn(s.autopilot); err != nil {
+		switch {
+		case errors.Is(err, autopilot.ErrNotRunning):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, autopilot.ErrApprovalNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, autopilot.ErrInvalidMode):
+			http.Error(w, "mode (semi-auto or autopilot) required", http.StatusBadRequest)
+			return
+		case errors.Is(err, escalation.ErrInvalidDecision):
+			http.Error(w, "decision (approve, reject, or respond with a message) required", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.autopilot.Status())
+}
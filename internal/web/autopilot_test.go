@@ -0,0 +1,210 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/autopilot"
+)
+
+// newAutopilot returns an autopilot for a fresh repository without cases.
+func newAutopilot(t *testing.T) *autopilot.Autopilot {
+	t.Helper()
+	ap, err := autopilot.New(autopilot.Config{}, autopilot.Options{
+		CaseFile:   filepath.Join(t.TempDir(), "cases.jsonl"),
+		Workspaces: newWorkspaceManager(t),
+	})
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	return ap
+}
+
+func TestServer_Autopilot_ReportsIdleWithoutAutopilot(t *testing.T) {
+	// Arrange
+	server := NewServer("/nonexistent/cases.jsonl")
+	req := httptest.NewRequest(http.MethodGet, "/api/autopilot", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var st autopilot.Status
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if st.State != autopilot.StateIdle {
+		t.Errorf("got state %s, want idle", st.State)
+	}
+	if rec := postForm(server, "/api/autopilot/start", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without an autopilot, got %d", rec.Code)
+	}
+}
+
+func TestServer_AutopilotStart_RunsUntilNothingIsReady(t *testing.T) {
+	// Arrange
+	server := NewServer("/nonexistent/cases.jsonl")
+	ap := newAutopilot(t)
+	server.SetAutopilot(ap)
+
+	// Act
+	rec := postForm(server, "/api/autopilot/start", nil)
+
+	// Assert
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for ap.Status().State != autopilot.StateCompleted {
+		if time.Now().After(deadline) {
+			t.Fatalf("autopilot did not complete, state %s", ap.Status().State)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that the log package and a test may share.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestServer_StartAutopilot_StopsAtSessionTimeout(t *testing.T) {
+	// Arrange
+	var logs syncBuffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetTimeouts(agent.Timeouts{Session: time.Nanosecond})
+	server.SetAutopilot(newAutopilot(t))
+
+	// Act
+	err := server.StartAutopilot()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs.String(), "[autopilot] stopped") {
+		if strings.Contains(logs.String(), "[autopilot] finished") {
+			t.Fatalf("autopilot ignored the session deadline: %s", logs.String())
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("autopilot did not stop: %s", logs.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestServer_AutopilotPause_ConflictWhenNotRunning(t *testing.T) {
+	// Arrange
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetAutopilot(newAutopilot(t))
+
+	// Act
+	pause := postForm(server, "/api/autopilot/pause", nil)
+	resume := postForm(server, "/api/autopilot/resume", nil)
+
+	// Assert
+	if pause.Code != http.StatusConflict || resume.Code != http.StatusConflict {
+		t.Errorf("expected 409 for pause and resume, got %d and %d", pause.Code, resume.Code)
+	}
+}
+
+func TestServer_AutopilotPanel_RendersStatus(t *testing.T) {
+	// Arrange
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetAutopilot(newAutopilot(t))
+	req := httptest.NewRequest(http.MethodGet, "/autopilot", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"idle", "semi-auto", "0/3 slots", "/api/autopilot/start", "Switch to autopilot"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("panel missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestServer_AutopilotMode_SwitchesAtRuntime(t *testing.T) {
+	// Arrange
+	server := NewServer("/nonexistent/cases.jsonl")
+	ap := newAutopilot(t)
+	server.SetAutopilot(ap)
+
+	// Act
+	rec := postForm(server, "/api/autopilot/mode", url.Values{"mode": {"autopilot"}})
+	invalid := postForm(server, "/api/autopilot/mode", url.Values{"mode": {"manual"}})
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var st autopilot.Status
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if st.Mode != autopilot.ModeAutopilot || ap.Mode() != autopilot.ModeAutopilot {
+		t.Errorf("got mode %s, want autopilot", st.Mode)
+	}
+	if invalid.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown mode, got %d", invalid.Code)
+	}
+}
+
+func TestServer_AutopilotApprove_ValidatesRequest(t *testing.T) {
+	// Arrange
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetAutopilot(newAutopilot(t))
+
+	// Act
+	missing := postForm(server, "/api/autopilot/approve", url.Values{"decision": {"approve"}})
+	unknown := postForm(server, "/api/autopilot/approve", url.Values{"id": {"apr-001"}, "decision": {"approve"}})
+	invalid := postForm(server, "/api/autopilot/approve", url.Values{"id": {"apr-001"}, "decision": {"maybe"}})
+
+	// Assert
+	if missing.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without an id, got %d", missing.Code)
+	}
+	if unknown.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown approval, got %d", unknown.Code)
+	}
+	if invalid.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown decision, got %d", invalid.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/deligoez/axiom/internal/escalation"
+)
+
+// SetEscalations enables the escalation inbox and refuses new agents while an
+// unanswered escalation keeps the session paused.
+func (s *Server) SetEscalations(in *escalation.Inbox) {
+	s.escalations = in
+}
+
+// checkPaused returns escalation.ErrPaused while the session is paused.
+func (s *Server) checkPaused() error {
+	if s.escalations != nil && s.escalations.Paused() {
+		return escalation.ErrPaused
+	}
+	return nil
+}
+
+// escalationPanel is the view model of the escalation-list template.
+type escalationPanel struct {
+	Paused      bool
+	Escalations []escalation.Escalation
+}
+
+// handleEscalations handles GET /api/escalations, listing open escalations as JSON.
+func (s *Server) handleEscalations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	open := []escalation.Escalation{}
+	if s.escalations != nil {
+		open = s.escalations.Pending()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(open)
+}
+
+// handleEscalationsPanel handles GET /escalations, rendering the inbox for htmx polling.
+func (s *Server) handleEscalationsPanel(w http.ResponseWriter, r *http.Request) {
+	var panel escalationPanel
+	if s.escalations != nil {
+		panel = escalationPanel{Paused: s.escalations.Paused(), Escalations: s.escalations.Pending()}
+	}
+
+	var buf bytes.Buffer
+	if err := s.templates.ExecuteTemplate(&buf, "escalation-list", panel); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// handleEscalationsRespond handles POST /api/escalations/respond with form values
+// "id", "decision" ("approve", "reject" or "respond") and "message", required to respond.
+func (s *Server) handleEscalationsRespond(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "id required", http.StatusBadRequest)
+		return
+	}
+	if s.escalations == nil {
+		http.Error(w, "Escalation not found", http.StatusNotFound)
+		return
+	}
+
+	e, err := s.escalations.Answer(id, escalation.Decision(r.FormValue("decision")), r.FormValue("message"))
+	switch {
+	case errors.Is(err, escalation.ErrNotFound):
+		http.Error(w, "Escalation not found", http.StatusNotFound)
+		return
+	case errors.Is(err, escalation.ErrClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, escalation.ErrInvalidDecision):
+		http.Error(w, "decision (approve, reject, or respond with a message) required", http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(e)
+}
+
+// handleEscalationsResume handles POST /api/escalations/resume, lifting a pause
+// applied by an unanswered escalation.
+func (s *Server) handleEscalationsResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.escalations == nil {
+		http.Error(w, "Escalations not configured", http.StatusNotFound)
+		return
+	}
+	if err := s.escalations.Resume(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"status":"ok"}`))
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/escalation"
+)
+
+// newInbox returns an escalation inbox in a temporary project with one open escalation.
+func newInbox(t *testing.T, cfg escalation.Config) (*escalation.Inbox, escalation.Escalation) {
+	t.Helper()
+	project := t.TempDir()
+	axiomDir := filepath.Join(project, ".axiom")
+	in, err := escalation.Open(filepath.Join(axiomDir, "escalations.json"), project, axiomDir, cfg)
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	e, err := in.Raise(context.Background(), escalation.Escalation{
+		TaskID:  "task-042",
+		Kind:    escalation.KindConflict,
+		Reason:  "resolver gave up",
+		Context: "auth.go: semantic (medium)",
+		Options: []string{"Approve after resolving"},
+	})
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	return in, e
+}
+
+// postForm sends form values to the server.
+func postForm(server *Server, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_Escalations_ListsOpen(t *testing.T) {
+	// Arrange
+	in, e := newInbox(t, escalation.Config{})
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetEscalations(in)
+	req := httptest.NewRequest(http.MethodGet, "/api/escalations", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var open []escalation.Escalation
+	if err := json.NewDecoder(rec.Body).Decode(&open); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(open) != 1 || open[0].ID != e.ID {
+		t.Errorf("got %+v, want %s", open, e.ID)
+	}
+}
+
+func TestServer_EscalationsPanel_RendersInbox(t *testing.T) {
+	// Arrange
+	in, e := newInbox(t, escalation.Config{})
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetEscalations(in)
+	req := httptest.NewRequest(http.MethodGet, "/escalations", nil)
+	rec := httptest.NewRecorder()
+
+	// Act
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	body := rec.Body.String()
+	for _, want := range []string{e.ID, "task-042", "resolver gave up", "auth.go: semantic (medium)", `value="approve"`, `value="reject"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("panel lacks %q", want)
+		}
+	}
+}
+
+func TestServer_EscalationsRespond(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     url.Values
+		wantCode int
+	}{
+		{"approve", url.Values{"decision": {"approve"}}, http.StatusOK},
+		{"respond", url.Values{"decision": {"respond"}, "message": {"keep both checks"}}, http.StatusOK},
+		{"respond without message", url.Values{"decision": {"respond"}}, http.StatusBadRequest},
+		{"unknown decision", url.Values{"decision": {"maybe"}}, http.StatusBadRequest},
+		{"unknown id", url.Values{"id": {"esc-404"}, "decision": {"approve"}}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			in, e := newInbox(t, escalation.Config{})
+			server := NewServer("/nonexistent/cases.jsonl")
+			server.SetEscalations(in)
+			if tt.form.Get("id") == "" {
+				tt.form.Set("id", e.ID)
+			}
+
+			// Act
+			rec := postForm(server, "/api/escalations/respond", tt.form)
+
+			// Assert
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body)
+			}
+			wantOpen := 1
+			if tt.wantCode == http.StatusOK {
+				wantOpen = 0
+			}
+			if n := len(in.Pending()); n != wantOpen {
+				t.Errorf("got %d open escalations, want %d", n, wantOpen)
+			}
+		})
+	}
+}
+
+func TestServer_SpawnRefusedWhilePaused(t *testing.T) {
+	// Arrange
+	in, _ := newInbox(t, escalation.Config{Action: escalation.ActionPause, Timeout: 1})
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetEscalations(in)
+	waitExpired(t, in)
+
+	// Act
+	_, err := server.spawnAgent("echo", "task-044", "", nil)
+
+	// Assert
+	if !errors.Is(err, escalation.ErrPaused) {
+		t.Fatalf("got %v, want ErrPaused", err)
+	}
+	if rec := postForm(server, "/api/escalations/resume", nil); rec.Code != http.StatusOK {
+		t.Fatalf("resume: expected status 200, got %d", rec.Code)
+	}
+	if _, err := server.spawnAgent("echo", "task-044", "", nil); err != nil {
+		t.Errorf("spawn after resume: %v", err)
+	}
+}
+
+// waitExpired waits for the inbox's open escalations to pass their deadline and expires them.
+func waitExpired(t *testing.T, in *escalation.Inbox) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		expired, err := in.Expire()
+		if err != nil {
+			t.Fatalf("expire: %v", err)
+		}
+		if len(expired) > 0 {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("escalation did not expire")
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/deligoez/axiom/internal/permission"
+)
+
+// SetBroker routes agents' tool-use requests through broker instead of skipping permissions.
+func (s *Server) SetBroker(b *permission.Broker) {
+	s.broker = b
+}
+
+// pendingEscalations returns the broker's pending escalations, or none without a broker.
+func (s *Server) pendingEscalations() []permission.Escalation {
+	if s.broker == nil {
+		return []permission.Escalation{}
+	}
+	return s.broker.Pending()
+}
+
+// handlePermissions handles GET /api/permissions, listing pending escalations as JSON.
+func (s *Server) handlePermissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.pendingEscalations())
+}
+
+// handlePermissionsPanel handles GET /permissions, rendering pending escalations for htmx polling.
+func (s *Server) handlePermissionsPanel(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	err := s.templates.ExecuteTemplate(&buf, "permission-list", s.pendingEscalations())
+	if err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// handlePermissionsRespond handles POST /api/permissions/respond with form values
+// "id", "decision" ("allow" or "deny") and an optional "message" for the agent.
+func (s *Server) handlePermissionsRespond(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	id := r.FormValue("id")
+	decision := permission.Decision(r.FormValue("decision"))
+	if id == "" || (decision != permission.Allow && decision != permission.Deny) {
+		http.Error(w, "id and decision (allow or deny) required", http.StatusBadRequest)
+		return
+	}
+	if s.broker == nil {
+		http.Error(w, "Escalation not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.broker.Resolve(id, decision == permission.Allow, r.FormValue("message")); err != nil {
+		if errors.Is(err, permission.ErrNotFound) {
+			http.Error(w, "Escalation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"status":"ok"}`))
+}
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deligoez/axiom/internal/permission"
+)
+
+func TestServer_Permissions_ListAndRespond(t *testing.T) {
+	// Arrange - an escalated request waiting on the broker
+	broker, err := permission.NewBroker(permission.DefaultPolicy())
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetBroker(broker)
+
+	decided := make(chan bool)
+	go func() {
+		allow, _ := broker.Decide(context.Background(), permission.Request{
+			AgentID: "ava-001",
+			Tool:    "Bash",
+			Input:   map[string]any{"command": "docker ps"},
+			WorkDir: "/repo",
+		})
+		decided <- allow
+	}()
+
+	var pending []permission.Escalation
+	deadline := time.Now().Add(time.Second)
+	for len(pending) == 0 && time.Now().Before(deadline) {
+		req := httptest.NewRequest(http.MethodGet, "/api/permissions", http.NoBody)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		if err := json.Unmarshal(rec.Body.Bytes(), &pending); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 pending escalation, got %d", len(pending))
+	}
+
+	// The panel shows the request
+	req := httptest.NewRequest(http.MethodGet, "/permissions", http.NoBody)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "ava-001") || !strings.Contains(rec.Body.String(), pending[0].ID) {
+		t.Errorf("panel should show the escalation, got:\n%s", rec.Body.String())
+	}
+
+	// Act - deny it
+	req = httptest.NewRequest(http.MethodPost, "/api/permissions/respond",
+		strings.NewReader("id="+pending[0].ID+"&decision=deny"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if <-decided {
+		t.Error("expected request denied")
+	}
+}
+
+func TestServer_PermissionsRespond_Validation(t *testing.T) {
+	broker, _ := permission.NewBroker(permission.DefaultPolicy())
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetBroker(broker)
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{"id=perm-001&decision=maybe", http.StatusBadRequest},
+		{"decision=allow", http.StatusBadRequest},
+		{"id=perm-404&decision=allow", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/permissions/respond", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: got status %d, want %d", tt.body, rec.Code, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"embed"
+	"errors"
 	"html/template"
 	"log"
 	"net/http"
@@ -14,8 +15,16 @@ import (
 	"time"
 
 	"github.com/deligoez/axiom/internal/agent"
+	"github.com/deligoez/axiom/internal/autopilot"
 	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/escalation"
+	"github.com/deligoez/axiom/internal/models"
+	"github.com/deligoez/axiom/internal/permission"
+	"github.com/deligoez/axiom/internal/persona"
+	"github.com/deligoez/axiom/internal/registry"
 	"github.com/deligoez/axiom/internal/scaffold"
+	"github.com/deligoez/axiom/internal/usage"
+	"github.com/deligoez/axiom/internal/workspace"
 )
 
 //go:embed templates/*.html
@@ -28,6 +37,39 @@ type Server struct {
 	caseStore *casestore.CaseStore
 	caseFile  string
 
+	// Live agents, shared with the orchestrator
+	registry *registry.Registry
+
+	// Persona prompts and the project root agents are confined to
+	personas   *persona.Loader
+	projectDir string
+
+	// Tool-use permission broker
+	broker *permission.Broker
+
+	// Usage accounting and run logs
+	ledger   *usage.Ledger
+	axiomDir string
+
+	// Decisions waiting for a human
+	escalations *escalation.Inbox
+
+	// Execution loop
+	autopilot *autopilot.Autopilot
+
+	// Task worktrees, retention and the disk guard
+	workspaces   *workspace.Manager
+	workspaceCfg workspace.Config
+
+	// Model selection
+	models       models.Policy
+	defaultModel string
+
+	// Agent deadlines; every agent context derives from sessionCtx
+	timeouts      agent.Timeouts
+	sessionCtx    context.Context
+	sessionCancel context.CancelFunc
+
 	// Init mode state
 	initMode    bool
 	promptPath  string
@@ -36,6 +78,8 @@ type Server struct {
 
 	// Interactive agent (SDK-based)
 	initAgent    *agent.AgentClient
+	initAgentID  string
+	initModel    models.Choice
 	initCtx      context.Context
 	initCancel   context.CancelFunc
 	initErr      error
@@ -47,7 +91,7 @@ type Server struct {
 
 // initEntry represents a buffered output entry.
 type initEntry struct {
-	Type    string // "assistant" or "user"
+	Type    string // "assistant", "tool" or "user"
 	Content string
 }
 
@@ -79,6 +123,25 @@ func (s *Server) routes() {
 	s.mux.HandleFunc("/init", s.handleInit)
 	s.mux.HandleFunc("/sse/init", s.handleSSEInit)
 	s.mux.HandleFunc("/api/init/respond", s.handleInitRespond)
+	s.mux.HandleFunc("/api/agents", s.handleAgents)
+	s.mux.HandleFunc("/api/agents/stop", s.handleAgentStop)
+	s.mux.HandleFunc("/permissions", s.handlePermissionsPanel)
+	s.mux.HandleFunc("/api/permissions", s.handlePermissions)
+	s.mux.HandleFunc("/api/permissions/respond", s.handlePermissionsRespond)
+	s.mux.HandleFunc("/escalations", s.handleEscalationsPanel)
+	s.mux.HandleFunc("/api/escalations", s.handleEscalations)
+	s.mux.HandleFunc("/api/escalations/respond", s.handleEscalationsRespond)
+	s.mux.HandleFunc("/api/escalations/resume", s.handleEscalationsResume)
+	s.mux.HandleFunc("/autopilot", s.handleAutopilotPanel)
+	s.mux.HandleFunc("/api/autopilot", s.handleAutopilot)
+	s.mux.HandleFunc("/api/autopilot/start", s.handleAutopilotStart)
+	s.mux.HandleFunc("/api/autopilot/pause", s.handleAutopilotPause)
+	s.mux.HandleFunc("/api/autopilot/resume", s.handleAutopilotResume)
+	s.mux.HandleFunc("/api/autopilot/mode", s.handleAutopilotMode)
+	s.mux.HandleFunc("/api/autopilot/approve", s.handleAutopilotApprove)
+	s.mux.HandleFunc("/workspaces", s.handleWorkspacesPanel)
+	s.mux.HandleFunc("/api/workspaces", s.handleWorkspaces)
+	s.mux.HandleFunc("/api/workspaces/cleanup", s.handleWorkspacesCleanup)
 }
 
 // EnableInitMode enables Init Mode for first-time project setup.
@@ -89,6 +152,25 @@ func (s *Server) EnableInitMode(promptPath string, configState scaffold.ConfigSt
 	s.configState = configState
 }
 
+// SetPersonas makes agents run as their persona: with its system prompt and tool
+// policy, working inside projectDir.
+func (s *Server) SetPersonas(loader *persona.Loader, projectDir string) {
+	s.personas = loader
+	s.projectDir = projectDir
+}
+
+// configurePersona applies the named persona to config when a loader is set.
+func (s *Server) configurePersona(config *agent.AgentConfig, name string) error {
+	if s.personas == nil {
+		return nil
+	}
+	if config.WorkDir == "" {
+		config.WorkDir = s.projectDir
+	}
+	config.ProjectDir = s.projectDir
+	return s.personas.Configure(config, name, persona.PromptData{})
+}
+
 // StaticDir sets the directory for serving static files.
 func (s *Server) StaticDir(dir string) {
 	fs := http.FileServer(http.Dir(dir))
@@ -138,17 +220,37 @@ func (s *Server) handleSSEInit(w http.ResponseWriter, r *http.Request) {
 	s.initMu.Lock()
 	if s.initAgent == nil && s.initErr == nil {
 		initialMessage := s.buildInitialMessage()
-		config := &agent.AgentConfig{
-			Model:           "claude-sonnet-4-20250514",
-			Verbose:         true,
-			SkipPermissions: true,
+		agentID, err := s.spawnAgent(persona.Ava, "", s.projectDir, s.stopInitAgent)
+		var agentInstance *agent.AgentClient
+		choice := s.selectModel(models.Request{Persona: persona.Ava})
+		if err == nil {
+			config := &agent.AgentConfig{
+				Model:   choice.Model,
+				AgentID: agentID,
+				Verbose: true,
+			}
+			if s.timeouts.Query > 0 {
+				config.Timeout = s.timeouts.Query.String()
+			}
+			err = s.configurePersona(config, persona.Ava)
+			if err == nil && s.broker != nil {
+				config.PermissionHandler = s.broker.Handler(agentID, config.WorkDir)
+			}
+			if err == nil {
+				agentInstance, err = agent.NewAgentClient(config)
+			}
+			if err != nil && s.registry != nil {
+				s.registry.Remove(agentID)
+			}
 		}
-		agentInstance, err := agent.NewAgentClient(config)
 		if err != nil {
 			s.initErr = err
 		} else {
 			s.initAgent = agentInstance
-			s.initCtx, s.initCancel = context.WithCancel(context.Background())
+			s.initAgentID = agentID
+			s.initModel = choice
+			s.initCtx, s.initCancel = context.WithCancel(s.session())
+			s.trackAgent(agentID, agentInstance.Machine())
 			// Start buffering the initial response
 			go s.bufferAgentOutput(initialMessage)
 		}
@@ -174,14 +276,20 @@ func (s *Server) handleSSEInit(w http.ResponseWriter, r *http.Request) {
 		// Send any new entries
 		for i := sentCount; i < bufLen; i++ {
 			entry := s.initOutput[i]
-			if entry.Type == "user" {
+			switch entry.Type {
+			case "tool":
+				// Tool activity line, e.g. "Ava ran go test ./..."
+				escaped := template.HTMLEscapeString(entry.Content)
+				data := `<div class="my-1 text-xs font-mono text-gray-400">` + escaped + `</div>`
+				_, _ = w.Write([]byte("event: message\ndata: " + data + "\n\n"))
+			case "user":
 				// User message with styling (escape HTML)
 				escaped := template.HTMLEscapeString(entry.Content)
 				data := `<div class="border-t border-gray-700 my-4"></div>` +
 					`<div class="py-2 px-3 bg-blue-900 bg-opacity-50 rounded-lg mb-4 text-blue-200">` +
 					`<span class="font-semibold">You:</span> ` + escaped + `</div>`
 				_, _ = w.Write([]byte("event: message\ndata: " + data + "\n\n"))
-			} else {
+			default:
 				// Assistant output - SSE requires each line to have "data: " prefix
 				// Replace newlines with SSE-compatible format
 				content := strings.ReplaceAll(entry.Content, "\n", "\ndata: ")
@@ -276,9 +384,23 @@ func (s *Server) bufferAgentOutput(prompt string) {
 		return
 	}
 	agentClient := s.initAgent
-	ctx := s.initCtx
+	agentID := s.initAgentID
+	parent := s.initCtx
+	modelReason := s.initModel.Reason
 	s.initMu.Unlock()
 
+	// Ava is listed while she works on a prompt. The first run keeps the entry
+	// spawned with her client; later ones list her again under the same ID.
+	if s.registry != nil {
+		if _, err := s.registry.Register(agentID, persona.Ava, "", s.projectDir, s.stopInitAgent); err == nil {
+			_ = s.registry.SetState(agentID, agentClient.Machine().State())
+		}
+		defer s.registry.Remove(agentID)
+	}
+
+	ctx, cancel, run := s.beginRun(parent, agentClient, persona.Ava, "init", modelReason)
+	defer cancel(nil)
+
 	// Execute the prompt and stream messages
 	msgChan, errChan := agentClient.Execute(ctx, prompt)
 
@@ -288,26 +410,47 @@ func (s *Server) bufferAgentOutput(prompt string) {
 		case msg, ok := <-msgChan:
 			if !ok {
 				// Channel closed, we're done
+				err := run.finish(nil)
 				s.initMu.Lock()
+				if err != nil {
+					s.initErr = err
+				}
 				s.initComplete = true
 				s.initMu.Unlock()
 				return
 			}
+			run.observe(msg)
+			s.recordPID(agentClient)
+			s.initMu.Lock()
 			if msg.Text != "" {
-				s.initMu.Lock()
 				s.initOutput = append(s.initOutput, initEntry{Type: "assistant", Content: msg.Text})
-				s.initMu.Unlock()
 			}
+			for _, call := range msg.ToolCalls {
+				if !call.Done {
+					s.initOutput = append(s.initOutput, initEntry{Type: "tool", Content: "Ava " + call.Summary})
+				}
+			}
+			s.initMu.Unlock()
 		case err, ok := <-errChan:
-			if ok && err != nil {
+			if !ok {
+				err = nil
+			}
+			err = run.finish(err)
+			s.initMu.Lock()
+			if err != nil {
+				s.initErr = err
+			}
+			s.initComplete = true
+			s.initMu.Unlock()
+			return
+		case <-ctx.Done():
+			if err := run.finish(context.Cause(ctx)); errors.Is(err, usage.ErrBudgetExceeded) || errors.Is(err, agent.ErrTimeout) {
 				s.initMu.Lock()
 				s.initErr = err
 				s.initComplete = true
 				s.initMu.Unlock()
 			}
 			return
-		case <-ctx.Done():
-			return
 		}
 	}
 }
@@ -342,6 +485,21 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 
 // Shutdown gracefully closes the init agent if running.
 func (s *Server) Shutdown() {
+	s.initMu.Lock()
+	agentID := s.initAgentID
+	s.initMu.Unlock()
+
+	if s.registry != nil && agentID != "" {
+		s.registry.Remove(agentID)
+	}
+	_ = s.stopInitAgent()
+	if s.sessionCancel != nil {
+		s.sessionCancel()
+	}
+}
+
+// stopInitAgent cancels and closes the init agent. It is the init agent's registry stop hook.
+func (s *Server) stopInitAgent() error {
 	s.initMu.Lock()
 	defer s.initMu.Unlock()
 	if s.initCancel != nil {
@@ -351,4 +509,6 @@ func (s *Server) Shutdown() {
 		_ = s.initAgent.Close()
 		s.initAgent = nil
 	}
+	s.initAgentID = ""
+	return nil
 }
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// SetWorkspaces enables the workspace panel, retention cleanup and the disk
+// guard that refuses new agents when free space runs low.
+func (s *Server) SetWorkspaces(m *workspace.Manager, cfg workspace.Config) {
+	s.workspaces = m
+	s.workspaceCfg = cfg
+}
+
+// checkDisk returns workspace.ErrLowDisk when there is too little space for another agent.
+func (s *Server) checkDisk() error {
+	if s.workspaces == nil {
+		return nil
+	}
+	return workspace.CheckFree(s.workspaces.Root(), s.workspaceCfg.MinFreeMB)
+}
+
+// scanWorkspaces measures disk usage and plans retention against the current case outcomes.
+func (s *Server) scanWorkspaces(r *http.Request) (workspace.Report, error) {
+	cases, err := s.caseStore.Load(s.caseFile)
+	if err != nil && !os.IsNotExist(err) {
+		return workspace.Report{}, err
+	}
+	outcomes := make(map[string]casestore.Status, len(cases))
+	for _, c := range cases {
+		outcomes[c.ID] = c.Status
+	}
+	return s.workspaces.Scan(r.Context(), s.axiomDir, s.workspaceCfg.Retention, outcomes)
+}
+
+// handleWorkspaces handles GET /api/workspaces, reporting disk usage and reclaimable workspaces as JSON.
+func (s *Server) handleWorkspaces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.workspaces == nil {
+		http.Error(w, "Workspaces not configured", http.StatusNotFound)
+		return
+	}
+
+	report, err := s.scanWorkspaces(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// workspacePanel is the view model of the workspace-list template.
+type workspacePanel struct {
+	Free, Axiom, Total, Reclaimable string
+	Rows                            []workspaceRow
+}
+
+type workspaceRow struct {
+	TaskID, State, Size, Reason string
+	Delete                      bool
+}
+
+// handleWorkspacesPanel handles GET /workspaces, rendering workspace disk usage for htmx polling.
+func (s *Server) handleWorkspacesPanel(w http.ResponseWriter, r *http.Request) {
+	if s.workspaces == nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		return
+	}
+	report, err := s.scanWorkspaces(r)
+	if err != nil {
+		log.Printf("Workspace scan error: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	panel := workspacePanel{
+		Free:        formatBytes(int64(report.FreeBytes)),
+		Axiom:       formatBytes(report.AxiomBytes),
+		Total:       formatBytes(report.TotalBytes),
+		Reclaimable: formatBytes(report.Reclaimable),
+	}
+	for _, d := range report.Workspaces {
+		panel.Rows = append(panel.Rows, workspaceRow{
+			TaskID: d.TaskID,
+			State:  d.State(),
+			Size:   formatBytes(d.Bytes),
+			Reason: d.Reason,
+			Delete: d.Delete,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := s.templates.ExecuteTemplate(&buf, "workspace-list", panel); err != nil {
+		log.Printf("Template error: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// handleWorkspacesCleanup handles POST /api/workspaces/cleanup, deleting the
+// workspaces retention marks as reclaimable.
+func (s *Server) handleWorkspacesCleanup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.workspaces == nil {
+		http.Error(w, "Workspaces not configured", http.StatusNotFound)
+		return
+	}
+
+	report, err := s.scanWorkspaces(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	freed, err := s.workspaces.Apply(r.Context(), report.Workspaces)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]int64{"freedBytes": freed})
+}
+
+// formatBytes renders n in binary units, e.g. "1.5 GB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,93 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/workspace"
+)
+
+// newWorkspaceManager returns a workspace manager for a fresh git repository.
+func newWorkspaceManager(t *testing.T) *workspace.Manager {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	m, err := workspace.New(context.Background(), dir, workspace.Config{})
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	return m
+}
+
+func TestServer_SpawnRefusedOnLowDisk(t *testing.T) {
+	// Arrange
+	server := NewServer("/nonexistent/cases.jsonl")
+	server.SetWorkspaces(newWorkspaceManager(t), workspace.Config{MinFreeMB: 1 << 40})
+
+	// Act
+	_, err := server.spawnAgent("echo", "task-001", "", nil)
+
+	// Assert
+	if !errors.Is(err, workspace.ErrLowDisk) {
+		t.Errorf("got %v, want ErrLowDisk", err)
+	}
+}
+
+func TestServer_Workspaces_ReportsUsage(t *testing.T) {
+	// Arrange
+	m := newWorkspaceManager(t)
+	if _, err := m.Create(context.Background(), "task-001"); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	server := NewServer(filepath.Join(t.TempDir(), "cases.jsonl"))
+	server.SetWorkspaces(m, workspace.Config{})
+	req := httptest.NewRequest(http.MethodGet, "/api/workspaces", http.NoBody)
+	rec := httptest.NewRecorder()
+
+	// Act
+	server.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var report workspace.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(report.Workspaces) != 1 || report.Workspaces[0].TaskID != "task-001" || report.Workspaces[0].Delete {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	panel := httptest.NewRecorder()
+	server.ServeHTTP(panel, httptest.NewRequest(http.MethodGet, "/workspaces", http.NoBody))
+	if panel.Code != http.StatusOK {
+		t.Errorf("panel got status %d: %s", panel.Code, panel.Body)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KB", 5 << 30: "5.0 GB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
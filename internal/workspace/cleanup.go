@@ -0,0 +1,103 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// Cleanup describes what Clean removed and what it kept.
+type Cleanup struct {
+	// Pruned lists tasks whose worktree directory had disappeared.
+	Pruned []string `json:"pruned,omitempty"`
+	// Orphans lists directories under the root that were not registered worktrees.
+	Orphans []string `json:"orphans,omitempty"`
+	// Branches lists task branches without a worktree that were deleted.
+	Branches []string `json:"branches,omitempty"`
+	// Kept lists task branches without a worktree that were kept because they have unmerged commits.
+	Kept []string `json:"kept,omitempty"`
+}
+
+// Clean removes what a crash can leave behind: registrations of worktrees whose
+// directory is gone, directories under the root that git does not know about
+// (an interrupted Create), and task branches that no longer have a worktree.
+// Branches with commits not on the base are kept so no work is lost.
+func (m *Manager) Clean(ctx context.Context) (Cleanup, error) {
+	var c Cleanup
+
+	trees, err := m.worktrees(ctx)
+	if err != nil {
+		return c, err
+	}
+	for _, wt := range trees {
+		if wt.prunable {
+			c.Pruned = append(c.Pruned, strings.TrimPrefix(wt.branch, BranchPrefix))
+		}
+	}
+	if _, err := gitcmd.Run(ctx, m.repoDir, "worktree", "prune"); err != nil {
+		return c, fmt.Errorf("prune worktrees: %w", err)
+	}
+	// Every registered worktree counts, detached ones included: a directory that
+	// holds one, like a scratch directory of live merges, is not an orphan.
+	if trees, err = m.allWorktrees(ctx); err != nil {
+		return c, err
+	}
+	withTree := make(map[string]bool, len(trees))
+	for _, wt := range trees {
+		withTree[wt.branch] = true
+	}
+
+	entries, err := os.ReadDir(m.root)
+	if err != nil && !os.IsNotExist(err) {
+		return c, err
+	}
+	for _, e := range entries {
+		path := filepath.Join(m.root, e.Name())
+		if !e.IsDir() || holdsWorktree(path, trees) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return c, fmt.Errorf("remove orphaned workspace %s: %w", path, err)
+		}
+		c.Orphans = append(c.Orphans, e.Name())
+	}
+
+	branches, err := gitcmd.Run(ctx, m.repoDir, "for-each-ref", "--format=%(refname:short)", "refs/heads/"+BranchPrefix)
+	if err != nil {
+		return c, err
+	}
+	for _, branch := range strings.Fields(branches) {
+		if withTree[branch] {
+			continue
+		}
+		ahead, _, err := m.divergence(ctx, branch)
+		if err != nil {
+			return c, err
+		}
+		if ahead > 0 {
+			log.Printf("[WARN] keeping %s: %d unmerged commit(s) and no workspace", branch, ahead)
+			c.Kept = append(c.Kept, branch)
+			continue
+		}
+		if err := m.deleteBranch(ctx, branch); err != nil {
+			return c, err
+		}
+		c.Branches = append(c.Branches, branch)
+	}
+	return c, nil
+}
+
+// holdsWorktree reports whether dir is a registered worktree or contains one.
+func holdsWorktree(dir string, trees []worktree) bool {
+	for _, wt := range trees {
+		if wt.path == dir || strings.HasPrefix(wt.path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+// DefaultMinFreeMB is the free space below which no new agents are spawned.
+const DefaultMinFreeMB = 1024
+
+var (
+	// ErrLowDisk is returned by CheckFree when free space is below the threshold.
+	ErrLowDisk = errors.New("not enough free disk space")
+	// errUnsupported is returned by freeSpace on platforms without a free-space query.
+	errUnsupported = errors.New("free space query not supported")
+)
+
+// DirSize returns the total size of the regular files under path. Symlinks are not followed.
+func DirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("size of %s: %w", path, err)
+	}
+	return size, nil
+}
+
+// CheckFree returns ErrLowDisk when the filesystem holding path has less than
+// minFreeMB megabytes available. It returns nil when minFreeMB is not positive
+// or free space cannot be determined on this platform.
+func CheckFree(path string, minFreeMB int) error {
+	if minFreeMB <= 0 {
+		return nil
+	}
+	// The workspace root is created with the first worktree; measure its nearest existing parent.
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil || dir == filepath.Dir(dir) {
+			path = dir
+			break
+		}
+	}
+	free, err := freeSpace(path)
+	if errors.Is(err, errUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("free space of %s: %w", path, err)
+	}
+	if want := uint64(minFreeMB) << 20; free < want {
+		return fmt.Errorf("%w: %d MB free, %d MB required", ErrLowDisk, free>>20, minFreeMB)
+	}
+	return nil
+}
+
+// Report is the disk usage of AXIOM's state and workspaces, with the retention plan.
+type Report struct {
+	// FreeBytes is the space available on the workspace filesystem; 0 if unknown.
+	FreeBytes   uint64     `json:"freeBytes"`
+	AxiomBytes  int64      `json:"axiomBytes"`
+	TotalBytes  int64      `json:"workspaceBytes"`
+	Reclaimable int64      `json:"reclaimableBytes"`
+	Workspaces  []Decision `json:"workspaces"`
+}
+
+// Scan measures axiomDir and the workspaces and plans retention.
+func (m *Manager) Scan(ctx context.Context, axiomDir string, r Retention, outcomes map[string]casestore.Status) (Report, error) {
+	var rep Report
+	var err error
+	if rep.Workspaces, err = m.Plan(ctx, r, outcomes, time.Now()); err != nil {
+		return rep, err
+	}
+	for _, d := range rep.Workspaces {
+		rep.TotalBytes += d.Bytes
+		if d.Delete {
+			rep.Reclaimable += d.Bytes
+		}
+	}
+	if rep.AxiomBytes, err = DirSize(axiomDir); err != nil {
+		return rep, err
+	}
+	rep.FreeBytes, _ = freeSpace(m.repoDir)
+	return rep, nil
+}
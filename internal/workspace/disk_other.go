@@ -0,0 +1,8 @@
+//go:build !unix
+
+package workspace
+
+// freeSpace is not implemented on this platform; the disk guard is disabled.
+func freeSpace(string) (uint64, error) {
+	return 0, errUnsupported
+}
@@ -0,0 +1,148 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// Rebase is the outcome of moving one stale workspace onto the rewritten target.
+type Rebase struct {
+	TaskID string `json:"taskId"`
+	Branch string `json:"branch"`
+
+	// OldBase is the vanished commit the branch was created from; NewBase is the target's commit.
+	OldBase string `json:"oldBase"`
+	NewBase string `json:"newBase"`
+
+	// Rebased reports that the task's commits were replayed cleanly onto NewBase.
+	Rebased bool `json:"rebased"`
+	// Conflicts lists the files that stopped the rebase.
+	Conflicts []string `json:"conflicts,omitempty"`
+	// Reason explains why a workspace that was not rebased needs review.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Stale returns the workspaces whose recorded base commit is no longer reachable
+// from target, as after a force-push or history rewrite. An empty target means
+// the configured base.
+func (m *Manager) Stale(ctx context.Context, target string) ([]Status, error) {
+	head, err := m.resolve(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []Status
+	for _, st := range statuses {
+		if st.BaseCommit == "" {
+			continue
+		}
+		ok, err := gitcmd.OK(ctx, m.repoDir, "merge-base", "--is-ancestor", st.BaseCommit, head)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			stale = append(stale, st)
+		}
+	}
+	return stale, nil
+}
+
+// Recover rebases every stale workspace onto target's current commit. Workspaces
+// that cannot be rebased cleanly are left as they were, with the reason recorded.
+// Workspaces busy reports an agent working in are skipped; busy may be nil.
+func (m *Manager) Recover(ctx context.Context, target string, busy func(taskID string) bool) ([]Rebase, error) {
+	stale, err := m.Stale(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	head, err := m.resolve(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Rebase, 0, len(stale))
+	for _, st := range stale {
+		if busy != nil && busy(st.TaskID) {
+			continue
+		}
+		results = append(results, m.rebase(ctx, st, head))
+	}
+	return results, nil
+}
+
+// RecoverTask rebases taskID's workspace onto target's current commit if its base
+// is no longer reachable from target. It returns nil when the workspace is not stale.
+func (m *Manager) RecoverTask(ctx context.Context, target, taskID string) (*Rebase, error) {
+	stale, err := m.Stale(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range stale {
+		if st.TaskID != taskID {
+			continue
+		}
+		head, err := m.resolve(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		r := m.rebase(ctx, st, head)
+		return &r, nil
+	}
+	return nil, nil
+}
+
+// rebase replays the task's own commits, those after its old base, onto newBase
+// in its worktree. A conflicting rebase is aborted, leaving the branch untouched.
+func (m *Manager) rebase(ctx context.Context, st Status, newBase string) Rebase {
+	r := Rebase{TaskID: st.TaskID, Branch: st.Branch, OldBase: st.BaseCommit, NewBase: newBase}
+	switch {
+	case st.Missing:
+		r.Reason = "worktree is missing"
+		return r
+	case st.Dirty:
+		r.Reason = "worktree has uncommitted changes"
+		return r
+	}
+
+	if _, err := gitcmd.Run(ctx, st.Path, "rebase", "--onto", newBase, st.BaseCommit); err != nil {
+		conflicts, _ := gitcmd.Run(ctx, st.Path, "diff", "--name-only", "--diff-filter=U")
+		if conflicts != "" {
+			r.Conflicts = strings.Split(conflicts, "\n")
+		}
+		if _, abortErr := gitcmd.Run(ctx, st.Path, "rebase", "--abort"); abortErr != nil {
+			r.Reason = fmt.Sprintf("rebase failed and could not be aborted: %v", abortErr)
+			return r
+		}
+		r.Reason = fmt.Sprintf("rebase onto the new base failed: %v", err)
+		if len(r.Conflicts) > 0 {
+			r.Reason = "rebase onto the new base conflicts in " + strings.Join(r.Conflicts, ", ")
+		}
+		return r
+	}
+
+	if _, err := gitcmd.Run(ctx, m.repoDir, "config", "branch."+st.Branch+"."+baseConfigKey, newBase); err != nil {
+		r.Reason = fmt.Sprintf("record new base: %v", err)
+		return r
+	}
+	r.Rebased = true
+	return r
+}
+
+// resolve returns the commit target points at; empty means the configured base.
+func (m *Manager) resolve(ctx context.Context, target string) (string, error) {
+	if target == "" {
+		target = m.base
+	}
+	commit, err := gitcmd.Run(ctx, m.repoDir, "rev-parse", "--verify", target+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("resolve %q: %w", target, err)
+	}
+	return commit, nil
+}
@@ -0,0 +1,120 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManager_RecoverAfterForcePush(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	commitFile(t, dir, "upstream.txt", "soon rewritten\n", "upstream")
+	m := newManager(t, dir)
+	clean, err := m.Create(ctx, "clean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, clean.Path, "feature.txt", "feature\n", "feature")
+	conflicting, err := m.Create(ctx, "conflicting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, conflicting.Path, "README.md", "task\n", "edit readme")
+
+	// Rewrite main: drop the upstream commit and change the README instead.
+	run(t, dir, "reset", "-q", "--hard", "HEAD~1")
+	commitFile(t, dir, "README.md", "rewritten\n", "rewritten")
+	newBase := run(t, dir, "rev-parse", "main")
+	if _, err := m.Create(ctx, "fresh"); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := m.Stale(ctx, "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stale) != 2 || stale[0].TaskID != "clean" || stale[1].TaskID != "conflicting" {
+		t.Fatalf("got stale %+v, want clean and conflicting", stale)
+	}
+
+	results, err := m.Recover(ctx, "main", func(taskID string) bool { return taskID == "fresh" })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	ok := results[0]
+	if !ok.Rebased || ok.NewBase != newBase || ok.OldBase == newBase {
+		t.Errorf("got %+v, want clean rebased onto %s", ok, newBase)
+	}
+	if got := run(t, dir, "rev-parse", "axiom/clean~1"); got != newBase {
+		t.Errorf("clean branch sits on %s, want %s", got, newBase)
+	}
+	if _, err := os.Stat(filepath.Join(clean.Path, "upstream.txt")); !os.IsNotExist(err) {
+		t.Error("rebased worktree still has the vanished upstream commit")
+	}
+	if st, err := m.Get(ctx, "clean"); err != nil || st.BaseCommit != newBase {
+		t.Errorf("got base %q (%v), want the new base recorded", st.BaseCommit, err)
+	}
+
+	bad := results[1]
+	if bad.Rebased || len(bad.Conflicts) != 1 || bad.Conflicts[0] != "README.md" || bad.Reason == "" {
+		t.Errorf("got %+v, want a README.md conflict needing review", bad)
+	}
+	if got := run(t, conflicting.Path, "status", "--porcelain"); got != "" {
+		t.Errorf("aborted rebase left changes: %q", got)
+	}
+	if got := run(t, dir, "show", "axiom/conflicting:README.md"); got != "task" {
+		t.Errorf("conflicting branch was changed: README.md = %q", got)
+	}
+
+	if stale, err := m.Stale(ctx, "main"); err != nil || len(stale) != 1 {
+		t.Errorf("got stale %+v (%v), want only the conflicting workspace left", stale, err)
+	}
+}
+
+func TestManager_RecoverSkipsBusyAndSingleTasks(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	commitFile(t, dir, "upstream.txt", "soon rewritten\n", "upstream")
+	m := newManager(t, dir)
+	for _, id := range []string{"busy", "queued"} {
+		ws, err := m.Create(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		commitFile(t, ws.Path, id+".txt", id+"\n", id)
+	}
+	run(t, dir, "reset", "-q", "--hard", "HEAD~1")
+	newBase := run(t, dir, "rev-parse", "main")
+
+	results, err := m.Recover(ctx, "main", func(taskID string) bool { return taskID == "busy" })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].TaskID != "queued" || !results[0].Rebased {
+		t.Fatalf("got %+v, want only queued rebased", results)
+	}
+
+	r, err := m.RecoverTask(ctx, "main", "queued")
+	if err != nil || r != nil {
+		t.Errorf("got %+v (%v), want nothing to do for a rebased workspace", r, err)
+	}
+	r, err = m.RecoverTask(ctx, "main", "busy")
+	if err != nil || r == nil || !r.Rebased || r.NewBase != newBase {
+		t.Errorf("got %+v (%v), want busy rebased onto %s", r, err, newBase)
+	}
+}
+
+func TestManager_StaleUnknownTarget(t *testing.T) {
+	dir := initRepo(t)
+	m := newManager(t, dir)
+
+	if _, err := m.Stale(context.Background(), "no-such-branch"); err == nil {
+		t.Error("expected error for unknown target")
+	}
+}
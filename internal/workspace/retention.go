@@ -0,0 +1,122 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+// Retention defaults applied to zero-valued fields.
+const (
+	DefaultFailedDays   = 7
+	DefaultMaxPreserved = 10
+)
+
+// Retention decides which workspaces are deleted.
+type Retention struct {
+	// FailedDays is how long workspaces of failed or timed-out tasks are preserved for inspection.
+	FailedDays int `json:"failedDays,omitempty"`
+
+	// KeepMerged keeps workspaces whose branch is merged; by default they are deleted immediately.
+	KeepMerged bool `json:"keepMerged,omitempty"`
+
+	// MaxPreserved caps the preserved failed workspaces; the oldest beyond it are deleted.
+	MaxPreserved int `json:"maxPreserved,omitempty"`
+}
+
+// withDefaults fills in zero-valued fields.
+func (r Retention) withDefaults() Retention {
+	if r.FailedDays <= 0 {
+		r.FailedDays = DefaultFailedDays
+	}
+	if r.MaxPreserved <= 0 {
+		r.MaxPreserved = DefaultMaxPreserved
+	}
+	return r
+}
+
+// Decision is what retention does with one workspace.
+type Decision struct {
+	Status
+	Delete bool   `json:"delete"`
+	Reason string `json:"reason"`
+	Bytes  int64  `json:"bytes"`
+
+	// force removes the workspace even with unmerged or uncommitted work.
+	force bool
+}
+
+// Plan applies r to every workspace. outcomes maps task IDs to their case status;
+// workspaces of tasks missing from it are kept. Nothing is deleted until Apply.
+func (m *Manager) Plan(ctx context.Context, r Retention, outcomes map[string]casestore.Status, now time.Time) ([]Decision, error) {
+	r = r.withDefaults()
+	statuses, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make([]Decision, 0, len(statuses))
+	var preserved []int
+	for _, st := range statuses {
+		d := Decision{Status: st, Reason: "in use"}
+		if !st.Missing {
+			if d.Bytes, err = DirSize(st.Path); err != nil {
+				return nil, err
+			}
+		}
+		outcome := outcomes[st.TaskID]
+
+		switch {
+		case st.Missing:
+			d.Delete, d.Reason = true, "directory missing"
+		case st.Merged && !r.KeepMerged:
+			d.Delete, d.Reason = true, "merged"
+		case outcome == casestore.StatusFailed || outcome == casestore.StatusTimeout:
+			if age := now.Sub(st.Updated); age > time.Duration(r.FailedDays)*24*time.Hour {
+				d.Delete, d.force = true, true
+				d.Reason = fmt.Sprintf("%s more than %d day(s) ago", outcome, r.FailedDays)
+			} else {
+				d.Reason = fmt.Sprintf("%s, preserved for %d day(s)", outcome, r.FailedDays)
+				preserved = append(preserved, len(decisions))
+			}
+		case outcome != "":
+			d.Reason = string(outcome)
+		}
+		decisions = append(decisions, d)
+	}
+
+	if extra := len(preserved) - r.MaxPreserved; extra > 0 {
+		sort.Slice(preserved, func(i, j int) bool {
+			return decisions[preserved[i]].Updated.Before(decisions[preserved[j]].Updated)
+		})
+		for _, i := range preserved[:extra] {
+			decisions[i].Delete, decisions[i].force = true, true
+			decisions[i].Reason = fmt.Sprintf("over the limit of %d preserved workspaces", r.MaxPreserved)
+		}
+	}
+	return decisions, nil
+}
+
+// Apply deletes the workspaces plan marks for deletion and returns the bytes freed.
+// A workspace that cannot be removed is logged and skipped.
+func (m *Manager) Apply(ctx context.Context, plan []Decision) (int64, error) {
+	var freed int64
+	for _, d := range plan {
+		if !d.Delete {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return freed, err
+		}
+		if err := m.Remove(ctx, d.TaskID, d.force); err != nil {
+			log.Printf("[WARN] retention: keeping %s: %v", d.TaskID, err)
+			continue
+		}
+		freed += d.Bytes
+	}
+	return freed, nil
+}
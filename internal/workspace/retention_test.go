@@ -0,0 +1,113 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	casestore "github.com/deligoez/axiom/internal/case"
+)
+
+func TestManager_PlanAndApply(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	m := newManager(t, dir)
+
+	for _, id := range []string{"active", "merged", "failed-old", "failed-a", "failed-b"} {
+		ws, err := m.Create(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "active" {
+			commitFile(t, ws.Path, id+".txt", id, id)
+		}
+	}
+	run(t, dir, "merge", "-q", "--no-edit", "axiom/merged")
+
+	outcomes := map[string]casestore.Status{
+		"active":     casestore.StatusActive,
+		"failed-old": casestore.StatusFailed,
+		"failed-a":   casestore.StatusTimeout,
+		"failed-b":   casestore.StatusFailed,
+	}
+	// failed-a is the oldest of the recent failures, so it goes over the limit first.
+	later := time.Now().Add(10 * 24 * time.Hour)
+	for id, age := range map[string]time.Duration{"failed-old": 30 * 24 * time.Hour, "failed-a": 2 * time.Hour, "failed-b": time.Hour} {
+		stamp := later.Add(-age)
+		if err := os.Chtimes(m.Path(id), stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plan, err := m.Plan(ctx, Retention{FailedDays: 7, MaxPreserved: 1}, outcomes, later)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make(map[string]Decision, len(plan))
+	for _, d := range plan {
+		got[d.TaskID] = d
+	}
+	for id, wantDelete := range map[string]bool{"active": false, "merged": true, "failed-old": true, "failed-a": true, "failed-b": false} {
+		if got[id].Delete != wantDelete {
+			t.Errorf("%s: delete=%v (%s), want %v", id, got[id].Delete, got[id].Reason, wantDelete)
+		}
+	}
+	if got["failed-a"].Reason != "over the limit of 1 preserved workspaces" {
+		t.Errorf("unexpected reason for failed-a: %q", got["failed-a"].Reason)
+	}
+	if got["merged"].Bytes == 0 {
+		t.Error("expected workspace size to be measured")
+	}
+
+	freed, err := m.Apply(ctx, plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if freed == 0 {
+		t.Error("expected freed bytes")
+	}
+	list, err := m.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].TaskID != "active" || list[1].TaskID != "failed-b" {
+		t.Errorf("unexpected workspaces after apply: %+v", list)
+	}
+}
+
+func TestCheckFree(t *testing.T) {
+	dir := t.TempDir()
+	if err := CheckFree(dir, 0); err != nil {
+		t.Errorf("expected disabled guard, got %v", err)
+	}
+	if _, err := freeSpace(dir); errors.Is(err, errUnsupported) {
+		t.Skip("free space not supported on this platform")
+	}
+	if err := CheckFree(dir, 1<<30); !errors.Is(err, ErrLowDisk) {
+		t.Errorf("got %v, want ErrLowDisk", err)
+	}
+	if err := CheckFree(dir, 1); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/a", make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir+"/sub", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/sub/b", make([]byte, 50), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DirSize(dir); err != nil || got != 150 {
+		t.Errorf("got %d, %v; want 150", got, err)
+	}
+	if got, err := DirSize(dir + "/missing"); err != nil || got != 0 {
+		t.Errorf("got %d, %v for missing dir", got, err)
+	}
+}
@@ -0,0 +1,201 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// Status is a workspace with its state relative to the base.
+type Status struct {
+	Workspace
+
+	// Dirty reports uncommitted or untracked changes in the worktree.
+	Dirty bool `json:"dirty"`
+	// Ahead and Behind count commits only on the branch and only on the base.
+	Ahead  int `json:"ahead"`
+	Behind int `json:"behind"`
+	// Merged reports that the branch has commits and all of them are on the base.
+	Merged bool `json:"merged"`
+	// Missing reports a registered worktree whose directory no longer exists.
+	Missing bool `json:"missing,omitempty"`
+	// Updated is the later of the branch's last commit and the worktree directory's modification.
+	Updated time.Time `json:"updated"`
+}
+
+// State summarizes the status in one word: missing, dirty, merged, ahead, behind, diverged or clean.
+func (s Status) State() string {
+	switch {
+	case s.Missing:
+		return "missing"
+	case s.Dirty:
+		return "dirty"
+	case s.Merged:
+		return "merged"
+	case s.Ahead > 0 && s.Behind > 0:
+		return "diverged"
+	case s.Ahead > 0:
+		return "ahead"
+	case s.Behind > 0:
+		return "behind"
+	}
+	return "clean"
+}
+
+// worktree is one entry of git worktree list --porcelain.
+type worktree struct {
+	path     string
+	head     string
+	branch   string
+	prunable bool
+}
+
+// List returns the status of every task worktree, sorted by task ID.
+func (m *Manager) List(ctx context.Context) ([]Status, error) {
+	trees, err := m.worktrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(trees))
+	for _, wt := range trees {
+		st, err := m.statusOf(ctx, wt)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].TaskID < statuses[j].TaskID })
+	return statuses, nil
+}
+
+// Get returns the status of taskID's worktree, or ErrNotFound.
+func (m *Manager) Get(ctx context.Context, taskID string) (Status, error) {
+	trees, err := m.worktrees(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+	for _, wt := range trees {
+		if wt.branch == Branch(taskID) {
+			return m.statusOf(ctx, wt)
+		}
+	}
+	return Status{}, fmt.Errorf("%w: %s", ErrNotFound, taskID)
+}
+
+// statusOf builds the status of a task worktree.
+func (m *Manager) statusOf(ctx context.Context, wt worktree) (Status, error) {
+	ws := Workspace{
+		TaskID: strings.TrimPrefix(wt.branch, BranchPrefix),
+		Branch: wt.branch,
+		Path:   wt.path,
+	}
+	ws.BaseCommit, _ = gitcmd.Run(ctx, m.repoDir, "config", "branch."+wt.branch+"."+baseConfigKey)
+	if wt.prunable {
+		return Status{Workspace: ws, Missing: true}, nil
+	}
+	return m.status(ctx, ws)
+}
+
+// status computes ws's state against the base.
+func (m *Manager) status(ctx context.Context, ws Workspace) (Status, error) {
+	st := Status{Workspace: ws}
+
+	changes, err := gitcmd.Run(ctx, ws.Path, "status", "--porcelain")
+	if err != nil {
+		return st, fmt.Errorf("status of %s: %w", ws.TaskID, err)
+	}
+	st.Dirty = changes != ""
+
+	if st.Ahead, st.Behind, err = m.divergence(ctx, ws.Branch); err != nil {
+		return st, err
+	}
+	if out, err := gitcmd.Run(ctx, m.repoDir, "log", "-1", "--format=%ct", "refs/heads/"+ws.Branch); err == nil {
+		if sec, err := strconv.ParseInt(out, 10, 64); err == nil {
+			st.Updated = time.Unix(sec, 0)
+		}
+	}
+	if info, err := os.Stat(ws.Path); err == nil && info.ModTime().After(st.Updated) {
+		st.Updated = info.ModTime()
+	}
+	if st.Ahead == 0 && ws.BaseCommit != "" {
+		head, err := gitcmd.Run(ctx, m.repoDir, "rev-parse", "refs/heads/"+ws.Branch)
+		if err != nil {
+			return st, err
+		}
+		// A branch still at its base commit has nothing to merge yet.
+		st.Merged = head != ws.BaseCommit
+	}
+	return st, nil
+}
+
+// divergence counts commits only on branch (ahead) and only on the base (behind).
+func (m *Manager) divergence(ctx context.Context, branch string) (ahead, behind int, err error) {
+	out, err := gitcmd.Run(ctx, m.repoDir, "rev-list", "--left-right", "--count", m.base+"...refs/heads/"+branch)
+	if err != nil {
+		return 0, 0, fmt.Errorf("compare %s with %s: %w", branch, m.base, err)
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("compare %s with %s: unexpected output %q", branch, m.base, out)
+	}
+	behind, _ = strconv.Atoi(fields[0])
+	ahead, _ = strconv.Atoi(fields[1])
+	return ahead, behind, nil
+}
+
+// worktrees returns the registered worktrees on task branches.
+func (m *Manager) worktrees(ctx context.Context) ([]worktree, error) {
+	all, err := m.allWorktrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var trees []worktree
+	for _, wt := range all {
+		if strings.HasPrefix(wt.branch, BranchPrefix) {
+			trees = append(trees, wt)
+		}
+	}
+	return trees, nil
+}
+
+// allWorktrees returns every registered worktree, including the main one and
+// detached worktrees such as the integration queue's.
+func (m *Manager) allWorktrees(ctx context.Context) ([]worktree, error) {
+	out, err := gitcmd.Run(ctx, m.repoDir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+
+	var trees []worktree
+	var cur worktree
+	flush := func() {
+		if cur.path != "" {
+			trees = append(trees, cur)
+		}
+		cur = worktree{}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "":
+			flush()
+		case "worktree":
+			cur.path = filepath.Clean(value)
+		case "HEAD":
+			cur.head = value
+		case "branch":
+			cur.branch = strings.TrimPrefix(value, "refs/heads/")
+		case "prunable":
+			cur.prunable = true
+		}
+	}
+	flush()
+	return trees, nil
+}
@@ -0,0 +1,230 @@
+// Package workspace isolates tasks in git worktrees: one worktree per task under
+// .workspaces/<taskId>, on branch axiom/<taskId>.
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// Defaults applied to zero-valued Config fields.
+const (
+	DefaultRoot   = ".workspaces"
+	DefaultBase   = "HEAD"
+	BranchPrefix  = "axiom/"
+	baseConfigKey = "axiomBase"
+)
+
+var (
+	// ErrExists is returned by Create when the task already has a worktree or branch.
+	ErrExists = errors.New("workspace already exists")
+	// ErrNotFound is returned for tasks without a worktree.
+	ErrNotFound = errors.New("workspace not found")
+	// ErrDirty is returned by Remove for worktrees with uncommitted changes.
+	ErrDirty = errors.New("workspace has uncommitted changes")
+	// ErrUnmerged is returned by Remove for branches with commits not on the base.
+	ErrUnmerged = errors.New("workspace has unmerged commits")
+	// ErrInvalidTaskID is returned for task IDs that are not safe as a directory and branch name.
+	ErrInvalidTaskID = errors.New("invalid task id")
+)
+
+// taskIDPattern matches IDs such as "task-001" or "auth.login_2".
+var taskIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Config is the "workspaces" section of .axiom/config.json.
+type Config struct {
+	// Root is the directory holding worktrees, relative to the repository. Default ".workspaces".
+	Root string `json:"root,omitempty"`
+
+	// Base is the branch or commit new task branches start from. Default "HEAD".
+	Base string `json:"base,omitempty"`
+
+	// Retention decides when workspaces are deleted.
+	Retention Retention `json:"retention"`
+
+	// MinFreeMB is the free disk space below which no new agents are spawned; 0 disables the guard.
+	MinFreeMB int `json:"minFreeMB"`
+}
+
+// Workspace is one task's worktree.
+type Workspace struct {
+	TaskID string `json:"taskId"`
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+
+	// BaseCommit is the commit the branch was created from.
+	BaseCommit string `json:"baseCommit,omitempty"`
+}
+
+// Manager creates and removes task worktrees for one repository.
+type Manager struct {
+	repoDir string
+	root    string
+	base    string
+}
+
+// New returns a Manager for the git repository at repoDir.
+func New(ctx context.Context, repoDir string, cfg Config) (*Manager, error) {
+	top, err := gitcmd.Run(ctx, repoDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	top, err = filepath.EvalSymlinks(top)
+	if err != nil {
+		return nil, err
+	}
+
+	root := cfg.Root
+	if root == "" {
+		root = DefaultRoot
+	}
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(top, root)
+	}
+	base := cfg.Base
+	if base == "" {
+		base = DefaultBase
+	}
+	return &Manager{repoDir: top, root: filepath.Clean(root), base: base}, nil
+}
+
+// Root returns the directory holding the worktrees.
+func (m *Manager) Root() string { return m.root }
+
+// Branch returns the branch name for taskID.
+func Branch(taskID string) string { return BranchPrefix + taskID }
+
+// Path returns the worktree directory for taskID.
+func (m *Manager) Path(taskID string) string { return filepath.Join(m.root, taskID) }
+
+// Create adds a worktree for taskID on a new branch axiom/<taskID> from the configured base.
+func (m *Manager) Create(ctx context.Context, taskID string) (*Workspace, error) {
+	if !taskIDPattern.MatchString(taskID) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTaskID, taskID)
+	}
+	branch := Branch(taskID)
+	path := m.Path(taskID)
+
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrExists, path)
+	}
+	if exists, err := gitcmd.OK(ctx, m.repoDir, "show-ref", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, fmt.Errorf("%w: branch %s", ErrExists, branch)
+	}
+
+	baseCommit, err := gitcmd.Run(ctx, m.repoDir, "rev-parse", "--verify", m.base+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("resolve base %q: %w", m.base, err)
+	}
+	if err := m.exclude(); err != nil {
+		return nil, err
+	}
+	if _, err := gitcmd.Run(ctx, m.repoDir, "worktree", "add", "-b", branch, path, baseCommit); err != nil {
+		return nil, fmt.Errorf("create workspace %s: %w", taskID, err)
+	}
+	if _, err := gitcmd.Run(ctx, m.repoDir, "config", "branch."+branch+"."+baseConfigKey, baseCommit); err != nil {
+		return nil, fmt.Errorf("record base of %s: %w", branch, err)
+	}
+	return &Workspace{TaskID: taskID, Branch: branch, Path: path, BaseCommit: baseCommit}, nil
+}
+
+// Remove deletes the worktree and branch for taskID. Unless force is set, it refuses
+// worktrees with uncommitted changes (ErrDirty) and branches with commits that are
+// not on the base (ErrUnmerged).
+func (m *Manager) Remove(ctx context.Context, taskID string, force bool) error {
+	st, err := m.Get(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if st.Missing {
+		if st.Ahead, _, err = m.divergence(ctx, st.Branch); err != nil {
+			return err
+		}
+	}
+	if !force {
+		if st.Dirty {
+			return fmt.Errorf("%w: %s", ErrDirty, taskID)
+		}
+		if st.Ahead > 0 {
+			return fmt.Errorf("%w: %s is %d commit(s) ahead of %s", ErrUnmerged, taskID, st.Ahead, m.base)
+		}
+	}
+
+	if st.Missing {
+		if _, err := gitcmd.Run(ctx, m.repoDir, "worktree", "prune"); err != nil {
+			return fmt.Errorf("remove workspace %s: %w", taskID, err)
+		}
+	} else {
+		args := []string{"worktree", "remove", st.Path}
+		if force {
+			args = []string{"worktree", "remove", "--force", st.Path}
+		}
+		if _, err := gitcmd.Run(ctx, m.repoDir, args...); err != nil {
+			return fmt.Errorf("remove workspace %s: %w", taskID, err)
+		}
+	}
+	return m.deleteBranch(ctx, st.Branch)
+}
+
+// deleteBranch deletes a task branch and its recorded base.
+func (m *Manager) deleteBranch(ctx context.Context, branch string) error {
+	if _, err := gitcmd.Run(ctx, m.repoDir, "branch", "-D", branch); err != nil {
+		return fmt.Errorf("delete branch %s: %w", branch, err)
+	}
+	// The config section goes with the branch in recent git versions; this covers older ones.
+	_, _ = gitcmd.Run(ctx, m.repoDir, "config", "--unset", "branch."+branch+"."+baseConfigKey)
+	return nil
+}
+
+// exclude adds the worktree root to .git/info/exclude when it lies inside the
+// repository, so task worktrees never show up as untracked files.
+func (m *Manager) exclude() error {
+	rel, err := filepath.Rel(m.repoDir, m.root)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	pattern := "/" + filepath.ToSlash(rel) + "/"
+
+	gitDir, err := gitcmd.Run(context.Background(), m.repoDir, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(m.repoDir, gitDir)
+	}
+	path := filepath.Join(gitDir, "info", "exclude")
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		pattern = "\n" + pattern
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(pattern + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,246 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deligoez/axiom/internal/gitcmd"
+)
+
+// initRepo creates a git repository with one commit on main.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	run(t, dir, "init", "-q", "-b", "main")
+	commitFile(t, dir, "README.md", "hello\n", "initial")
+	return dir
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := gitcmd.Run(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return out
+}
+
+func commitFile(t *testing.T, dir, name, content, msg string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	run(t, dir, "add", name)
+	run(t, dir, "commit", "-q", "-m", msg)
+}
+
+func newManager(t *testing.T, dir string) *Manager {
+	t.Helper()
+	m, err := New(context.Background(), dir, Config{Base: "main"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m
+}
+
+func TestManager_CreateAndList(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	m := newManager(t, dir)
+
+	ws, err := m.Create(ctx, "task-001")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ws.Branch != "axiom/task-001" || ws.Path != filepath.Join(m.Root(), "task-001") {
+		t.Errorf("unexpected workspace: %+v", ws)
+	}
+	if got := run(t, ws.Path, "rev-parse", "--abbrev-ref", "HEAD"); got != "axiom/task-001" {
+		t.Errorf("worktree on branch %q", got)
+	}
+	if got := run(t, dir, "status", "--porcelain"); got != "" {
+		t.Errorf("expected workspaces to be excluded from the main checkout, got %q", got)
+	}
+
+	if _, err := m.Create(ctx, "task-001"); !errors.Is(err, ErrExists) {
+		t.Errorf("got %v, want ErrExists", err)
+	}
+	if _, err := m.Create(ctx, "../escape"); !errors.Is(err, ErrInvalidTaskID) {
+		t.Errorf("got %v, want ErrInvalidTaskID", err)
+	}
+
+	list, err := m.List(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list) != 1 || list[0].TaskID != "task-001" || list[0].State() != "clean" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+}
+
+func TestManager_Status(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	m := newManager(t, dir)
+	ws, err := m.Create(ctx, "task-002")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state := func() Status {
+		t.Helper()
+		st, err := m.Get(ctx, "task-002")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return st
+	}
+
+	if err := os.WriteFile(filepath.Join(ws.Path, "new.txt"), []byte("wip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if st := state(); st.State() != "dirty" {
+		t.Errorf("got %s, want dirty", st.State())
+	}
+
+	run(t, ws.Path, "add", "new.txt")
+	run(t, ws.Path, "commit", "-q", "-m", "work")
+	if st := state(); st.State() != "ahead" || st.Ahead != 1 {
+		t.Errorf("got %+v, want 1 ahead", st)
+	}
+
+	commitFile(t, dir, "other.txt", "x", "base moves")
+	if st := state(); st.State() != "diverged" || st.Behind != 1 {
+		t.Errorf("got %+v, want diverged", st)
+	}
+
+	run(t, dir, "merge", "-q", "--no-edit", "axiom/task-002")
+	if st := state(); st.State() != "merged" {
+		t.Errorf("got %+v, want merged", st)
+	}
+}
+
+func TestManager_Remove(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	m := newManager(t, dir)
+	ws, err := m.Create(ctx, "task-003")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commitFile(t, ws.Path, "feature.go", "package x\n", "feature")
+
+	if err := m.Remove(ctx, "task-003", false); !errors.Is(err, ErrUnmerged) {
+		t.Errorf("got %v, want ErrUnmerged", err)
+	}
+	if err := os.WriteFile(filepath.Join(ws.Path, "feature.go"), []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Remove(ctx, "task-003", false); !errors.Is(err, ErrDirty) {
+		t.Errorf("got %v, want ErrDirty", err)
+	}
+
+	if err := m.Remove(ctx, "task-003", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(ws.Path); !os.IsNotExist(err) {
+		t.Errorf("expected worktree directory removed, got %v", err)
+	}
+	if out := run(t, dir, "branch", "--list", "axiom/*"); out != "" {
+		t.Errorf("expected branch deleted, got %q", out)
+	}
+	if err := m.Remove(ctx, "task-003", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestManager_Clean(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	m := newManager(t, dir)
+
+	// A worktree whose directory vanished, with no work on its branch.
+	gone, err := m.Create(ctx, "gone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A worktree whose directory vanished after it committed work.
+	lost, err := m.Create(ctx, "lost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, lost.Path, "work.txt", "x", "work")
+	// A healthy worktree.
+	if _, err := m.Create(ctx, "live"); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{gone.Path, lost.Path} {
+		if err := os.RemoveAll(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A directory left by an interrupted Create.
+	if err := os.MkdirAll(filepath.Join(m.Root(), "half"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := m.Clean(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(c.Pruned, ",") != "gone,lost" {
+		t.Errorf("pruned %v", c.Pruned)
+	}
+	if strings.Join(c.Orphans, ",") != "half" {
+		t.Errorf("orphans %v", c.Orphans)
+	}
+	if strings.Join(c.Branches, ",") != "axiom/gone" || strings.Join(c.Kept, ",") != "axiom/lost" {
+		t.Errorf("deleted %v, kept %v", c.Branches, c.Kept)
+	}
+
+	list, err := m.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].TaskID != "live" {
+		t.Errorf("unexpected list after clean: %+v", list)
+	}
+}
+
+func TestManager_CleanKeepsDetachedWorktrees(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t)
+	m := newManager(t, dir)
+
+	// A merge in progress in a scratch directory under the root, and one directly in it.
+	scratch := filepath.Join(m.Root(), ".integration", "task-001-123")
+	run(t, dir, "worktree", "add", "--detach", scratch, "main")
+	detached := filepath.Join(m.Root(), "merge")
+	run(t, dir, "worktree", "add", "--detach", detached, "main")
+
+	c, err := m.Clean(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.Orphans) != 0 {
+		t.Errorf("orphans %v", c.Orphans)
+	}
+	for _, p := range []string{scratch, detached} {
+		if _, err := os.Stat(filepath.Join(p, "README.md")); err != nil {
+			t.Errorf("worktree %s removed: %v", p, err)
+		}
+	}
+}